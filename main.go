@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	// -----------------------------------------------------------
@@ -13,10 +17,18 @@ import (
 	// "github.com/wes-and-me/api-server-project/pkg/types"
 	// "github.com/wes-and-me/api-server-project/pkg/utils"
 	// "github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/blob"
+	"github.com/wesleywinston/wds/pkg/handlers"
+	"github.com/wesleywinston/wds/pkg/mail"
+	"github.com/wesleywinston/wds/pkg/middleware"
 	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/omma"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/services"
+	"github.com/wesleywinston/wds/pkg/strains"
 	"github.com/wesleywinston/wds/pkg/utils"
-	"github.com/wesleywinston/wds/pkg/handlers"
 
+	"cloud.google.com/go/firestore"
 	"github.com/gorilla/mux"
 )
 
@@ -28,7 +40,7 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 	vendorEntity := models.Vendor{
 		ID:                    "vendor_213",
 		BusinessName:          "Green Harvest Farms",
-		OKStateLicenseID:      "PAAA-DJ3F-28JJ-283H", // G - grow , P - processor , D - dispensary
+		OKStateLicenseID:      "PAAA-DJ3F-28JJ-283H",       // G - grow , P - processor , D - dispensary
 		LicenseExpirationDate: time.Now().AddDate(0, 3, 0), // Expires in 3 months
 		Status:                "",
 		ComplianceStatus:      "VERIFIED",
@@ -63,24 +75,213 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "%s\nUser ID: %s", message, sampleUser.ID)
 }
 
+// tokenSecret reads the token signing key from WDS_TOKEN_SECRET. Without it we fall back
+// to a random per-process key, which means every restart logs all users out.
+func tokenSecret() []byte {
+	if secret := os.Getenv("WDS_TOKEN_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	log.Println("WARNING: WDS_TOKEN_SECRET is not set; using a random signing key for this process.")
+	return []byte(utils.NewID("secret"))
+}
+
+// bootstrapToken reads the one-time token for creating the first admin from
+// WDS_ADMIN_BOOTSTRAP_TOKEN. Without it we generate one and log it, so a local
+// run can still bootstrap an admin.
+func bootstrapToken() string {
+	if token := os.Getenv("WDS_ADMIN_BOOTSTRAP_TOKEN"); token != "" {
+		return token
+	}
+	token := utils.NewID("bootstrap")
+	log.Printf("WARNING: WDS_ADMIN_BOOTSTRAP_TOKEN is not set; the admin bootstrap token for this process is %s", token)
+	return token
+}
+
+// newStore connects to Firestore when FIRESTORE_PROJECT_ID is set and otherwise
+// falls back to an in-memory store, which is what local dev and tests use.
+func newStore(ctx context.Context) *repository.Store {
+	projectID := os.Getenv("FIRESTORE_PROJECT_ID")
+	if projectID == "" {
+		log.Println("FIRESTORE_PROJECT_ID is not set; using in-memory storage.")
+		return repository.NewMemoryStore()
+	}
+
+	client, err := firestore.NewClient(ctx, projectID)
+	if err != nil {
+		log.Fatalf("Failed to create Firestore client: %v", err)
+	}
+	return repository.NewFirestoreStore(client)
+}
+
+// newLicenseVerifier returns an OMMA client for OMMA_BASE_URL. When it is unset we
+// start an in-process fake OMMA server so local runs still go over real HTTP.
+func newLicenseVerifier() services.LicenseVerifier {
+	baseURL := os.Getenv("OMMA_BASE_URL")
+	if baseURL == "" {
+		fake := omma.NewFakeServer(omma.SeedLicenses(time.Now()))
+		log.Printf("OMMA_BASE_URL is not set; using fake OMMA server at %s", fake.URL)
+		baseURL = fake.URL
+	}
+	return omma.NewClient(baseURL,
+		omma.WithAPIKey(os.Getenv("OMMA_API_KEY")),
+		omma.WithTimeout(5*time.Second),
+		omma.WithRetries(3, 250*time.Millisecond),
+	)
+}
+
+// newBlobStore returns the store for uploaded files. Only the local filesystem
+// store exists so far; WDS_BLOB_DIR picks its directory (default ./data/blobs).
+func newBlobStore() blob.Store {
+	dir := os.Getenv("WDS_BLOB_DIR")
+	if dir == "" {
+		dir = "data/blobs"
+	}
+	files, err := blob.NewLocalStore(dir)
+	if err != nil {
+		log.Fatalf("Failed to open blob store: %v", err)
+	}
+	log.Printf("Storing uploaded files in %s", dir)
+	return files
+}
+
+// newMailSender returns an SMTP sender for WDS_SMTP_ADDR ("host:port"), logging
+// in with WDS_SMTP_USERNAME and WDS_SMTP_PASSWORD if set. Without an address,
+// mail is logged and dropped by a fake sender.
+func newMailSender() mail.Sender {
+	addr := os.Getenv("WDS_SMTP_ADDR")
+	if addr == "" {
+		log.Println("WDS_SMTP_ADDR is not set; email will be logged instead of sent.")
+		return mail.NewFakeSender()
+	}
+	return mail.NewSMTPSender(addr, os.Getenv("WDS_SMTP_USERNAME"), os.Getenv("WDS_SMTP_PASSWORD"))
+}
+
+// mailFrom is the sender address for outgoing email: WDS_MAIL_FROM, defaulting to a no-reply address.
+func mailFrom() string {
+	if from := os.Getenv("WDS_MAIL_FROM"); from != "" {
+		return from
+	}
+	return "no-reply@wds.local"
+}
+
+// licenseReminderDays reads the reminder lead times from WDS_LICENSE_REMINDER_DAYS,
+// i.e. "60,30,7", falling back to the defaults.
+func licenseReminderDays() []int {
+	raw := os.Getenv("WDS_LICENSE_REMINDER_DAYS")
+	if raw == "" {
+		return services.DefaultLicenseReminderDays
+	}
+	var days []int
+	for _, field := range strings.Split(raw, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n <= 0 {
+			log.Fatalf("Invalid WDS_LICENSE_REMINDER_DAYS %q: lead times must be positive whole days", raw)
+		}
+		days = append(days, n)
+	}
+	return days
+}
+
+// reportingLocation is the timezone dashboards bucket sales by: WDS_TIMEZONE,
+// defaulting to Oklahoma's.
+func reportingLocation() *time.Location {
+	name := os.Getenv("WDS_TIMEZONE")
+	if name == "" {
+		name = "America/Chicago"
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("WARNING: could not load timezone %s (%v); reporting in UTC.", name, err)
+		return time.UTC
+	}
+	return loc
+}
+
+// taxConfig loads the tax rate table from WDS_TAX_CONFIG, falling back to the built-in defaults.
+func taxConfig() services.TaxConfig {
+	path := os.Getenv("WDS_TAX_CONFIG")
+	if path == "" {
+		return services.DefaultTaxConfig()
+	}
+	cfg, err := services.LoadTaxConfig(path)
+	if err != nil {
+		log.Fatalf("Failed to load tax config: %v", err)
+	}
+	return cfg
+}
+
 func main() {
 	// Initialize the router
 	r := mux.NewRouter()
 
+	// Storage and auth dependencies shared by the handlers
+	store := newStore(context.Background())
+	tokens := services.NewTokenIssuer(tokenSecret(), 24*time.Hour)
+	auth := middleware.NewAuthenticator(store.Users, tokens)
+	verifier := newLicenseVerifier()
+	audit := services.NewAuditLog(store.Audit)
+
+	// Remind businesses to renew before their licenses lapse, by email and in-app.
+	// Lapses and status changes found by the license scheduler also go to admins.
+	notifications := services.NewNotificationService(store, licenseReminderDays(), services.NewEmailChannel(newMailSender(), mailFrom()))
+	go notifications.Run(context.Background(), 24*time.Hour)
+
+	// Re-verify every business license against state records once a day.
+	scheduler := services.NewLicenseScheduler(store.Vendors, store.Buyers, verifier, audit, notifications, 24*time.Hour)
+	go scheduler.Run(context.Background())
+
+	// Transactional routes check the caller's license against our records first.
+	// Results are cached for a minute; failures queue an immediate re-verification.
+	gate := services.NewComplianceGate(store, scheduler, time.Minute)
+	compliant := func(h http.Handler) http.Handler { return middleware.RequireCompliance(gate, h) }
+
+	// Reserved stock is held for two days awaiting vendor acceptance.
+	orders := services.NewOrderService(store, services.NewTaxEngine(taxConfig()), gate, audit, 48*time.Hour)
+	go orders.RunReservationSweeper(context.Background(), 15*time.Minute)
+
+	// Invitations to join a business are valid for a week.
+	memberships := services.NewMembershipService(store, audit, 7*24*time.Hour)
+	admin := services.NewAdminService(store, audit, gate, bootstrapToken(), 7*24*time.Hour)
+	coas := services.NewCOAService(store, newBlobStore(), audit)
+	vaults := services.NewVaultService(store, orders)
+	community := services.NewCommunityService(store, audit)
+	details := services.NewProductDetailService(store)
+
+	loc := reportingLocation()
+	dashboards := services.NewDashboardService(store, loc)
+
+	// Refresh the strain reference database once a week from WDS_STRAIN_SOURCE
+	// (a file path or URL); without one, the built-in seed dataset is used.
+	strainDB := services.NewStrainService(store, strains.NewSource(os.Getenv("WDS_STRAIN_SOURCE")), audit, 7*24*time.Hour)
+	go strainDB.Run(context.Background())
+
+	// Test results now only come from uploaded COAs; drop ones vendors entered by hand.
+	if n, err := services.MigrateUnverifiedTestStatus(context.Background(), store.Products, audit); err != nil {
+		log.Fatalf("Test status migration failed: %v", err)
+	} else if n > 0 {
+		log.Printf("Reset unverified test status on %d products.", n)
+	}
+	// Move compliance attributes out of free-form tags on products saved before
+	// Product.Compliance existed. Already-migrated products are skipped.
+	if n, err := services.MigrateComplianceTags(context.Background(), store.Products, audit); err != nil {
+		log.Fatalf("Compliance tag migration failed: %v", err)
+	} else if n > 0 {
+		log.Printf("Migrated compliance tags on %d products.", n)
+	}
+	// Store categories in canonical form so marketplace searches can filter on them in the database.
+	if n, err := services.MigrateProductCategories(context.Background(), store.Products, audit); err != nil {
+		log.Fatalf("Product category migration failed: %v", err)
+	} else if n > 0 {
+		log.Printf("Migrated categories on %d products.", n)
+	}
+
 	// routes
 	//
 	// / (default homepage)
-	// /product/:id
-	// /vendor/dashboard
 	// auth/login
 	// auth/signup
-	// /vault
-	// community/feed
 	//
 
-	// internal database scrape for updating strain list
-	// run once a week and pull new info
-
 	// Define a simple route
 	r.HandleFunc("/", homeHandler).Methods("GET")
 
@@ -88,11 +289,145 @@ func main() {
 	// --- AUTHENTICATION ROUTES ---
 	// Endpoint: POST /auth/signup
 	// We use .Methods("POST") to ensure this handler only runs for POST requests.
-	r.HandleFunc("/auth/signup", handlers.SignupHandler).Methods("POST")
-	
+	r.HandleFunc("/auth/signup", handlers.SignupHandler(store.Users, tokens, audit)).Methods("POST")
+
 	// Endpoint: POST /auth/login
 	// We use .Methods("POST") to ensure this handler only runs for POST requests.
-	r.HandleFunc("/auth/login", handlers.LoginHandler).Methods("POST")
+	r.HandleFunc("/auth/login", handlers.LoginHandler(store.Users, tokens)).Methods("POST")
+
+	// Endpoint: GET /auth/me
+	// Any signed-in user, including ones still pending approval, may read their own account.
+	r.Handle("/auth/me", auth.Require(middleware.Policy{
+		Statuses: []models.AccountStatus{models.StatusActive, models.StatusPending},
+	}, handlers.MeHandler(store.Vendors, store.Buyers))).Methods("GET")
+
+	// --- ONBOARDING ROUTES ---
+	// A vendor or buyer user registers their business (and has its license verified), which links it to their account.
+	// New accounts may do this while pending approval, so admins review the account and business together.
+	onboarding := func(role models.UserRole) middleware.Policy {
+		return middleware.Policy{Roles: []models.UserRole{role}, Statuses: []models.AccountStatus{models.StatusActive, models.StatusPending}}
+	}
+	r.Handle("/vendors/register", auth.Require(onboarding(models.RoleVendor), handlers.RegisterVendor(store.Vendors, store.Users, verifier, audit))).Methods("POST")
+	r.Handle("/buyers/register", auth.Require(onboarding(models.RoleBuyer), handlers.RegisterBuyer(store.Buyers, store.Users, verifier, audit))).Methods("POST")
+
+	// --- ADMIN ROUTES ---
+	// Endpoint: POST /admin/bootstrap
+	// Public, but only creates an admin while none exists and the bootstrap token matches.
+	adminOnly := middleware.Roles(models.RoleAdmin)
+	r.HandleFunc("/admin/bootstrap", handlers.BootstrapAdmin(admin, tokens)).Methods("POST")
+	r.Handle("/admin/invitations", auth.Require(adminOnly, handlers.InviteAdmin(admin))).Methods("POST")
+	r.Handle("/admin/review-queue", auth.Require(adminOnly, handlers.ReviewQueue(admin))).Methods("GET")
+	r.Handle("/admin/users/{userID}/status", auth.Require(adminOnly, handlers.UpdateAccountStatus(admin))).Methods("POST")
+	r.Handle("/admin/businesses/{entityID}/status", auth.Require(adminOnly, handlers.SetBusinessStatus(admin))).Methods("POST")
+
+	// Admins search the audit log, or export it as JSON lines for regulators.
+	r.Handle("/admin/audit", auth.Require(adminOnly, handlers.QueryAuditLog(audit))).Methods("GET")
+	r.Handle("/admin/audit/export", auth.Require(adminOnly, handlers.ExportAuditLog(audit))).Methods("GET")
+
+	// Endpoint: POST /users
+	// Admins provision accounts linked to an existing business.
+	r.Handle("/users", auth.Require(adminOnly, handlers.CreateUser(store.Users, store.Vendors, store.Buyers, memberships, audit))).Methods("POST")
+
+	// --- BUSINESS MEMBERSHIP ROUTES ---
+	tradingParties := middleware.Roles(models.RoleBuyer, models.RoleVendor)
+	// Members of a vendor or buyer business manage their teammates; the membership service enforces owner/manager/staff rights.
+	r.Handle("/business/members", auth.Require(tradingParties, handlers.ListMembers(memberships))).Methods("GET")
+	r.Handle("/business/members/{userID}", auth.Require(tradingParties, handlers.UpdateMemberRole(memberships))).Methods("PUT")
+	r.Handle("/business/members/{userID}", auth.Require(tradingParties, handlers.RemoveMember(memberships))).Methods("DELETE")
+	r.Handle("/business/invitations", auth.Require(tradingParties, handlers.ListInvitations(memberships))).Methods("GET")
+	r.Handle("/business/invitations", auth.Require(tradingParties, handlers.InviteMember(memberships))).Methods("POST")
+	r.Handle("/business/invitations/{invitationID}", auth.Require(tradingParties, handlers.RevokeInvitation(memberships))).Methods("DELETE")
+
+	// Endpoint: POST /invitations/accept
+	// Public: the invitation token identifies the invitee, who may not have an account yet.
+	r.HandleFunc("/invitations/accept", handlers.AcceptInvitation(memberships, tokens)).Methods("POST")
+
+	// --- CATALOG ROUTES ---
+	// Vendors manage their own products; buyers cannot edit catalogs.
+	// Listing or changing products needs a compliant vendor; removing them doesn't.
+	vendorOnly := middleware.Roles(models.RoleVendor)
+	r.Handle("/vendor/products", auth.Require(vendorOnly, handlers.ListVendorProducts(store.Products))).Methods("GET")
+	r.Handle("/vendor/products", auth.Require(vendorOnly, compliant(handlers.CreateProduct(store.Products, strainDB, audit)))).Methods("POST")
+	r.Handle("/vendor/products/{productID}", auth.Require(vendorOnly, compliant(handlers.UpdateProduct(store.Products, strainDB, audit)))).Methods("PUT")
+	r.Handle("/vendor/products/{productID}", auth.Require(vendorOnly, handlers.DeleteProduct(store.Products, audit))).Methods("DELETE")
+	// Stock only changes by adjustment, atomically with order reservations, so a restock can't undo a sale.
+	r.Handle("/vendor/products/{productID}/stock", auth.Require(vendorOnly, handlers.AdjustStock(store.Products, audit))).Methods("POST")
+	r.Handle("/vendor/menu", auth.Require(vendorOnly, handlers.SetMenuEnabled(store.Vendors, audit))).Methods("PUT")
+
+	// Endpoint: GET /vendor/dashboard
+	// Sales, top products, open orders, low stock and license countdown for the caller's business.
+	r.Handle("/vendor/dashboard", auth.Require(vendorOnly, handlers.VendorDashboard(dashboards, loc))).Methods("GET")
+
+	// Endpoint: POST /vendor/products/{productID}/coa
+	// Certificates of analysis are uploaded as multipart forms; the parsed lab results land on the product.
+	r.Handle("/vendor/products/{productID}/coa", auth.Require(vendorOnly, compliant(handlers.UploadCOA(coas)))).Methods("POST")
+
+	// Endpoint: GET /products/{productID}
+	// Product detail with the vendor's compliance badge, so buyers can see at a glance whether it's safe to buy.
+	r.Handle("/products/{productID}", auth.Require(middleware.Policy{}, handlers.GetProductDetail(details))).Methods("GET")
+
+	// Endpoint: GET /products/{productID}/coa
+	// The original COA is visible to whoever can see the product.
+	r.Handle("/products/{productID}/coa", auth.Require(middleware.Policy{}, handlers.DownloadCOA(coas))).Methods("GET")
+
+	// Any signed-in user may browse a vendor's live menu.
+	r.Handle("/vendors/{vendorID}/products", auth.Require(middleware.Policy{}, handlers.GetVendorCatalog(store.Vendors, store.Products))).Methods("GET")
+
+	// --- STRAIN ROUTES ---
+	// The strain reference database is readable by anyone signed in; admins can force an import.
+	r.Handle("/strains", auth.Require(middleware.Policy{}, handlers.ListStrains(strainDB))).Methods("GET")
+	r.Handle("/strains/{strainID}", auth.Require(middleware.Policy{}, handlers.GetStrain(strainDB))).Methods("GET")
+	r.Handle("/admin/strains/import", auth.Require(adminOnly, handlers.ImportStrains(strainDB))).Methods("POST")
+
+	// --- MARKETPLACE ROUTES ---
+	r.Handle("/marketplace/search", auth.Require(middleware.Roles(models.RoleBuyer, models.RoleAdmin), handlers.SearchMarketplace(store.Vendors, store.Products))).Methods("GET")
+
+	// --- ORDER ROUTES ---
+	// Only buyers place orders; vendors cannot place buyer orders.
+	// Both sides move orders through their lifecycle; the order state machine decides which moves each role may make.
+	// Placing an order checks both the buyer and the vendor; status changes re-check the caller unless they cancel or complete.
+	r.Handle("/orders", auth.Require(middleware.Roles(models.RoleBuyer), compliant(handlers.PlaceOrder(orders)))).Methods("POST")
+	r.Handle("/orders", auth.Require(tradingParties, handlers.ListOrders(orders))).Methods("GET")
+	r.Handle("/orders/{orderID}/status", auth.Require(tradingParties, handlers.UpdateOrderStatus(orders))).Methods("POST")
+
+	// --- VAULT ROUTES ---
+	// A buyer business's saved vendors, favorite products and reorder lists, shared by its whole team.
+	// Ordering a list places a normal order, re-checked against current price, stock and licenses.
+	buyerOnly := middleware.Roles(models.RoleBuyer)
+	r.Handle("/vault", auth.Require(buyerOnly, handlers.GetVault(vaults))).Methods("GET")
+	r.Handle("/vault/vendors/{vendorID}", auth.Require(buyerOnly, handlers.SetFavoriteVendor(vaults))).Methods("PUT", "DELETE")
+	r.Handle("/vault/products/{productID}", auth.Require(buyerOnly, handlers.SetFavoriteProduct(vaults))).Methods("PUT", "DELETE")
+	r.Handle("/vault/lists", auth.Require(buyerOnly, handlers.CreateReorderList(vaults))).Methods("POST")
+	r.Handle("/vault/lists/{listID}", auth.Require(buyerOnly, handlers.UpdateReorderList(vaults))).Methods("PUT")
+	r.Handle("/vault/lists/{listID}", auth.Require(buyerOnly, handlers.DeleteReorderList(vaults))).Methods("DELETE")
+	r.Handle("/vault/lists/{listID}/order", auth.Require(buyerOnly, compliant(handlers.PlaceReorder(vaults)))).Methods("POST")
+
+	// --- COMMUNITY ROUTES ---
+	// Anyone signed in can read the feed; compliant vendors and buyers post, comment, react and report.
+	// Content from businesses that are out of compliance or banned is hidden automatically.
+	r.Handle("/community/feed", auth.Require(middleware.Policy{}, handlers.GetFeed(community))).Methods("GET")
+	r.Handle("/community/posts", auth.Require(tradingParties, handlers.CreatePost(community))).Methods("POST")
+	r.Handle("/community/posts/{postID}", auth.Require(middleware.Policy{}, handlers.GetPost(community))).Methods("GET")
+	r.Handle("/community/posts/{postID}", auth.Require(tradingParties, handlers.DeletePost(community))).Methods("DELETE")
+	r.Handle("/community/posts/{postID}/reactions/{reaction}", auth.Require(tradingParties, handlers.SetReaction(community))).Methods("PUT", "DELETE")
+	r.Handle("/community/posts/{postID}/comments", auth.Require(tradingParties, handlers.CreateComment(community))).Methods("POST")
+	r.Handle("/community/posts/{postID}/reports", auth.Require(tradingParties, handlers.ReportContent(community))).Methods("POST")
+	r.Handle("/community/comments/{commentID}", auth.Require(tradingParties, handlers.DeleteComment(community))).Methods("DELETE")
+	r.Handle("/community/comments/{commentID}/reports", auth.Require(tradingParties, handlers.ReportContent(community))).Methods("POST")
+
+	// Admins work the report queue and moderate content and businesses directly.
+	r.Handle("/admin/community/reports", auth.Require(adminOnly, handlers.ListReports(community))).Methods("GET")
+	r.Handle("/admin/community/reports/{reportID}/resolve", auth.Require(adminOnly, handlers.ResolveReport(community))).Methods("POST")
+	r.Handle("/admin/community/posts/{postID}/moderation", auth.Require(adminOnly, handlers.ModerateContent(community))).Methods("POST")
+	r.Handle("/admin/community/comments/{commentID}/moderation", auth.Require(adminOnly, handlers.ModerateContent(community))).Methods("POST")
+	r.Handle("/admin/community/bans/{entityID}", auth.Require(adminOnly, handlers.SetCommunityBan(community))).Methods("PUT", "DELETE")
+
+	// --- NOTIFICATION ROUTES ---
+	// Every signed-in user has an in-app inbox of license reminders and notices, readable
+	// while pending approval since a business can be registered before then.
+	inbox := middleware.Policy{Statuses: []models.AccountStatus{models.StatusActive, models.StatusPending}}
+	r.Handle("/notifications", auth.Require(inbox, handlers.ListNotifications(notifications))).Methods("GET")
+	r.Handle("/notifications/{notificationID}/read", auth.Require(inbox, handlers.MarkNotificationRead(notifications))).Methods("POST")
 
 	// --- HEALTH CHECK ROUTE ---
 	// This will respond to GET requests on /health
@@ -109,7 +444,8 @@ func main() {
 	// You should run this command in your terminal after creating the files:
 	// go mod tidy
 
-	if err := http.ListenAndServe(port, r); err != nil {
+	// Every request gets an ID and its origin recorded for the audit log.
+	if err := http.ListenAndServe(port, middleware.RequestMetadata(r)); err != nil {
 		log.Fatalf("Server failed to start: %v", err)
 	}
 }
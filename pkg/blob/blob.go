@@ -0,0 +1,37 @@
+// Package blob stores uploaded files such as certificates of analysis. Store is
+// the extension point: LocalStore keeps files on disk for development, and a
+// cloud bucket can be dropped in behind the same interface.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob: object not found")
+	ErrInvalidKey = errors.New("blob: invalid object key")
+)
+
+// Store saves and retrieves objects by key. Keys are slash-separated paths such
+// as "coa/vendor_1/product_2/3f9c.pdf".
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// validKey rejects keys that are empty, absolute or try to climb out of the store.
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+package coa
+
+import "strings"
+
+// Result kinds. Contaminant results are kinded by their category name, i.e. "Heavy Metals".
+const (
+	kindCannabinoid = "cannabinoid"
+	kindTerpene     = "terpene"
+)
+
+// normalize reduces an analyte name to a lookup key: "Δ9-THC", "Delta 9 THC" and
+// "d9-thc" all become "d9thc"; "β-Myrcene" becomes "bmyrcene".
+func normalize(name string) string {
+	name = strings.ToLower(name)
+	name = strings.NewReplacer("δ", "d", "delta", "d", "β", "b", "α", "a").Replace(name)
+	var b strings.Builder
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// canonical returns the display name for key from names, or fallback as printed on the COA.
+func canonical(names map[string]string, key, fallback string) string {
+	if name, ok := names[key]; ok {
+		return name
+	}
+	return fallback
+}
+
+var cannabinoids = map[string]string{
+	"thc": "Δ9-THC", "d9thc": "Δ9-THC", "d9": "Δ9-THC",
+	"thca": "THCa", "thcaa": "THCa",
+	"d8thc": "Δ8-THC",
+	"cbd":   "CBD", "cbda": "CBDa",
+	"cbg": "CBG", "cbga": "CBGa",
+	"cbn": "CBN", "cbc": "CBC",
+	"thcv": "THCV", "cbdv": "CBDV",
+	"totalthc": "Total THC", "totalcbd": "Total CBD",
+	"totalcannabinoids": "Total Cannabinoids",
+}
+
+var terpenes = map[string]string{
+	"myrcene": "β-Myrcene", "bmyrcene": "β-Myrcene", "betamyrcene": "β-Myrcene",
+	"limonene": "Limonene", "dlimonene": "Limonene",
+	"caryophyllene": "β-Caryophyllene", "bcaryophyllene": "β-Caryophyllene", "betacaryophyllene": "β-Caryophyllene",
+	"apinene": "α-Pinene", "alphapinene": "α-Pinene",
+	"bpinene": "β-Pinene", "betapinene": "β-Pinene",
+	"pinene":   "Pinene",
+	"linalool": "Linalool",
+	"humulene": "α-Humulene", "ahumulene": "α-Humulene", "alphahumulene": "α-Humulene",
+	"terpinolene": "Terpinolene",
+	"ocimene":     "Ocimene", "bocimene": "Ocimene",
+	"bisabolol": "α-Bisabolol", "abisabolol": "α-Bisabolol", "alphabisabolol": "α-Bisabolol",
+	"nerolidol": "Nerolidol", "transnerolidol": "Nerolidol",
+	"caryophylleneoxide": "Caryophyllene Oxide",
+	"guaiol":             "Guaiol",
+	"eucalyptol":         "Eucalyptol",
+	"camphene":           "Camphene",
+	"totalterpenes":      "Total Terpenes",
+}
+
+// contaminants maps well-known safety analytes to their category, for COAs that
+// don't label their sections.
+var contaminants = map[string]string{
+	"lead": "Heavy Metals", "arsenic": "Heavy Metals", "cadmium": "Heavy Metals", "mercury": "Heavy Metals",
+	"ecoli": "Microbials", "salmonella": "Microbials", "aspergillus": "Microbials",
+	"totalyeastandmold": "Microbials", "totalyeastmold": "Microbials", "yeastandmold": "Microbials",
+	"totalaerobicbacteria": "Microbials",
+	"aflatoxins":           "Mycotoxins", "totalaflatoxins": "Mycotoxins", "ochratoxin": "Mycotoxins", "ochratoxina": "Mycotoxins",
+	"wateractivity": "Water Activity",
+	"moisture":      "Moisture", "moisturecontent": "Moisture",
+}
+
+// sections maps the leading words of a COA section heading to its kind.
+var sections = []struct{ prefix, kind string }{
+	{"cannabinoid", kindCannabinoid},
+	{"potency", kindCannabinoid},
+	{"terpene", kindTerpene},
+	{"heavymetal", "Heavy Metals"},
+	{"metal", "Heavy Metals"},
+	{"microbial", "Microbials"},
+	{"microbiolog", "Microbials"},
+	{"mycotoxin", "Mycotoxins"},
+	{"pesticide", "Pesticides"},
+	{"residualsolvent", "Residual Solvents"},
+	{"solvent", "Residual Solvents"},
+	{"foreignmatter", "Foreign Matter"},
+	{"wateractivity", "Water Activity"},
+	{"moisture", "Moisture"},
+}
+
+// sectionOf returns the kind of results under a section heading, or "" if
+// heading isn't one.
+func sectionOf(heading string) string {
+	key := normalize(heading)
+	for _, s := range sections {
+		if strings.HasPrefix(key, s.prefix) {
+			return s.kind
+		}
+	}
+	return ""
+}
+
+// kindOf classifies a result outside any recognised section by its analyte name.
+// Unknown analytes with a PASS/FAIL verdict are still kept as contaminants.
+func kindOf(key, status string) string {
+	switch {
+	case cannabinoids[key] != "":
+		return kindCannabinoid
+	case terpenes[key] != "":
+		return kindTerpene
+	case contaminants[key] != "":
+		return contaminants[key]
+	case status != "":
+		return "Other"
+	}
+	return ""
+}
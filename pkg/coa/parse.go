@@ -0,0 +1,376 @@
+// Package coa extracts lab results from certificates of analysis. Labs publish
+// COAs as PDFs or CSV exports; both are reduced to rows of (section, analyte,
+// result, unit, status) and read the same way.
+package coa
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/wesleywinston/wds/pkg/models"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("coa: file must be a PDF or CSV")
+	ErrNoText            = errors.New("coa: no readable text found; upload the lab's CSV export instead")
+	ErrMissingLabID      = errors.New("coa: lab ID not found")
+	ErrMissingBatch      = errors.New("coa: batch number not found")
+	ErrNoPotency         = errors.New("coa: no cannabinoid potency results found")
+)
+
+// Content types of the formats we accept.
+const (
+	ContentTypePDF = "application/pdf"
+	ContentTypeCSV = "text/csv"
+)
+
+// DetectContentType identifies a COA file from its contents, falling back to the
+// file extension for CSVs (which have no magic number).
+func DetectContentType(data []byte, fileName string) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		return ContentTypePDF, nil
+	case strings.EqualFold(filepath.Ext(fileName), ".csv"):
+		return ContentTypeCSV, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Parse reads a COA of the given content type. It fails if the lab ID, batch
+// number or potency results can't be found, since a COA without them can't be
+// matched to a product.
+func Parse(data []byte, contentType string) (*models.LabResults, error) {
+	var (
+		results *models.LabResults
+		err     error
+	)
+	switch contentType {
+	case ContentTypePDF:
+		text, textErr := pdfText(data)
+		if textErr != nil {
+			return nil, textErr
+		}
+		results = parseText(text)
+	case ContentTypeCSV:
+		results, err = parseCSV(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case results.LabID == "":
+		return nil, ErrMissingLabID
+	case results.BatchNumber == "":
+		return nil, ErrMissingBatch
+	case len(results.Cannabinoids) == 0:
+		return nil, ErrNoPotency
+	}
+	return results, nil
+}
+
+// row is one result line, whichever format it came from.
+type row struct {
+	section string // as labelled on the COA; may be empty
+	name    string
+	result  string // as printed: "24.1", "ND", "<LOQ"
+	unit    string
+	status  string // "PASS", "FAIL" or empty
+}
+
+// metaLine matches header fields such as "Lab ID: OK-LAB-0042" or "Batch #: GG4-0917".
+var metaLine = regexp.MustCompile(`(?i)^\s*(lab(?:oratory)?\s*(?:id|license\s*(?:#|no\.?|number)?)|batch\s*(?:#|no\.?|number|id)?|lot\s*(?:#|no\.?|number)?|sample\s*(?:id|#)|lab(?:oratory)?(?:\s*name)?|date\s*tested|test(?:ed)?\s*(?:date|on))\s*[:#]\s*(.+?)\s*$`)
+
+// setMeta stores a header field on results. key is the label as printed.
+func setMeta(results *models.LabResults, key, value string) {
+	key = strings.ToLower(key)
+	switch {
+	case strings.Contains(key, "batch") || strings.Contains(key, "lot"):
+		results.BatchNumber = value
+	case strings.Contains(key, "sample"):
+		results.SampleID = value
+	case strings.Contains(key, "date") || strings.Contains(key, "test"):
+		results.TestedOn = value
+	case strings.Contains(key, "id") || strings.Contains(key, "license"):
+		results.LabID = value
+	default:
+		results.LabName = value
+	}
+}
+
+// parseText reads COA text one line at a time. Lines are either header fields,
+// section headings ("Heavy Metals") or results ("Lead <LOQ ppm 0.5 PASS").
+func parseText(text string) *models.LabResults {
+	results := &models.LabResults{}
+	var (
+		rows    []row
+		section string
+	)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(loqSpacing.ReplaceAllString(line, "<$1"))
+		if line == "" {
+			continue
+		}
+		if m := metaLine.FindStringSubmatch(line); m != nil {
+			setMeta(results, m[1], m[2])
+			continue
+		}
+		// A bare number after unknown words ("Page 1 of 2") isn't a result.
+		if r, ok := parseResultLine(line); ok && (r.unit != "" || r.status != "" || kindOf(normalize(r.name), "") != "") {
+			r.section = section
+			rows = append(rows, r)
+			continue
+		}
+		if s := sectionOf(line); s != "" {
+			section = line
+		}
+	}
+	build(results, rows)
+	return results
+}
+
+// loqSpacing joins "< LOQ" and "< 100" into a single token.
+var loqSpacing = regexp.MustCompile(`<\s+(\S)`)
+
+var (
+	resultToken = regexp.MustCompile(`(?i)^(ND|N/D|<LOQ|<LOD|[<>]?\d+(?:\.\d+)?)(%|[a-zµ/]+)?$`)
+	statusToken = regexp.MustCompile(`(?i)^(PASS|PASSED|FAIL|FAILED)$`)
+	numberToken = regexp.MustCompile(`^[<>]?\d+(?:\.\d+)?$`)
+)
+
+// units we recognise after a result; anything else means the number was part of the name (i.e. "Delta 9 THC").
+var knownUnits = map[string]bool{
+	"%": true, "mg/g": true, "mg/kg": true, "ppm": true, "ppb": true, "cfu/g": true,
+	"µg/kg": true, "ug/kg": true, "µg/g": true, "ug/g": true, "aw": true,
+}
+
+// parseResultLine splits "Total THC: 22.3 %" into name, result and unit. The result
+// is the first value-looking token that is followed by a unit, a status, another
+// number (the limit column) or nothing at all.
+func parseResultLine(line string) (row, bool) {
+	tokens := strings.Fields(line)
+	for i := 1; i < len(tokens); i++ {
+		m := resultToken.FindStringSubmatch(tokens[i])
+		if m == nil {
+			continue
+		}
+		unit := strings.ToLower(m[2])
+		if unit != "" && !knownUnits[unit] {
+			continue
+		}
+		if i+1 < len(tokens) {
+			next := strings.ToLower(tokens[i+1])
+			switch {
+			case unit == "" && knownUnits[next]:
+				unit = next
+			case statusToken.MatchString(next), numberToken.MatchString(next), next == "nd", strings.HasPrefix(next, "<"):
+			default:
+				continue
+			}
+		}
+
+		r := row{
+			name:   strings.TrimRight(strings.Join(tokens[:i], " "), ":="),
+			result: strings.ToUpper(m[1]),
+			unit:   unit,
+		}
+		if numberToken.MatchString(m[1]) {
+			r.result = m[1]
+		}
+		for _, t := range tokens[i+1:] {
+			if statusToken.MatchString(t) {
+				r.status = normalizeStatus(t)
+			}
+		}
+		return r, r.name != ""
+	}
+	return row{}, false
+}
+
+func normalizeStatus(s string) string {
+	if strings.HasPrefix(strings.ToUpper(s), "PASS") {
+		return "PASS"
+	}
+	return "FAIL"
+}
+
+// parseCSV reads a lab's CSV export. Two-column rows such as "Lab ID,OK-LAB-0042"
+// are header fields; results come after a header row naming at least the analyte
+// and result columns, i.e. "Section,Analyte,Result,Unit,Limit,Status".
+func parseCSV(data []byte) (*models.LabResults, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+
+	results := &models.LabResults{}
+	var (
+		rows    []row
+		columns map[string]int
+	)
+	for _, record := range records {
+		if columns == nil {
+			if cols := csvColumns(record); cols != nil {
+				columns = cols
+				continue
+			}
+			if len(record) >= 2 {
+				if m := metaLine.FindStringSubmatch(record[0] + ": " + record[1]); m != nil {
+					setMeta(results, m[1], m[2])
+				}
+			}
+			continue
+		}
+
+		cell := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		r := row{
+			section: cell("section"),
+			name:    cell("analyte"),
+			result:  strings.ToUpper(strings.ReplaceAll(cell("result"), " ", "")),
+			unit:    strings.ToLower(cell("unit")),
+			status:  cell("status"),
+		}
+		if r.status != "" {
+			r.status = normalizeStatus(r.status)
+		}
+		// Header fields can also appear as rows in an "Info" section.
+		if m := metaLine.FindStringSubmatch(r.name + ": " + r.result); m != nil && sectionOf(r.section) == "" {
+			setMeta(results, m[1], cell("result"))
+			continue
+		}
+		if r.name != "" && r.result != "" {
+			rows = append(rows, r)
+		}
+	}
+	build(results, rows)
+	return results, nil
+}
+
+// csvColumns recognises a results header row and maps column names to indexes.
+func csvColumns(record []string) map[string]int {
+	aliases := map[string]string{
+		"section": "section", "category": "section", "panel": "section", "test": "section",
+		"analyte": "analyte", "name": "analyte", "compound": "analyte",
+		"result": "result", "value": "result", "amount": "result",
+		"unit": "unit", "units": "unit",
+		"status": "status", "pass/fail": "status",
+	}
+	columns := map[string]int{}
+	for i, cell := range record {
+		if name, ok := aliases[strings.ToLower(strings.TrimSpace(cell))]; ok {
+			columns[name] = i
+		}
+	}
+	_, hasAnalyte := columns["analyte"]
+	_, hasResult := columns["result"]
+	if !hasAnalyte || !hasResult {
+		return nil
+	}
+	return columns
+}
+
+// build sorts rows into potency, terpene and contaminant results and fills in
+// the totals.
+func build(results *models.LabResults, rows []row) {
+	results.Cannabinoids = []models.AnalyteResult{}
+	results.Terpenes = []models.AnalyteResult{}
+	results.Contaminants = []models.ContaminantResult{}
+
+	for _, r := range rows {
+		key := normalize(r.name)
+		kind := sectionOf(r.section)
+		if kind == "" {
+			kind = kindOf(key, r.status)
+		}
+
+		switch kind {
+		case kindCannabinoid:
+			name := canonical(cannabinoids, key, r.name)
+			results.Cannabinoids = append(results.Cannabinoids, models.AnalyteResult{Name: name, Value: value(r.result), Unit: r.unit})
+		case kindTerpene:
+			name := canonical(terpenes, key, r.name)
+			results.Terpenes = append(results.Terpenes, models.AnalyteResult{Name: name, Value: value(r.result), Unit: r.unit})
+		case "":
+			// Not a result we know how to classify, i.e. a page footer that happened to end in a number.
+		default:
+			category := kind
+			if c, ok := contaminants[key]; ok && sectionOf(r.section) == "" {
+				category = c
+			}
+			results.Contaminants = append(results.Contaminants, models.ContaminantResult{
+				Name:     r.name,
+				Category: category,
+				Result:   r.result,
+				Unit:     r.unit,
+				Passed:   contaminantPassed(r),
+			})
+		}
+	}
+
+	results.TotalTHC = total(results.Cannabinoids, "Total THC", "THCa", "Δ9-THC")
+	results.TotalCBD = total(results.Cannabinoids, "Total CBD", "CBDa", "CBD")
+	results.Passed = true
+	for _, c := range results.Contaminants {
+		results.Passed = results.Passed && c.Passed
+	}
+}
+
+// contaminantPassed trusts the lab's PASS/FAIL column when there is one; otherwise
+// only a non-detect counts as a pass.
+func contaminantPassed(r row) bool {
+	if r.status != "" {
+		return r.status == "PASS"
+	}
+	return r.result == "ND" || r.result == "N/D" || strings.HasPrefix(r.result, "<")
+}
+
+// value converts a printed result to a number. Non-detects and below-limit results are 0.
+func value(result string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimLeft(result, "<>"), 64)
+	if err != nil || strings.HasPrefix(result, "<") {
+		return 0
+	}
+	return v
+}
+
+// total returns the reported total if the COA has one, and otherwise computes it
+// the way Oklahoma labs do: acid form × 0.877 (decarboxylation) + neutral form.
+func total(results []models.AnalyteResult, totalName, acidName, neutralName string) float64 {
+	var acid, neutral float64
+	for _, r := range results {
+		percent := r.Value
+		if r.Unit == "mg/g" {
+			percent /= 10
+		}
+		switch r.Name {
+		case totalName:
+			return round2(percent)
+		case acidName:
+			acid = percent
+		case neutralName:
+			neutral = percent
+		}
+	}
+	return round2(acid*0.877 + neutral)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,172 @@
+package coa
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/wesleywinston/wds/pkg/models"
+)
+
+const labCSV = `Lab ID,OK-LAB-0042
+Laboratory Name,Red Dirt Labs
+Batch Number,GG4-0917
+Date Tested,2025-09-17
+Section,Analyte,Result,Unit,Limit,Status
+Potency,THCa,24.1,%,,
+Potency,Delta 9 THC,0.5,%,,
+Potency,CBD,ND,%,,
+Terpenes,beta-Myrcene,0.82,%,,
+Heavy Metals,Lead,< LOQ,ppm,0.5,PASS
+Microbials,E. coli,ND,cfu/g,,PASS
+`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		contentType string
+		wantErr     error
+		check       func(t *testing.T, r *models.LabResults)
+	}{
+		{
+			name:        "lab CSV export",
+			data:        labCSV,
+			contentType: ContentTypeCSV,
+			check: func(t *testing.T, r *models.LabResults) {
+				if r.LabID != "OK-LAB-0042" || r.LabName != "Red Dirt Labs" || r.BatchNumber != "GG4-0917" || r.TestedOn != "2025-09-17" {
+					t.Errorf("header = %q %q %q %q", r.LabID, r.LabName, r.BatchNumber, r.TestedOn)
+				}
+				wantNames(t, "cannabinoids", analyteNames(r.Cannabinoids), "THCa", "Δ9-THC", "CBD")
+				wantNames(t, "terpenes", analyteNames(r.Terpenes), "β-Myrcene")
+				// Total THC is computed from the acid and neutral forms: 24.1 × 0.877 + 0.5.
+				if r.TotalTHC != 21.64 || r.TotalCBD != 0 {
+					t.Errorf("totals = THC %v, CBD %v; want 21.64, 0", r.TotalTHC, r.TotalCBD)
+				}
+				if len(r.Contaminants) != 2 || r.Contaminants[0].Category != "Heavy Metals" || r.Contaminants[0].Result != "<LOQ" || !r.Passed {
+					t.Errorf("contaminants = %+v, passed %v", r.Contaminants, r.Passed)
+				}
+			},
+		},
+		{
+			name:        "reported total wins and a failed screen fails the COA",
+			data:        "Lab ID,OK-LAB-0042\nBatch #,B1\nAnalyte,Result,Unit,Status\nTotal THC,19.9,%,\nTHCa,24.1,%,\nLead,1.2,ppm,FAIL\n",
+			contentType: ContentTypeCSV,
+			check: func(t *testing.T, r *models.LabResults) {
+				if r.TotalTHC != 19.9 {
+					t.Errorf("TotalTHC = %v, want the reported 19.9", r.TotalTHC)
+				}
+				if r.Passed {
+					t.Error("Passed = true with a failed lead screen")
+				}
+			},
+		},
+		{
+			name:        "mg/g potency is converted to percent",
+			data:        "Lab ID,L\nBatch,B\nAnalyte,Result,Unit\nCBDa,100,mg/g\nCBD,5,mg/g\n",
+			contentType: ContentTypeCSV,
+			check: func(t *testing.T, r *models.LabResults) {
+				if r.TotalCBD != 9.27 {
+					t.Errorf("TotalCBD = %v, want 9.27", r.TotalCBD)
+				}
+			},
+		},
+		{
+			name: "PDF text",
+			data: string(testPDF(text(`BT (Lab ID: OK-LAB-0042) Tj 0 -14 Td (Batch #: GG4-0917) Tj
+0 -14 Td (Cannabinoids) Tj
+0 -14 Td (THCa) Tj 200 0 Td (24.1 %) Tj
+0 -14 Td (Total THC) Tj 200 0 Td (21.6 %) Tj
+0 -14 Td (Heavy Metals) Tj
+0 -14 Td (Lead < LOQ ppm 0.5 PASS) Tj
+0 -14 Td (Page 1 of 2) Tj ET`))),
+			contentType: ContentTypePDF,
+			check: func(t *testing.T, r *models.LabResults) {
+				if r.LabID != "OK-LAB-0042" || r.BatchNumber != "GG4-0917" {
+					t.Errorf("header = %q %q", r.LabID, r.BatchNumber)
+				}
+				wantNames(t, "cannabinoids", analyteNames(r.Cannabinoids), "THCa", "Total THC")
+				if r.TotalTHC != 21.6 {
+					t.Errorf("TotalTHC = %v, want 21.6", r.TotalTHC)
+				}
+				if len(r.Contaminants) != 1 || r.Contaminants[0].Name != "Lead" || r.Contaminants[0].Unit != "ppm" || !r.Passed {
+					t.Errorf("contaminants = %+v, passed %v", r.Contaminants, r.Passed)
+				}
+			},
+		},
+		{
+			name:        "missing lab ID",
+			data:        "Batch Number,B1\nAnalyte,Result,Unit\nTHCa,20,%\n",
+			contentType: ContentTypeCSV,
+			wantErr:     ErrMissingLabID,
+		},
+		{
+			name:        "missing batch",
+			data:        "Lab ID,L1\nAnalyte,Result,Unit\nTHCa,20,%\n",
+			contentType: ContentTypeCSV,
+			wantErr:     ErrMissingBatch,
+		},
+		{
+			name:        "no potency",
+			data:        "Lab ID,L1\nBatch Number,B1\nAnalyte,Result,Unit,Status\nLead,ND,ppm,PASS\n",
+			contentType: ContentTypeCSV,
+			wantErr:     ErrNoPotency,
+		},
+		{
+			name:        "malformed CSV",
+			data:        "Lab ID,\"L1\nBatch",
+			contentType: ContentTypeCSV,
+			wantErr:     ErrUnsupportedFormat,
+		},
+		{
+			name:        "other content types",
+			data:        labCSV,
+			contentType: "image/png",
+			wantErr:     ErrUnsupportedFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.data), tt.contentType)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.check != nil {
+				tt.check(t, got)
+			}
+		})
+	}
+}
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		data, fileName string
+		want           string
+		wantErr        error
+	}{
+		{"%PDF-1.7\n...", "coa.bin", ContentTypePDF, nil},
+		{"Lab ID,L1\n", "COA.CSV", ContentTypeCSV, nil},
+		{"\x89PNG", "coa.png", "", ErrUnsupportedFormat},
+	}
+	for _, tt := range tests {
+		got, err := DetectContentType([]byte(tt.data), tt.fileName)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("DetectContentType(%q, %q) = %q, %v; want %q, %v", tt.data, tt.fileName, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func analyteNames(results []models.AnalyteResult) []string {
+	names := make([]string, len(results))
+	for i, r := range results {
+		names[i] = r.Name
+	}
+	return names
+}
+
+func wantNames(t *testing.T, what string, got []string, want ...string) {
+	t.Helper()
+	if !slices.Equal(got, want) {
+		t.Errorf("%s = %q, want %q", what, got, want)
+	}
+}
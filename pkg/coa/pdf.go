@@ -0,0 +1,246 @@
+package coa
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxStreamSize caps how much a single inflated PDF stream may expand to.
+const maxStreamSize = 8 << 20
+
+// pdfText pulls the text out of a PDF's content streams, one text line per
+// line. It understands what lab reporting tools emit (uncompressed or
+// FlateDecode streams with Tj/TJ text) and nothing more: scanned COAs have no
+// text to extract, and labs that encrypt or subset-encode fonts need to supply a CSV.
+func pdfText(data []byte) (string, error) {
+	var out strings.Builder
+	rest := data
+	for {
+		start := bytes.Index(rest, []byte("stream"))
+		if start < 0 {
+			break
+		}
+		// "endstream" also contains "stream"; skip past it.
+		if start >= 3 && string(rest[start-3:start]) == "end" {
+			rest = rest[start+len("stream"):]
+			continue
+		}
+		dict := rest[:start]
+		if obj := bytes.LastIndex(dict, []byte(" obj")); obj >= 0 {
+			dict = dict[obj:]
+		}
+		body := rest[start+len("stream"):]
+		body = bytes.TrimLeft(body, "\r\n")
+		end := bytes.Index(body, []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		rest = body[end+len("endstream"):]
+		content := body[:end]
+
+		if bytes.Contains(dict, []byte("/Image")) || bytes.Contains(dict, []byte("/FontFile")) || bytes.Contains(dict, []byte("/Length1")) {
+			continue
+		}
+		if bytes.Contains(dict, []byte("/FlateDecode")) {
+			zr, err := zlib.NewReader(bytes.NewReader(content))
+			if err != nil {
+				continue
+			}
+			inflated, err := io.ReadAll(io.LimitReader(zr, maxStreamSize))
+			zr.Close()
+			if err != nil && len(inflated) == 0 {
+				continue
+			}
+			content = inflated
+		} else if bytes.Contains(dict, []byte("/Filter")) {
+			continue // some other encoding we don't decode
+		}
+		extractText(content, &out)
+	}
+
+	text := out.String()
+	if strings.TrimSpace(text) == "" {
+		return "", ErrNoText
+	}
+	return text, nil
+}
+
+// extractText interprets the text operators in one content stream, writing
+// shown strings to out. Moves to a new baseline start a new line; moves along the
+// same baseline (table columns) become a space.
+func extractText(content []byte, out *strings.Builder) {
+	var (
+		operands []string // numbers since the last operator
+		strs     []string // strings since the last operator, in a TJ array or before Tj
+		lastY    = 0.0
+		line     strings.Builder
+	)
+	newline := func() {
+		if s := strings.TrimSpace(line.String()); s != "" {
+			out.WriteString(s)
+			out.WriteByte('\n')
+		}
+		line.Reset()
+	}
+	space := func() {
+		if line.Len() > 0 {
+			line.WriteByte(' ')
+		}
+	}
+	number := func(i int) float64 {
+		if i < 0 || i >= len(operands) {
+			return 0
+		}
+		v, _ := strconv.ParseFloat(operands[i], 64)
+		return v
+	}
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			s, next := literalString(content, i)
+			strs = append(strs, s)
+			i = next
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				return
+			}
+			strs = append(strs, hexString(content[i+1:i+end]))
+			i += end + 1
+		case c == '[' || c == ']':
+			i++
+		case c == '-' || c == '.' || c == '+' || (c >= '0' && c <= '9'):
+			start := i
+			for i < len(content) && (content[i] == '-' || content[i] == '.' || content[i] == '+' || (content[i] >= '0' && content[i] <= '9')) {
+				i++
+			}
+			num := string(content[start:i])
+			// A large negative kerning adjustment inside a TJ array is a word gap.
+			if v, err := strconv.ParseFloat(num, 64); err == nil && v < -200 && len(strs) > 0 {
+				strs = append(strs, " ")
+			}
+			operands = append(operands, num)
+		case isRegular(c):
+			start := i
+			for i < len(content) && isRegular(content[i]) {
+				i++
+			}
+			switch string(content[start:i]) {
+			case "Tj", "TJ":
+				line.WriteString(strings.Join(strs, ""))
+			case "'", "\"":
+				newline()
+				line.WriteString(strings.Join(strs, ""))
+			case "Td", "TD":
+				if number(len(operands)-1) != 0 {
+					newline()
+				} else {
+					space()
+				}
+			case "Tm":
+				if y := number(len(operands) - 1); y != lastY {
+					newline()
+					lastY = y
+				} else {
+					space()
+				}
+			case "T*", "ET":
+				newline()
+			}
+			operands, strs = operands[:0], strs[:0]
+		default:
+			i++
+		}
+	}
+	newline()
+}
+
+// isRegular reports whether c can be part of an operator name.
+func isRegular(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '*' || c == '\'' || c == '"'
+}
+
+// literalString reads a (parenthesised) string starting at content[i], returning
+// it and the index just past its closing parenthesis.
+func literalString(content []byte, i int) (string, int) {
+	var b strings.Builder
+	depth := 0
+	for i < len(content) {
+		c := content[i]
+		switch {
+		case c == '\\' && i+1 < len(content):
+			i++
+			switch e := content[i]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				end := i
+				for end < len(content) && end < i+3 && content[end] >= '0' && content[end] <= '7' {
+					end++
+				}
+				v, _ := strconv.ParseUint(string(content[i:end]), 8, 8)
+				b.WriteByte(byte(v))
+				i = end - 1
+			case '\n', '\r':
+				// line continuation
+			default:
+				b.WriteByte(e)
+			}
+		case c == '(':
+			if depth > 0 {
+				b.WriteByte(c)
+			}
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return latin1(b.String()), i + 1
+			}
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+		i++
+	}
+	return latin1(b.String()), i
+}
+
+// hexString decodes a <hex> string. An odd final digit is padded with 0.
+func hexString(h []byte) string {
+	h = bytes.Join(bytes.Fields(h), nil)
+	if len(h)%2 == 1 {
+		h = append(h, '0')
+	}
+	b := make([]byte, 0, len(h)/2)
+	for i := 0; i+1 < len(h); i += 2 {
+		v, err := strconv.ParseUint(string(h[i:i+2]), 16, 8)
+		if err != nil {
+			return ""
+		}
+		b = append(b, byte(v))
+	}
+	return latin1(string(b))
+}
+
+// latin1 converts a PDF string in the standard (WinAnsi-like) single-byte
+// encoding to UTF-8, so that "µg/kg" and "Δ" survive.
+func latin1(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		b.WriteRune(rune(s[i]))
+	}
+	return b.String()
+}
@@ -0,0 +1,127 @@
+package coa
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// pdfStream is one stream object in a test PDF: its dictionary entries and raw content.
+type pdfStream struct {
+	dict    string
+	content []byte
+}
+
+// testPDF assembles a bare-bones PDF around streams. pdfText only looks at the
+// streams, so there is no xref table or trailer.
+func testPDF(streams ...pdfStream) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	for i, s := range streams {
+		fmt.Fprintf(&b, "%d 0 obj\n<< /Length %d %s >>\nstream\n", i+1, len(s.content), s.dict)
+		b.Write(s.content)
+		b.WriteString("\nendstream\nendobj\n")
+	}
+	b.WriteString("%%EOF\n")
+	return b.Bytes()
+}
+
+func text(content string) pdfStream {
+	return pdfStream{content: []byte(content)}
+}
+
+func deflated(t *testing.T, content string) pdfStream {
+	t.Helper()
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	if _, err := zw.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return pdfStream{dict: "/Filter /FlateDecode", content: b.Bytes()}
+}
+
+func TestPDFText(t *testing.T) {
+	tests := []struct {
+		name    string
+		pdf     []byte
+		want    string
+		wantErr error
+	}{
+		{
+			name: "Td to a new baseline starts a line",
+			pdf:  testPDF(text("BT /F1 12 Tf 72 720 Td (Lab ID: OK-LAB-0042) Tj 0 -14 Td (Batch #: GG4-0917) Tj ET")),
+			want: "Lab ID: OK-LAB-0042\nBatch #: GG4-0917\n",
+		},
+		{
+			name: "Td along the baseline is a column gap",
+			pdf:  testPDF(text("BT (THCa) Tj 200 0 Td (24.1 %) Tj ET")),
+			want: "THCa 24.1 %\n",
+		},
+		{
+			name: "Tm compares baselines",
+			pdf:  testPDF(text("BT 1 0 0 1 72 700 Tm (Lead) Tj 1 0 0 1 200 700 Tm (ND) Tj 1 0 0 1 72 686 Tm (Arsenic) Tj ET")),
+			want: "Lead ND\nArsenic\n",
+		},
+		{
+			name: "large TJ kerning is a word gap",
+			pdf:  testPDF(text("BT [(Tot) -20 (al) -300 (THC)] TJ ET")),
+			want: "Total THC\n",
+		},
+		{
+			name: "T* and quote operators start lines",
+			pdf:  testPDF(text("BT (Potency) Tj T* (CBD) Tj (CBN) ' ET")),
+			want: "Potency\nCBD\nCBN\n",
+		},
+		{
+			name: "escapes, nesting and Latin-1",
+			pdf:  testPDF(text(`BT (Limit \(action\) 0.5 \265g/kg) Tj 0 -14 Td (a (b) c) Tj ET`)),
+			want: "Limit (action) 0.5 µg/kg\na (b) c\n",
+		},
+		{
+			name: "hex strings",
+			pdf:  testPDF(text("BT <4C 65 61 64> Tj ET")),
+			want: "Lead\n",
+		},
+		{
+			name: "comments are skipped",
+			pdf:  testPDF(text("% generated by LabTool\nBT (CBG) Tj ET")),
+			want: "CBG\n",
+		},
+		{
+			name: "FlateDecode streams are inflated",
+			pdf:  testPDF(deflated(t, "BT (Total CBD) Tj 100 0 Td (0.05 %) Tj ET")),
+			want: "Total CBD 0.05 %\n",
+		},
+		{
+			name: "images, fonts and unknown filters are skipped",
+			pdf: testPDF(
+				pdfStream{dict: "/Subtype /Image", content: []byte("BT (pixels) Tj ET")},
+				pdfStream{dict: "/Length1 20", content: []byte("BT (glyphs) Tj ET")},
+				pdfStream{dict: "/Filter /DCTDecode", content: []byte("BT (jpeg) Tj ET")},
+				text("BT (CBC) Tj ET"),
+			),
+			want: "CBC\n",
+		},
+		{
+			name:    "scanned COA has no text",
+			pdf:     testPDF(pdfStream{dict: "/Subtype /Image", content: []byte{0xff, 0xd8, 0xff}}),
+			wantErr: ErrNoText,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pdfText(tt.pdf)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("pdfText error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("pdfText = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
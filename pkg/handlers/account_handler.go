@@ -1,58 +1,174 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/middleware"
 	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/services"
+	"github.com/wesleywinston/wds/pkg/utils"
 )
 
 // SignupHandler handles the creation of a new user account.
-func SignupHandler(w http.ResponseWriter, r *http.Request) {
-	log.Println("Received request for /auth/signup")
+func SignupHandler(users repository.UserRepository, tokens *services.TokenIssuer, audit *services.AuditLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Println("Received request for /auth/signup")
 
-	// Note: We no longer need to check r.Method == http.MethodPost here, 
-	// as Gorilla Mux has already enforced it in main.go.
+		// Note: We no longer need to check r.Method == http.MethodPost here,
+		// as Gorilla Mux has already enforced it in main.go.
 
-	var req models.AuthRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Error decoding signup payload: %v", err)
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
-		return
-	}
+		var req models.AuthRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Printf("Error decoding signup payload: %v", err)
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
 
-	// --- TO DO: IMPLEMENT ACCOUNT CREATION LOGIC ---
-	// Placeholder Success Response:
-	response := models.AuthResponse{
-		Message: fmt.Sprintf("User %s successfully created as a %s. (Logic TBD)", req.Email, req.Role),
-		UserID:  "mock-user-1234",
-		Token:   "mock-jwt-token-...",
-	}
+		email := strings.ToLower(strings.TrimSpace(req.Email))
+		if email == "" || !strings.Contains(email, "@") {
+			http.Error(w, "A valid email address is required.", http.StatusBadRequest)
+			return
+		}
+
+		// Admin accounts are provisioned internally, never through public signup.
+		role := models.UserRole(req.Role)
+		if role != models.RoleBuyer && role != models.RoleVendor {
+			http.Error(w, "Invalid user role specified.", http.StatusBadRequest)
+			return
+		}
+
+		hash, err := services.HashPassword(req.Password)
+		if errors.Is(err, services.ErrPasswordTooShort) || errors.Is(err, services.ErrPasswordTooLong) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Printf("Error hashing password for %s: %v", email, err)
+			http.Error(w, "Could not create account.", http.StatusInternalServerError)
+			return
+		}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
-	log.Printf("Signup attempt successful for: %s", req.Email)
+		newUser := models.User{
+			ID:           utils.NewID("user"),
+			FullName:     req.FullName,
+			Email:        email,
+			PasswordHash: hash,
+			Role:         role,
+			Status:       models.StatusPending, // Vendor and buyer accounts wait for admin approval.
+			CreatedAt:    time.Now(),
+		}
+
+		if err := users.Create(r.Context(), newUser); errors.Is(err, repository.ErrAlreadyExists) {
+			http.Error(w, "An account with this email already exists.", http.StatusConflict)
+			return
+		} else if err != nil {
+			log.Printf("Error persisting user %s: %v", email, err)
+			http.Error(w, "Could not create account.", http.StatusInternalServerError)
+			return
+		}
+		audit.Record(r.Context(), services.UserEvent(&newUser, models.AuditUserCreated, models.AuditEntityUser, newUser.ID), nil, newUser)
+
+		token, err := tokens.Issue(newUser)
+		if err != nil {
+			log.Printf("Error issuing token for %s: %v", newUser.ID, err)
+			http.Error(w, "Account created, but sign-in failed. Please log in.", http.StatusInternalServerError)
+			return
+		}
+
+		response := models.AuthResponse{
+			Message: fmt.Sprintf("User %s successfully created as a %s.", newUser.Email, newUser.Role),
+			UserID:  newUser.ID,
+			Token:   token,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(response)
+		log.Printf("Signup successful for: %s (%s)", newUser.Email, newUser.ID)
+	}
 }
 
 // LoginHandler authenticates an existing user.
-func LoginHandler(w http.ResponseWriter, r *http.Request) {
-	log.Println("Received request for /auth/login")
+func LoginHandler(users repository.UserRepository, tokens *services.TokenIssuer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Println("Received request for /auth/login")
 
-	// Note: We no longer need to check r.Method == http.MethodPost here.
+		// Note: We no longer need to check r.Method == http.MethodPost here.
 
-	var req models.AuthRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Error decoding login payload: %v", err)
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
-		return
-	}
+		var req models.AuthRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			log.Printf("Error decoding login payload: %v", err)
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		email := strings.ToLower(strings.TrimSpace(req.Email))
+
+		// Unknown emails and wrong passwords get the same response so callers
+		// cannot probe which addresses have accounts.
+		user, err := users.GetByEmail(r.Context(), email)
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Invalid email or password.", http.StatusUnauthorized)
+			return
+		} else if err != nil {
+			log.Printf("Error loading user %s: %v", email, err)
+			http.Error(w, "Could not log in.", http.StatusInternalServerError)
+			return
+		}
+		if err := services.CheckPassword(user.PasswordHash, req.Password); err != nil {
+			log.Printf("Failed login attempt for: %s", email)
+			http.Error(w, "Invalid email or password.", http.StatusUnauthorized)
+			return
+		}
+
+		// Pending accounts may still log in to register their business and check on their review.
+		if user.Status == models.StatusSuspended || user.Status == models.StatusInactive || user.Status == models.StatusRejected {
+			http.Error(w, "This account is not active.", http.StatusForbidden)
+			return
+		}
+
+		token, err := tokens.Issue(*user)
+		if err != nil {
+			log.Printf("Error issuing token for %s: %v", user.ID, err)
+			http.Error(w, "Could not log in.", http.StatusInternalServerError)
+			return
+		}
 
-	// --- TO DO: IMPLEMENT LOGIN LOGIC ---
-	// Placeholder Success Response:
-	response := models.AuthResponse{
-		Message: fmt.Sprintf("Welcome back, %s. Successfully logged in. (Logic TBD)", req.Email),
-		Token:   "new-mock-jwt-token-...",
+		response := models.AuthResponse{
+			Message: fmt.Sprintf("Welcome back, %s. Successfully logged in.", user.Email),
+			UserID:  user.ID,
+			Token:   token,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		log.Printf("Login successful for: %s", user.Email)
 	}
+}
+
+// MeHandler returns the authenticated caller's own user record, with AccountData
+// loaded from their business as it is now.
+func MeHandler(vendors repository.VendorRepository, buyers repository.BuyerRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-	log.Printf("Login attempt successful for: %s", req.Email)
-}
\ No newline at end of file
+		me := *user
+		if err := repository.LoadAccountData(r.Context(), vendors, buyers, &me); err != nil {
+			log.Printf("Error loading business %s for user %s: %v", me.AssociatedEntityID, me.ID, err)
+			http.Error(w, "Could not load account.", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(me)
+	}
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/wesleywinston/wds/pkg/middleware"
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/services"
+)
+
+// BootstrapAdminRequest creates the first admin. BootstrapToken must match the
+// deployment's WDS_ADMIN_BOOTSTRAP_TOKEN.
+type BootstrapAdminRequest struct {
+	BootstrapToken string   `json:"bootstrapToken"`
+	Email          string   `json:"email"`
+	Password       string   `json:"password"`
+	FullName       []string `json:"fullName,omitempty"`
+}
+
+// AdminInviteRequest invites someone to become an admin, i.e. {"email": "ops@wds.com"}.
+type AdminInviteRequest struct {
+	Email string `json:"email"`
+}
+
+// AccountStatusRequest records a review decision, i.e. {"status": "SUSPENDED", "reason": "License lapsed"}.
+type AccountStatusRequest struct {
+	Status models.AccountStatus `json:"status"`
+	Reason string               `json:"reason,omitempty"`
+}
+
+// writeAdminError maps admin service errors onto HTTP status codes.
+func writeAdminError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAccount):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrInvalidBootstrapToken):
+		http.Error(w, "Invalid bootstrap token.", http.StatusForbidden)
+	case errors.Is(err, services.ErrAdminExists):
+		http.Error(w, "An admin account already exists; ask an admin for an invitation.", http.StatusConflict)
+	case errors.Is(err, services.ErrIllegalAccountTransition):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrUserNotFound):
+		http.Error(w, "User not found.", http.StatusNotFound)
+	case errors.Is(err, services.ErrBusinessNotFound):
+		http.Error(w, "Business not found.", http.StatusNotFound)
+	case errors.Is(err, repository.ErrAlreadyExists):
+		http.Error(w, "An account with this email already exists.", http.StatusConflict)
+	default:
+		log.Printf("Admin request failed: %v", err)
+		http.Error(w, "Could not process admin request.", http.StatusInternalServerError)
+	}
+}
+
+// BootstrapAdmin creates the platform's first admin account and signs them in. It is
+// public, but refuses once any admin exists or without the bootstrap token.
+func BootstrapAdmin(admin *services.AdminService, tokens *services.TokenIssuer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req BootstrapAdminRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		user, err := admin.Bootstrap(r.Context(), req.BootstrapToken, req.Email, req.Password, req.FullName)
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		log.Printf("SUCCESS: First admin bootstrapped: %s (%s)", user.Email, user.ID)
+
+		token, err := tokens.Issue(*user)
+		if err != nil {
+			log.Printf("Error issuing token for %s: %v", user.ID, err)
+			http.Error(w, "Admin created, but sign-in failed. Please log in.", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusCreated, models.AuthResponse{
+			Message: fmt.Sprintf("Admin %s created.", user.Email),
+			UserID:  user.ID,
+			Token:   token,
+		})
+	}
+}
+
+// InviteAdmin invites someone to become an admin. The invitee redeems the token at
+// /invitations/accept, which creates their account.
+func InviteAdmin(admin *services.AdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		var req AdminInviteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		invitation, token, err := admin.InviteAdmin(r.Context(), user, req.Email)
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		log.Printf("Admin invitation %s sent by %s to %s", invitation.ID, user.ID, invitation.Email)
+		writeJSON(w, http.StatusCreated, InviteResponse{Invitation: *invitation, Token: token})
+	}
+}
+
+// ReviewQueue lists vendor and buyer accounts awaiting admin approval.
+func ReviewQueue(admin *services.AdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		queue, err := admin.ReviewQueue(r.Context())
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, queue)
+	}
+}
+
+// UpdateAccountStatus approves, rejects, suspends or reinstates a user account.
+func UpdateAccountStatus(admin *services.AdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		var req AccountStatusRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		updated, err := admin.SetStatus(r.Context(), user, mux.Vars(r)["userID"], req.Status, req.Reason)
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		log.Printf("Admin %s moved user %s to %s: %s", user.ID, updated.ID, updated.Status, updated.StatusReason)
+		writeJSON(w, http.StatusOK, updated)
+	}
+}
+
+// SetBusinessStatus suspends or reinstates a vendor or buyer business. A
+// suspended business cannot trade until an admin moves it back to ACTIVE.
+func SetBusinessStatus(admin *services.AdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		var req AccountStatusRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		entityID := mux.Vars(r)["entityID"]
+		updated, err := admin.SetBusinessStatus(r.Context(), user, entityID, req.Status, req.Reason)
+		if err != nil {
+			writeAdminError(w, err)
+			return
+		}
+		log.Printf("Admin %s moved business %s to %s: %s", user.ID, entityID, req.Status, req.Reason)
+		writeJSON(w, http.StatusOK, updated)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/middleware"
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/services"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// recordAudit records an action taken by the authenticated caller.
+func recordAudit(r *http.Request, audit *services.AuditLog, action models.AuditAction, entityType models.AuditEntityType, entityID string, before, after any) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		return
+	}
+	audit.Record(r.Context(), services.UserEvent(user, action, entityType, entityID), before, after)
+}
+
+// parseAuditFilter reads the audit filter from the query string, i.e.
+// ?entityType=order&entityID=order_123&since=2025-01-01T00:00:00Z&limit=50.
+// since and until are RFC 3339 timestamps.
+func parseAuditFilter(r *http.Request) (repository.AuditFilter, error) {
+	q := r.URL.Query()
+	filter := repository.AuditFilter{
+		ActorID:    q.Get("actorID"),
+		Action:     models.AuditAction(q.Get("action")),
+		EntityType: models.AuditEntityType(q.Get("entityType")),
+		EntityID:   q.Get("entityID"),
+	}
+
+	var err error
+	if v := q.Get("since"); v != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			return filter, err
+		}
+	}
+	if v := q.Get("until"); v != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, v); err != nil {
+			return filter, err
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
+
+// QueryAuditLog returns matching audit events, oldest first. At most limit events
+// (default 100, max 1000) are returned.
+func QueryAuditLog(audit *services.AuditLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseAuditFilter(r)
+		if err != nil {
+			http.Error(w, "Invalid audit filter: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if filter.Limit <= 0 {
+			filter.Limit = defaultAuditLimit
+		}
+		filter.Limit = min(filter.Limit, maxAuditLimit)
+
+		events, err := audit.Query(r.Context(), filter)
+		if err != nil {
+			log.Printf("Error querying audit log: %v", err)
+			http.Error(w, "Could not query audit log.", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, events)
+	}
+}
+
+// ExportAuditLog streams matching audit events as JSON lines, one event per line,
+// oldest first. Unlike QueryAuditLog there is no default limit.
+func ExportAuditLog(audit *services.AuditLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseAuditFilter(r)
+		if err != nil {
+			http.Error(w, "Invalid audit filter: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		events, err := audit.Query(r.Context(), filter)
+		if err != nil {
+			log.Printf("Error exporting audit log: %v", err)
+			http.Error(w, "Could not export audit log.", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="audit-log.jsonl"`)
+		enc := json.NewEncoder(w)
+		for _, event := range events {
+			if err := enc.Encode(event); err != nil {
+				log.Printf("Audit export aborted after a write error: %v", err)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/services"
+)
+
+func TestExportAuditLog(t *testing.T) {
+	ctx := services.WithRequestMetadata(context.Background(), models.RequestMetadata{RequestID: "req_1"})
+	audit := services.NewAuditLog(repository.NewMemoryAuditRepository())
+	admin := &models.User{ID: "admin", Role: models.RoleAdmin}
+	audit.Record(ctx, services.UserEvent(admin, models.AuditUserStatusChanged, models.AuditEntityUser, "u1"),
+		map[string]string{"status": "PENDING_APPROVAL"}, map[string]string{"status": "ACTIVE"})
+	audit.Record(ctx, services.SystemEvent(models.AuditLicenseChecked, models.AuditEntityLicense, "GAAA-4K7M-2Q9X-8B3N"), nil, nil)
+	audit.Record(ctx, services.UserEvent(admin, models.AuditUserStatusChanged, models.AuditEntityUser, "u2"), nil, nil)
+
+	tests := []struct {
+		query string
+		code  int
+		want  []string // entity IDs, one line each
+	}{
+		{"", http.StatusOK, []string{"u1", "GAAA-4K7M-2Q9X-8B3N", "u2"}},
+		{"?entityType=user", http.StatusOK, []string{"u1", "u2"}},
+		{"?actorID=system", http.StatusOK, []string{"GAAA-4K7M-2Q9X-8B3N"}},
+		{"?entityType=user&limit=1", http.StatusOK, []string{"u1"}},
+		{"?since=yesterday", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		ExportAuditLog(audit)(rec, httptest.NewRequest(http.MethodGet, "/admin/audit/export"+tt.query, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%q: status = %d, want %d", tt.query, rec.Code, tt.code)
+			continue
+		}
+		if tt.code != http.StatusOK {
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("%q: Content-Type = %q", tt.query, ct)
+		}
+
+		var got []string
+		lines := bufio.NewScanner(rec.Body)
+		for lines.Scan() {
+			var event models.AuditEvent
+			if err := json.Unmarshal(lines.Bytes(), &event); err != nil {
+				t.Fatalf("%q: line %q is not an event: %v", tt.query, lines.Text(), err)
+			}
+			if event.Request == nil || event.Request.RequestID != "req_1" {
+				t.Errorf("%q: event %s lost its request metadata", tt.query, event.ID)
+			}
+			got = append(got, event.EntityID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q: exported %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestQueryAuditLogDefaultLimit(t *testing.T) {
+	audit := services.NewAuditLog(repository.NewMemoryAuditRepository())
+	for range defaultAuditLimit + 5 {
+		audit.Record(context.Background(), services.SystemEvent(models.AuditLicenseChecked, models.AuditEntityLicense, "L"), nil, nil)
+	}
+
+	rec := httptest.NewRecorder()
+	QueryAuditLog(audit)(rec, httptest.NewRequest(http.MethodGet, "/admin/audit", nil))
+	var events []models.AuditEvent
+	if err := json.Unmarshal(rec.Body.Bytes(), &events); err != nil {
+		t.Fatalf("decoding %s: %v", rec.Body, err)
+	}
+	if len(events) != defaultAuditLimit {
+		t.Errorf("returned %d events, want the default limit of %d", len(events), defaultAuditLimit)
+	}
+}
@@ -4,98 +4,160 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
-	"cloud.google.com/go/firestore"
+	"github.com/wesleywinston/wds/pkg/middleware"
 	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/services"
+	"github.com/wesleywinston/wds/pkg/utils"
 )
 
 // NewUserRequest is the payload for creating a new platform user.
 type NewUserRequest struct {
-	Email              string `json:"email"`
-	Password           string `json:"password"`
-	FirstName          string `json:"firstName"`
-	LastName           string `json:"lastName"`
-	Role               string `json:"role"`               // e.g., models.RoleVendor
-	AssociatedEntityID string `json:"associatedEntityId"` // Vendor/Buyer ID
+	Email              string              `json:"email"`
+	Password           string              `json:"password"`
+	FirstName          string              `json:"firstName"`
+	LastName           string              `json:"lastName"`
+	Role               string              `json:"role"`               // e.g., models.RoleVendor
+	AssociatedEntityID string              `json:"associatedEntityId"` // Vendor/Buyer ID
+	BusinessRole       models.BusinessRole `json:"businessRole"`       // role within the Vendor/Buyer; defaults to STAFF
 }
 
 // CheckEntityCompliance ensures the referenced Vendor/Buyer entity is valid and active.
-// In a real app, this would query the DB for the entity and check its ComplianceStatus.
-func CheckEntityCompliance(ctx context.Context, db *firestore.Client, entityID string, role string) error {
+func CheckEntityCompliance(ctx context.Context, vendors repository.VendorRepository, buyers repository.BuyerRepository, entityID string, role string) error {
 	if entityID == "" && (role == "VENDOR" || role == "BUYER") {
 		return errors.New("missing associated entity ID for licensed role")
 	}
 
-	// --- SIMULATION LOGIC: Entity check against DB ---
-	// Real-world: Fetch the Vendor/Buyer document and check its ComplianceStatus and ExpiryDate.
-	// For now, we only allow specific IDs to simulate compliance.
-	if role == models.RoleVendor && entityID != "V-ACTIVE-101" {
-		return errors.New("vendor entity not found or compliance failed")
-	}
-	if role == models.RoleBuyer && entityID != "B-ACTIVE-202" {
-		return errors.New("buyer entity not found or compliance failed")
+	// Fetch the Vendor/Buyer document; IsCompliant covers both ComplianceStatus and expiry.
+	var entity models.AccountEntity
+	switch models.UserRole(role) {
+	case models.RoleVendor:
+		vendor, err := vendors.Get(ctx, entityID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return errors.New("vendor entity not found")
+		} else if err != nil {
+			return err
+		}
+		entity = vendor
+	case models.RoleBuyer:
+		buyer, err := buyers.Get(ctx, entityID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return errors.New("buyer entity not found")
+		} else if err != nil {
+			return err
+		}
+		entity = buyer
+	default:
+		return nil
 	}
-	// --- END SIMULATION ---
 
+	if !entity.IsCompliant() {
+		return fmt.Errorf("%s failed compliance check", entity)
+	}
 	return nil
 }
 
-// CreateUser handles the registration of a new user and assigns role-based permissions.
-func CreateUser(db *firestore.Client) http.HandlerFunc {
+// CreateUser lets an admin provision a vendor or buyer user for an existing business.
+// The account is ACTIVE immediately, since an admin created it.
+// Licensed users are added to their business's member list.
+func CreateUser(users repository.UserRepository, vendors repository.VendorRepository, buyers repository.BuyerRepository, memberships *services.MembershipService, audit *services.AuditLog) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		var req NewUserRequest
 
+		admin, ok := middleware.UserFromContext(ctx)
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "Invalid request payload", http.StatusBadRequest)
 			return
 		}
 
 		// 1. Role-based Entity and Compliance Check
-		// TODO: Implement actual entity and compliance checks
-		// For now, we only allow specific IDs to simulate compliance.
-		//
 		//	ADMIN, BUYER, VENDOR
 		if req.Role == "VENDOR" || req.Role == "BUYER" {
-			if err := CheckEntityCompliance(ctx, db, req.AssociatedEntityID, req.Role); err != nil {
+			if err := CheckEntityCompliance(ctx, vendors, buyers, req.AssociatedEntityID, req.Role); err != nil {
 				log.Printf("Entity compliance failed for user %s: %v", req.Email, err)
 				http.Error(w, "Cannot create user: Associated business entity is invalid or non-compliant.", http.StatusForbidden)
 				return
 			}
 		} else if req.Role == "ADMIN" {
-			// Admin accounts require human intervention/approval (simulated here by checking a secret key or internal list)
-			if req.Email != "admin@company.com" {
-				// Prevent self-registering as admin
-				http.Error(w, "Admin accounts must be provisioned internally.", http.StatusForbidden)
-				return
-			}
+			// Admins join by invitation so they set their own password.
+			http.Error(w, "Admin accounts are created by invitation; use /admin/invitations.", http.StatusBadRequest)
+			return
 		} else {
 			http.Error(w, "Invalid user role specified.", http.StatusBadRequest)
 			return
 		}
+		businessRole := req.BusinessRole
+		if businessRole == "" {
+			businessRole = models.BusinessRoleStaff
+		}
+		if !services.ValidBusinessRole(businessRole) {
+			http.Error(w, fmt.Sprintf("Unknown business role %q.", businessRole), http.StatusBadRequest)
+			return
+		}
 
 		// 2. Create the User Model
+		hash, err := services.HashPassword(req.Password)
+		if errors.Is(err, services.ErrPasswordTooShort) || errors.Is(err, services.ErrPasswordTooLong) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Printf("Error hashing password for %s: %v", req.Email, err)
+			http.Error(w, "Could not create user.", http.StatusInternalServerError)
+			return
+		}
+
 		newUser := models.User{
 			// ID, FullName, Email, PasswordHash, Role, Status, AssociatedEntityID
-			ID:                 time.Now().Format("20060102-150405-user"),
-			Email:              req.Email,
-			PasswordHash:       "hashed_" + req.Password, // Hashing logic goes here
-			FirstName:          req.FirstName,
-			LastName:           req.LastName,
-			Role:               req.Role,
-			AssociatedEntityID: req.AssociatedEntityID,
-			Status:             models.StatusActive, // Assuming compliance check passed, otherwise PENDING_APPROVAL
-			CreatedAt:          time.Now(),
+			ID:           utils.NewID("user"),
+			FullName:     []string{req.FirstName, req.LastName},
+			Email:        strings.ToLower(strings.TrimSpace(req.Email)),
+			PasswordHash: hash,
+			Role:         models.UserRole(req.Role),
+			Status:       models.StatusActive, // Approved by the provisioning admin; the business passed its compliance check above.
+			CreatedAt:    time.Now(),
+			StatusReason: "Provisioned by admin",
+			StatusHistory: []models.AccountStatusChange{
+				{To: models.StatusActive, ActorID: admin.ID, Reason: "Provisioned by admin", At: time.Now()},
+			},
+		}
+
+		// 3. Persist to Database
+		if err := users.Create(ctx, newUser); errors.Is(err, repository.ErrAlreadyExists) {
+			http.Error(w, "An account with this email already exists.", http.StatusConflict)
+			return
+		} else if err != nil {
+			log.Printf("Error persisting user %s: %v", newUser.Email, err)
+			http.Error(w, "Could not create user.", http.StatusInternalServerError)
+			return
 		}
 
-		// 3. Persist to Database (Simulated)
-		// db.Collection("users").Doc(newUser.ID).Set(ctx, newUser)
+		// 4. Join the business; this sets AssociatedEntityID alongside the membership.
+		// The user is removed again if that fails, so no account is left without a business.
+		if err := memberships.Attach(ctx, &newUser, req.AssociatedEntityID, businessRole); err != nil {
+			log.Printf("Error adding user %s to %s: %v", newUser.ID, req.AssociatedEntityID, err)
+			if deleteErr := users.Delete(ctx, newUser.ID); deleteErr != nil {
+				log.Printf("Could not remove unattached user %s: %v", newUser.ID, deleteErr)
+			}
+			writeMembershipError(w, err)
+			return
+		}
+		recordAudit(r, audit, models.AuditUserCreated, models.AuditEntityUser, newUser.ID, nil, newUser)
 		log.Printf("SUCCESS: User created: %s (%s) for Entity: %s", newUser.Email, newUser.Role, newUser.AssociatedEntityID)
 
 		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(map[string]string{"message": "User account created successfully.", "userID": newUser.ID})
 	}
 }
@@ -1,16 +1,21 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
+	"unicode"
 
-	"cloud.google.com/go/firestore" // Placeholder for Firestore client
+	"github.com/wesleywinston/wds/pkg/license"
+	"github.com/wesleywinston/wds/pkg/middleware"
 	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
 	"github.com/wesleywinston/wds/pkg/services"
+	"github.com/wesleywinston/wds/pkg/utils"
 )
 
 // VendorRegistrationRequest is the payload expected from the frontend.
@@ -20,94 +25,260 @@ type VendorRegistrationRequest struct {
 	// ... other required vendor fields
 }
 
+// BuyerRegistrationRequest is the payload expected from the frontend.
+type BuyerRegistrationRequest struct {
+	BusinessName     string `json:"businessName"`
+	OkStateLicenseID string `json:"okStateLicenseId"`
+}
+
+// errAlreadyLinked stops a registration from linking an account that another
+// registration linked to its business first.
+var errAlreadyLinked = errors.New("account is already linked to a business")
+
+// validBusinessName reports whether name is safe to show and to put in email
+// headers: it must not contain control characters such as CR or LF.
+func validBusinessName(name string) bool {
+	return !strings.ContainsFunc(name, unicode.IsControl)
+}
+
+// verifyRegistrationLicense checks the license ID format, that its type may act on
+// the requested side of a trade, and that the state reports it active with the
+// same license type. The state's answer is recorded to audit either way. On
+// failure it writes the HTTP error and returns ok == false.
+func verifyRegistrationLicense(w http.ResponseWriter, r *http.Request, verifier services.LicenseVerifier, audit *services.AuditLog, licenseID string, allowed func(models.LicenseType) bool, side string) (*models.OMMAVerificationResponse, models.LicenseType, bool) {
+	licenseType, err := license.Parse(licenseID)
+	if err != nil {
+		http.Error(w, "Invalid license ID: "+err.Error(), http.StatusBadRequest)
+		return nil, "", false
+	}
+	if !allowed(licenseType) {
+		http.Error(w, fmt.Sprintf("A %s license cannot register as a %s.", licenseType, side), http.StatusForbidden)
+		return nil, "", false
+	}
+
+	// --- External Real-Time License Verification ---
+	// We verify the license ID against the state's API.
+	ommaResponse, err := services.VerifyLicenseExternally(r.Context(), verifier, licenseID)
+	var typeErr error
+	if err == nil && ommaResponse.IsActive {
+		typeErr = license.MatchesEntityType(licenseID, ommaResponse.EntityType)
+	}
+	recordAudit(r, audit, models.AuditLicenseChecked, models.AuditEntityLicense, licenseID, nil, registrationCheckResult(ommaResponse, errors.Join(err, typeErr)))
+
+	if err != nil {
+		// This covers network failure or the license being inactive/expired externally.
+		log.Printf("External verification failed for %s: %v", licenseID, err)
+		http.Error(w, "License verification failed or license is inactive/expired.", http.StatusForbidden)
+		return nil, "", false
+	}
+
+	// Ensure the external license check returned a response that is active.
+	if !ommaResponse.IsActive {
+		http.Error(w, "License is not currently active according to state records.", http.StatusForbidden)
+		return nil, "", false
+	}
+
+	if typeErr != nil {
+		log.Printf("License type check failed for %s: %v", licenseID, typeErr)
+		http.Error(w, "License type does not match state records.", http.StatusForbidden)
+		return nil, "", false
+	}
+
+	return ommaResponse, licenseType, true
+}
+
+// registrationCheckResult summarises a registration-time license check for the audit log.
+func registrationCheckResult(response *models.OMMAVerificationResponse, err error) services.LicenseCheckResult {
+	result := services.LicenseCheckResult{ComplianceStatus: models.ComplianceVerified}
+	if response != nil {
+		result.ExpirationDate = response.ExpirationDate
+	}
+	switch {
+	case errors.Is(err, services.ErrLicenseExpired):
+		result.ComplianceStatus = models.ComplianceExpired
+	case err != nil || !response.IsActive:
+		result.ComplianceStatus = models.CompliancePending
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
 // RegisterVendor handles the initial registration and license verification for a new Vendor.
-func RegisterVendor(db *firestore.Client) http.HandlerFunc {
+// The calling VENDOR user becomes linked to the newly created business as its owner.
+func RegisterVendor(vendors repository.VendorRepository, users repository.UserRepository, verifier services.LicenseVerifier, audit *services.AuditLog) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		var req VendorRegistrationRequest
 
+		user, ok := middleware.UserFromContext(ctx)
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+		if user.AssociatedEntityID != "" {
+			http.Error(w, "Your account is already linked to a business.", http.StatusConflict)
+			return
+		}
+
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "Invalid request payload", http.StatusBadRequest)
 			return
 		}
-
-		// --- STEP 1: External Real-Time License Verification ---
-		// We verify the license ID against the state's (simulated) API.
-		ommaResponse, err := services.VerifyLicenseExternally(ctx, req.OkStateLicenseID)
-		if err != nil {
-			// This covers network failure or the license being inactive/expired externally.
-			log.Printf("External verification failed for %s: %v", req.OkStateLicenseID, err)
-			http.Error(w, "License verification failed or license is inactive/expired.", http.StatusForbidden)
+		req.OkStateLicenseID = license.Normalize(req.OkStateLicenseID)
+		if !validBusinessName(req.BusinessName) {
+			http.Error(w, "Business name cannot contain line breaks or control characters.", http.StatusBadRequest)
 			return
 		}
 
-		// Ensure the external license check returned a response that is active.
-		if !ommaResponse.IsActive {
-			http.Error(w, "License is not currently active according to state records.", http.StatusForbidden)
+		// --- STEP 1: License format, trade rules and external verification ---
+		// Vendor is derived from the license type: only licenses that may sell to other businesses qualify.
+		ommaResponse, licenseType, ok := verifyRegistrationLicense(w, r, verifier, audit, req.OkStateLicenseID, license.CanSell, "vendor")
+		if !ok {
 			return
 		}
 
 		// --- STEP 2: Create Vendor Entity ---
 		newVendor := models.Vendor{
 			// ID, BusinessName, OKStateLicenseID, LicenseExpirationDate, ComplianceStatus, ContactInfo, MenuEnabled, CreatedAt
-			ID:                    time.Now().Format("20060102-150405"), // Simplified ID generation
+			ID:                    utils.NewID("vendor"),
 			BusinessName:          req.BusinessName,
 			OKStateLicenseID:      req.OkStateLicenseID,
+			LicenseType:           licenseType,
 			LicenseExpirationDate: ommaResponse.ExpirationDate, // Use the date returned from the state API
-			ComplianceStatus:      "VERIFIED",                  // Mark VERIFIED because the external check succeeded
+			ComplianceStatus:      models.ComplianceVerified,   // Mark VERIFIED because the external check succeeded
 			ContactInfo:           models.ContactInfo{ /* populate contact */ },
 			MenuEnabled:           false,
 			CreatedAt:             time.Now(),
+			Members:               []models.Membership{{UserID: user.ID, Email: user.Email, Role: models.BusinessRoleOwner, AddedAt: time.Now()}},
 		}
 
 		// --- STEP 3: Persist to Database ---
-		// In a real scenario, you'd use db.Collection("vendors").Doc(newVendor.ID).Set(ctx, newVendor)
-		// For simulation, we'll just log success.
+		if err := vendors.Create(ctx, newVendor); err != nil {
+			log.Printf("Error persisting vendor %s: %v", newVendor.BusinessName, err)
+			http.Error(w, "Could not register vendor.", http.StatusInternalServerError)
+			return
+		}
+		// --- STEP 4: Link the registering user to the Vendor ---
+		// The link is re-checked inside Modify so two registrations racing for one
+		// account can't both claim it. The vendor is removed again if linking fails.
+		_, err := users.Modify(ctx, user.ID, func(u *models.User) error {
+			if u.AssociatedEntityID != "" {
+				return errAlreadyLinked
+			}
+			u.AssociatedEntityID = newVendor.ID
+			u.AccountData = newVendor
+			return nil
+		})
+		if err != nil {
+			log.Printf("Error linking user %s to vendor %s: %v", user.ID, newVendor.ID, err)
+			if deleteErr := vendors.Delete(ctx, newVendor.ID); deleteErr != nil {
+				log.Printf("Could not remove unlinked vendor %s: %v", newVendor.ID, deleteErr)
+			}
+			if errors.Is(err, errAlreadyLinked) {
+				http.Error(w, "Your account is already linked to a business.", http.StatusConflict)
+				return
+			}
+			http.Error(w, "Could not register vendor.", http.StatusInternalServerError)
+			return
+		}
+		user.AssociatedEntityID = newVendor.ID
+		user.AccountData = newVendor
+
+		recordAudit(r, audit, models.AuditBusinessRegistered, models.AuditEntityVendor, newVendor.ID, nil, newVendor)
 		log.Printf("SUCCESS: Vendor %s registered with license active until %s", newVendor.BusinessName, newVendor.LicenseExpirationDate.Format("2006-01-02"))
 
 		// Respond to the user
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"message": "Vendor registration successful. Please proceed to create your user account."})
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Vendor registration successful.", "vendorID": newVendor.ID})
 	}
 }
 
-// CheckEntityActive is a general middleware/handler used for transactional checks (e.g., before placing an order).
-func CheckEntityActive(db *firestore.Client, entityID string) error {
-	ctx := context.Background()
-
-	// --- STEP 1: Retrieve Vendor/Buyer data from our DB ---
-	// For simplicity, we assume we fetch a Vendor here, but this logic would be dynamic based on role.
-	var entity models.User // check direct reference of call to this function from user input
-
-	// Simulated database retrieval: Fetch the Vendor based on the entityID
-	// In reality: docSnap, err := db.Collection("vendors").Doc(entityID).Get(ctx)
-	// For simulation, we assume retrieval succeeded with known good data:
-	if entityID == "V-12345" {
-		entity.AccountData.ComplianceStatus = "VERIFIED"
-		// Set expiration 1 year from now
-		entity.AccountData.LicenseExpirationDate = time.Now().AddDate(1, 0, 0)
-	} else {
-		// Simulate expired/bad data
-		entity.AccountData.ComplianceStatus = "EXPIRED"
-		entity.AccountData.LicenseExpirationDate = time.Now().AddDate(-1, 0, 0)
-	}
+// RegisterBuyer handles the initial registration and license verification for a new Buyer.
+// The calling BUYER user becomes linked to the newly created business as its owner.
+func RegisterBuyer(buyers repository.BuyerRepository, users repository.UserRepository, verifier services.LicenseVerifier, audit *services.AuditLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		var req BuyerRegistrationRequest
 
-	// --- STEP 2: Internal Compliance Check ---
-	err := services.CheckInternalLicenseStatus(entityID, entity.AccountData.LicenseExpirationDate, entity.AccountData.ComplianceStatus) // this might need to be updated to entity.AccountData.(Vendor/Buyer)
-	// if buy, ok := buyerUser.AccountData.(Buyer); ok {
-	// 	if buy.ComplianceStatus != "VERIFIED" {
-	// 		return errors.New("buyer account is not verified")
-	// 	}
-	// }
-	if err != nil {
-		// Log the failure for administrative review
-		log.Printf("Transactional license check failed for entity %s: %v", entityID, err)
+		user, ok := middleware.UserFromContext(ctx)
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+		if user.AssociatedEntityID != "" {
+			http.Error(w, "Your account is already linked to a business.", http.StatusConflict)
+			return
+		}
 
-		// If the internal check fails, we might trigger a background job to re-verify externally
-		// to catch recently renewed licenses that we haven't updated yet.
-		// For now, we return the internal failure.
-		return errors.New("entity license is not active or has expired")
-	}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+		req.OkStateLicenseID = license.Normalize(req.OkStateLicenseID)
+		if !validBusinessName(req.BusinessName) {
+			http.Error(w, "Business name cannot contain line breaks or control characters.", http.StatusBadRequest)
+			return
+		}
 
-	return nil // Transactional check passed
+		// --- STEP 1: License format, trade rules and external verification ---
+		// Buyer is derived from the license type: only licenses that may purchase from other businesses qualify.
+		ommaResponse, licenseType, ok := verifyRegistrationLicense(w, r, verifier, audit, req.OkStateLicenseID, license.CanPurchase, "buyer")
+		if !ok {
+			return
+		}
+
+		// --- STEP 2: Create Buyer Entity ---
+		newBuyer := models.Buyer{
+			ID:                    utils.NewID("buyer"),
+			BusinessName:          req.BusinessName,
+			OKStateLicenseID:      req.OkStateLicenseID,
+			LicenseType:           licenseType,
+			LicenseExpirationDate: ommaResponse.ExpirationDate,
+			ComplianceStatus:      models.ComplianceVerified,
+			CreatedAt:             time.Now(),
+			Members:               []models.Membership{{UserID: user.ID, Email: user.Email, Role: models.BusinessRoleOwner, AddedAt: time.Now()}},
+		}
+
+		// --- STEP 3: Persist to Database ---
+		if err := buyers.Create(ctx, newBuyer); err != nil {
+			log.Printf("Error persisting buyer %s: %v", newBuyer.BusinessName, err)
+			http.Error(w, "Could not register buyer.", http.StatusInternalServerError)
+			return
+		}
+		// --- STEP 4: Link the registering user to the Buyer ---
+		// The link is re-checked inside Modify so two registrations racing for one
+		// account can't both claim it. The buyer is removed again if linking fails.
+		_, err := users.Modify(ctx, user.ID, func(u *models.User) error {
+			if u.AssociatedEntityID != "" {
+				return errAlreadyLinked
+			}
+			u.AssociatedEntityID = newBuyer.ID
+			u.AccountData = newBuyer
+			return nil
+		})
+		if err != nil {
+			log.Printf("Error linking user %s to buyer %s: %v", user.ID, newBuyer.ID, err)
+			if deleteErr := buyers.Delete(ctx, newBuyer.ID); deleteErr != nil {
+				log.Printf("Could not remove unlinked buyer %s: %v", newBuyer.ID, deleteErr)
+			}
+			if errors.Is(err, errAlreadyLinked) {
+				http.Error(w, "Your account is already linked to a business.", http.StatusConflict)
+				return
+			}
+			http.Error(w, "Could not register buyer.", http.StatusInternalServerError)
+			return
+		}
+		user.AssociatedEntityID = newBuyer.ID
+		user.AccountData = newBuyer
+
+		recordAudit(r, audit, models.AuditBusinessRegistered, models.AuditEntityBuyer, newBuyer.ID, nil, newBuyer)
+		log.Printf("SUCCESS: Buyer %s registered with license active until %s", newBuyer.BusinessName, newBuyer.LicenseExpirationDate.Format("2006-01-02"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Buyer registration successful.", "buyerID": newBuyer.ID})
+	}
 }
@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/wesleywinston/wds/pkg/middleware"
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/services"
+	"github.com/wesleywinston/wds/pkg/utils"
+)
+
+// ProductRequest is the payload for creating or replacing a product. ID, VendorID
+// and UpdatedAt are always set by the server. AvailableUnits only applies when
+// creating a product; after that stock changes through StockRequest, so an edit
+// can't undo units reserved by orders.
+type ProductRequest struct {
+	Name             string                      `json:"name"`
+	Description      string                      `json:"description"`
+	Category         string                      `json:"category"`
+	SubCategory      string                      `json:"subCategory"`
+	IsMedical        bool                        `json:"isMedical"`
+	PricePerUnit     models.Money                `json:"pricePerUnit"`
+	AvailableUnits   int                         `json:"availableUnits"`
+	MinOrderQuantity int                         `json:"minOrderQuantity"`
+	MaxOrderQuantity int                         `json:"maxOrderQuantity"`
+	CoaLink          string                      `json:"coaLink"`
+	StrainID         string                      `json:"strainID"` // optional; without it the product is matched to a strain by name
+	Compliance       models.ComplianceAttributes `json:"compliance"`
+	ComplianceTags   []string                    `json:"complianceTags"`
+}
+
+func (req ProductRequest) apply(p *models.Product) {
+	p.Name = req.Name
+	p.Description = req.Description
+	p.Category = services.CanonicalCategory(req.Category)
+	p.SubCategory = req.SubCategory
+	p.IsMedical = req.IsMedical
+	p.PricePerUnit = req.PricePerUnit
+	p.AvailableUnits = req.AvailableUnits
+	p.MinOrderQuantity = req.MinOrderQuantity
+	p.MaxOrderQuantity = req.MaxOrderQuantity
+	p.StrainID = req.StrainID
+	// Tags such as "THC: 25%" are folded into Compliance, the same way
+	// MigrateComplianceTags treats products saved before it existed.
+	compliance := req.Compliance
+	tags := services.FoldComplianceTags(&compliance, req.ComplianceTags)
+	// A new batch number means new stock the uploaded COA doesn't cover, so the
+	// COA is detached (its file stays stored) until one for the new batch is uploaded.
+	if p.COA != nil && compliance.BatchNumber != "" && !strings.EqualFold(compliance.BatchNumber, p.COA.Results.BatchNumber) {
+		p.COA = nil
+	}
+	// Otherwise CoaLink points at the uploaded COA and can't be edited.
+	if p.COA == nil {
+		p.CoaLink = req.CoaLink
+	}
+	p.Compliance, p.ComplianceTags = compliance, tags
+	if p.COA != nil {
+		p.Compliance.ApplyLabResults(p.COA.Results)
+	}
+}
+
+// StockRequest adds units to a product (a restock) or removes them (a write-off).
+type StockRequest struct {
+	Adjustment int `json:"adjustment"`
+}
+
+// MenuRequest toggles whether a vendor's products are visible on the marketplace.
+type MenuRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// callerEntityID returns the Vendor/Buyer ID linked to the authenticated user,
+// writing a 403 if the user hasn't registered a business yet.
+func callerEntityID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	user, ok := middleware.UserFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+		return "", false
+	}
+	if user.AssociatedEntityID == "" {
+		http.Error(w, "Register your business before using this endpoint.", http.StatusForbidden)
+		return "", false
+	}
+	return user.AssociatedEntityID, true
+}
+
+// loadOwnedProduct fetches the product named in the route and checks it belongs to
+// vendorID. Products owned by other vendors are reported as not found.
+func loadOwnedProduct(w http.ResponseWriter, r *http.Request, products repository.ProductRepository, vendorID string) (*models.Product, bool) {
+	product, err := products.Get(r.Context(), mux.Vars(r)["productID"])
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && product.VendorID != vendorID) {
+		http.Error(w, "Product not found.", http.StatusNotFound)
+		return nil, false
+	} else if err != nil {
+		log.Printf("Error loading product %s: %v", mux.Vars(r)["productID"], err)
+		http.Error(w, "Could not load product.", http.StatusInternalServerError)
+		return nil, false
+	}
+	return product, true
+}
+
+// linkStrain links product to its canonical strain, writing a 400 if the vendor
+// named a strain that doesn't exist.
+func linkStrain(w http.ResponseWriter, r *http.Request, strains *services.StrainService, product *models.Product) bool {
+	err := strains.LinkProduct(r.Context(), product)
+	if errors.Is(err, services.ErrStrainNotFound) {
+		http.Error(w, "Unknown strainID; look strains up with GET /strains?q=name.", http.StatusBadRequest)
+		return false
+	} else if err != nil {
+		log.Printf("Error linking product %s to a strain: %v", product.ID, err)
+		http.Error(w, "Could not save product.", http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// CreateProduct adds a product to the calling vendor's catalog.
+func CreateProduct(products repository.ProductRepository, strains *services.StrainService, audit *services.AuditLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vendorID, ok := callerEntityID(w, r)
+		if !ok {
+			return
+		}
+
+		var req ProductRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		product := models.Product{ID: utils.NewID("product"), VendorID: vendorID}
+		req.apply(&product)
+		if !linkStrain(w, r, strains, &product) {
+			return
+		}
+		if err := services.ValidateProduct(product); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		product.UpdatedAt = time.Now()
+		services.RecordPrice(&product, product.UpdatedAt)
+
+		if err := products.Create(r.Context(), product); err != nil {
+			log.Printf("Error creating product for vendor %s: %v", vendorID, err)
+			http.Error(w, "Could not create product.", http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("Product %s (%s) created by vendor %s", product.ID, product.Name, vendorID)
+		recordAudit(r, audit, models.AuditProductCreated, models.AuditEntityProduct, product.ID, nil, product)
+		writeJSON(w, http.StatusCreated, product)
+	}
+}
+
+// ListVendorProducts returns the calling vendor's whole catalog, whether or not the menu is enabled.
+func ListVendorProducts(products repository.ProductRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vendorID, ok := callerEntityID(w, r)
+		if !ok {
+			return
+		}
+
+		list, err := products.ListByVendor(r.Context(), vendorID)
+		if err != nil {
+			log.Printf("Error listing products for vendor %s: %v", vendorID, err)
+			http.Error(w, "Could not load catalog.", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, models.Catalog{TotalProducts: len(list), Products: list})
+	}
+}
+
+// UpdateProduct replaces the editable fields of one of the calling vendor's products.
+func UpdateProduct(products repository.ProductRepository, strains *services.StrainService, audit *services.AuditLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vendorID, ok := callerEntityID(w, r)
+		if !ok {
+			return
+		}
+		product, ok := loadOwnedProduct(w, r, products, vendorID)
+		if !ok {
+			return
+		}
+
+		var req ProductRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		// Apply the edit to the product as stored at write time, so a COA upload or
+		// strain link that lands after loadOwnedProduct isn't lost.
+		var before models.Product
+		updated, err := products.Modify(r.Context(), product.ID, func(p *models.Product) error {
+			before = *p
+			req.apply(p)
+			if err := strains.LinkProduct(r.Context(), p); err != nil {
+				return err
+			}
+			if err := services.ValidateProduct(*p); err != nil {
+				return err
+			}
+			p.UpdatedAt = time.Now()
+			services.RecordPrice(p, p.UpdatedAt)
+			return nil
+		})
+		switch {
+		case errors.Is(err, services.ErrInvalidProduct):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, services.ErrStrainNotFound):
+			http.Error(w, "Unknown strainID; look strains up with GET /strains?q=name.", http.StatusBadRequest)
+			return
+		case errors.Is(err, repository.ErrNotFound):
+			http.Error(w, "Product not found.", http.StatusNotFound)
+			return
+		case err != nil:
+			log.Printf("Error updating product %s: %v", product.ID, err)
+			http.Error(w, "Could not update product.", http.StatusInternalServerError)
+			return
+		}
+		recordAudit(r, audit, models.AuditProductUpdated, models.AuditEntityProduct, updated.ID, before, updated)
+		writeJSON(w, http.StatusOK, updated)
+	}
+}
+
+// AdjustStock restocks or writes off units of one of the calling vendor's products.
+// It goes through the same atomic path as order reservations, so the two can't
+// overwrite each other.
+func AdjustStock(products repository.ProductRepository, audit *services.AuditLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vendorID, ok := callerEntityID(w, r)
+		if !ok {
+			return
+		}
+		product, ok := loadOwnedProduct(w, r, products, vendorID)
+		if !ok {
+			return
+		}
+
+		var req StockRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Adjustment == 0 {
+			http.Error(w, "Invalid request payload: adjustment must be a non-zero number of units", http.StatusBadRequest)
+			return
+		}
+
+		err := products.AdjustStock(r.Context(), map[string]int{product.ID: req.Adjustment})
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			http.Error(w, "Not enough units in stock to remove that many.", http.StatusConflict)
+			return
+		} else if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Product not found.", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("Error adjusting stock for product %s: %v", product.ID, err)
+			http.Error(w, "Could not adjust stock.", http.StatusInternalServerError)
+			return
+		}
+
+		updated, err := products.Get(r.Context(), product.ID)
+		if err != nil {
+			log.Printf("Error reloading product %s: %v", product.ID, err)
+			http.Error(w, "Could not load product.", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Product %s stock adjusted by %+d by vendor %s", product.ID, req.Adjustment, vendorID)
+		recordAudit(r, audit, models.AuditProductUpdated, models.AuditEntityProduct, product.ID, product, updated)
+		writeJSON(w, http.StatusOK, updated)
+	}
+}
+
+// DeleteProduct removes one of the calling vendor's products.
+func DeleteProduct(products repository.ProductRepository, audit *services.AuditLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vendorID, ok := callerEntityID(w, r)
+		if !ok {
+			return
+		}
+		product, ok := loadOwnedProduct(w, r, products, vendorID)
+		if !ok {
+			return
+		}
+
+		if err := products.Delete(r.Context(), product.ID); err != nil {
+			log.Printf("Error deleting product %s: %v", product.ID, err)
+			http.Error(w, "Could not delete product.", http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("Product %s deleted by vendor %s", product.ID, vendorID)
+		recordAudit(r, audit, models.AuditProductDeleted, models.AuditEntityProduct, product.ID, product, nil)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// errMenuNotCompliant stops a non-compliant vendor's menu from going live.
+var errMenuNotCompliant = errors.New("vendor is not compliant")
+
+// SetMenuEnabled shows or hides the calling vendor's products on the marketplace.
+// Only a compliant vendor may turn its menu on.
+func SetMenuEnabled(vendors repository.VendorRepository, audit *services.AuditLog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vendorID, ok := callerEntityID(w, r)
+		if !ok {
+			return
+		}
+
+		var req MenuRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		// Only MenuEnabled is written, so a license re-check or membership change
+		// landing at the same time isn't undone.
+		var before map[string]bool
+		vendor, err := vendors.Modify(r.Context(), vendorID, func(v *models.Vendor) error {
+			if req.Enabled && !v.IsCompliant() {
+				return errMenuNotCompliant
+			}
+			before = map[string]bool{"menuEnabled": v.MenuEnabled}
+			v.MenuEnabled = req.Enabled
+			return nil
+		})
+		if errors.Is(err, errMenuNotCompliant) {
+			http.Error(w, "Your license must be verified and current before your menu can go live.", http.StatusForbidden)
+			return
+		} else if err != nil {
+			log.Printf("Error updating vendor %s: %v", vendorID, err)
+			http.Error(w, "Could not update menu.", http.StatusInternalServerError)
+			return
+		}
+		recordAudit(r, audit, models.AuditMenuUpdated, models.AuditEntityVendor, vendor.ID, before, map[string]bool{"menuEnabled": vendor.MenuEnabled})
+		writeJSON(w, http.StatusOK, map[string]bool{"menuEnabled": vendor.MenuEnabled})
+	}
+}
+
+// GetVendorCatalog returns a vendor's public catalog. Vendors whose menu is disabled
+// are reported as not found so hidden menus don't leak.
+func GetVendorCatalog(vendors repository.VendorRepository, products repository.ProductRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vendorID := mux.Vars(r)["vendorID"]
+
+		vendor, err := vendors.Get(r.Context(), vendorID)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && !vendor.MenuEnabled) {
+			http.Error(w, "Vendor not found.", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("Error loading vendor %s: %v", vendorID, err)
+			http.Error(w, "Could not load catalog.", http.StatusInternalServerError)
+			return
+		}
+
+		list, err := products.ListByVendor(r.Context(), vendorID)
+		if err != nil {
+			log.Printf("Error listing products for vendor %s: %v", vendorID, err)
+			http.Error(w, "Could not load catalog.", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, models.Catalog{TotalProducts: len(list), Products: list})
+	}
+}
+
+// GetProductDetail returns a product with its vendor's public profile and compliance
+// badge, live stock, price history and parsed lab results.
+func GetProductDetail(details *services.ProductDetailService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		detail, err := details.Detail(r.Context(), user, mux.Vars(r)["productID"])
+		if errors.Is(err, services.ErrProductNotFound) {
+			http.Error(w, "Product not found.", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("Error loading product %s: %v", mux.Vars(r)["productID"], err)
+			http.Error(w, "Could not load product.", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, detail)
+	}
+}
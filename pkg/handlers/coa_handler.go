@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/wesleywinston/wds/pkg/middleware"
+	"github.com/wesleywinston/wds/pkg/services"
+)
+
+// maxCOASize caps COA uploads. Lab PDFs run to a few hundred KB.
+const maxCOASize = 10 << 20
+
+// writeCOAError maps COA service errors onto HTTP status codes.
+func writeCOAError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCOA):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrCOAMismatch), errors.Is(err, services.ErrCOAFailed):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, services.ErrProductNotFound):
+		http.Error(w, "Product not found.", http.StatusNotFound)
+	case errors.Is(err, services.ErrCOANotFound):
+		http.Error(w, "No certificate of analysis has been uploaded for this product.", http.StatusNotFound)
+	default:
+		log.Printf("COA request failed: %v", err)
+		http.Error(w, "Could not process certificate of analysis.", http.StatusInternalServerError)
+	}
+}
+
+// UploadCOA attaches a certificate of analysis to one of the caller's products.
+// It takes a multipart form with the COA in "file" (PDF or the lab's CSV export)
+// and the "labID" and "batchNumber" it is expected to show.
+func UploadCOA(coas *services.COAService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxCOASize+1<<20)
+		if err := r.ParseMultipartForm(maxCOASize); err != nil {
+			http.Error(w, "Invalid upload: send a multipart form with the COA in \"file\".", http.StatusBadRequest)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "Invalid upload: send a multipart form with the COA in \"file\".", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, err := io.ReadAll(io.LimitReader(file, maxCOASize+1))
+		if err != nil {
+			http.Error(w, "Could not read upload.", http.StatusBadRequest)
+			return
+		}
+		if len(data) > maxCOASize {
+			http.Error(w, fmt.Sprintf("COA files are limited to %d MB.", maxCOASize>>20), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		product, err := coas.Upload(r.Context(), user, mux.Vars(r)["productID"], header.Filename, data, r.FormValue("labID"), r.FormValue("batchNumber"))
+		if err != nil {
+			writeCOAError(w, err)
+			return
+		}
+		log.Printf("COA %s uploaded for product %s by %s", product.COA.SHA256[:12], product.ID, user.ID)
+		writeJSON(w, http.StatusOK, product)
+	}
+}
+
+// DownloadCOA serves the original COA file for a product.
+func DownloadCOA(coas *services.COAService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		file, doc, err := coas.Open(r.Context(), user, mux.Vars(r)["productID"])
+		if err != nil {
+			writeCOAError(w, err)
+			return
+		}
+		defer file.Close()
+
+		w.Header().Set("Content-Type", doc.ContentType)
+		w.Header().Set("Content-Length", strconv.FormatInt(doc.Size, 10))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", doc.FileName))
+		if _, err := io.Copy(w, file); err != nil {
+			log.Printf("COA download for product %s aborted: %v", mux.Vars(r)["productID"], err)
+		}
+	}
+}
@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/wesleywinston/wds/pkg/middleware"
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/services"
+)
+
+// PostRequest is the payload for publishing a post.
+type PostRequest struct {
+	Kind       models.PostKind `json:"kind"`
+	Body       string          `json:"body"`
+	ProductIDs []string        `json:"productIDs"`
+	EventAt    time.Time       `json:"eventAt"` // EVENT posts only, RFC 3339
+}
+
+// CommentRequest is the payload for commenting on a post.
+type CommentRequest struct {
+	Body string `json:"body"`
+}
+
+// ReportRequest is the payload for reporting a post or comment.
+type ReportRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ModerationRequest is an admin's action on a post or comment, or on a report.
+// BanUntil only applies when resolving a report with BAN; zero bans indefinitely.
+type ModerationRequest struct {
+	Action   services.ModerationAction `json:"action"`
+	Note     string                    `json:"note"`
+	BanUntil time.Time                 `json:"banUntil"`
+}
+
+// BanRequest bans a business from the community. A zero Until bans indefinitely.
+type BanRequest struct {
+	Reason string    `json:"reason"`
+	Until  time.Time `json:"until"`
+}
+
+// writeCommunityError maps community service errors onto HTTP status codes.
+func writeCommunityError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidPost), errors.Is(err, services.ErrInvalidModeration):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrCommunityNotAllowed):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrPostNotFound):
+		http.Error(w, "Post not found.", http.StatusNotFound)
+	case errors.Is(err, services.ErrCommentNotFound):
+		http.Error(w, "Comment not found.", http.StatusNotFound)
+	case errors.Is(err, services.ErrReportNotFound):
+		http.Error(w, "Report not found.", http.StatusNotFound)
+	default:
+		log.Printf("Community request failed: %v", err)
+		http.Error(w, "Could not process request.", http.StatusInternalServerError)
+	}
+}
+
+// GetFeed returns a page of the community feed, newest first, i.e.
+// /community/feed?kind=RESTOCK&limit=20. Pass the page's nextBefore as before to
+// fetch the next one.
+func GetFeed(community *services.CommunityService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		params := r.URL.Query()
+		q := services.FeedQuery{
+			Kind:     models.PostKind(strings.ToUpper(params.Get("kind"))),
+			EntityID: params.Get("entityID"),
+		}
+		var err error
+		if v := params.Get("before"); v != "" {
+			if q.Before, err = time.Parse(time.RFC3339Nano, v); err != nil {
+				http.Error(w, "before must be an RFC 3339 timestamp", http.StatusBadRequest)
+				return
+			}
+		}
+		if v := params.Get("limit"); v != "" {
+			if q.Limit, err = strconv.Atoi(v); err != nil {
+				http.Error(w, "limit must be a number", http.StatusBadRequest)
+				return
+			}
+		}
+
+		page, err := community.Feed(r.Context(), user, q)
+		if err != nil {
+			writeCommunityError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, page)
+	}
+}
+
+// GetPost returns one post with its comments.
+func GetPost(community *services.CommunityService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		post, err := community.GetPost(r.Context(), user, mux.Vars(r)["postID"])
+		if err != nil {
+			writeCommunityError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, post)
+	}
+}
+
+// CreatePost publishes a post for the caller's business.
+func CreatePost(community *services.CommunityService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		var req PostRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		post, err := community.CreatePost(r.Context(), user, services.PostDraft{
+			Kind:       models.PostKind(strings.ToUpper(string(req.Kind))),
+			Body:       req.Body,
+			ProductIDs: req.ProductIDs,
+			EventAt:    req.EventAt,
+		})
+		if err != nil {
+			writeCommunityError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, post)
+	}
+}
+
+// DeletePost removes one of the caller's business's posts.
+func DeletePost(community *services.CommunityService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		if err := community.DeletePost(r.Context(), user, mux.Vars(r)["postID"]); err != nil {
+			writeCommunityError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// SetReaction adds (PUT) or removes (DELETE) the caller's reaction to a post and
+// returns the post's updated counts.
+func SetReaction(community *services.CommunityService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		vars := mux.Vars(r)
+		reaction := models.Reaction(strings.ToUpper(vars["reaction"]))
+		post, err := community.React(r.Context(), user, vars["postID"], reaction, r.Method == http.MethodPut)
+		if err != nil {
+			writeCommunityError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, post)
+	}
+}
+
+// CreateComment replies to a post.
+func CreateComment(community *services.CommunityService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		var req CommentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		comment, err := community.AddComment(r.Context(), user, mux.Vars(r)["postID"], req.Body)
+		if err != nil {
+			writeCommunityError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, comment)
+	}
+}
+
+// DeleteComment removes one of the caller's business's comments.
+func DeleteComment(community *services.CommunityService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		if err := community.DeleteComment(r.Context(), user, mux.Vars(r)["commentID"]); err != nil {
+			writeCommunityError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// contentTarget returns the post or comment named in the route.
+func contentTarget(r *http.Request) (models.AuditEntityType, string) {
+	vars := mux.Vars(r)
+	if id, ok := vars["commentID"]; ok {
+		return models.AuditEntityComment, id
+	}
+	return models.AuditEntityPost, vars["postID"]
+}
+
+// ReportContent files a report about the post or comment named in the route.
+func ReportContent(community *services.CommunityService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		var req ReportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		targetType, targetID := contentTarget(r)
+		report, err := community.Report(r.Context(), user, targetType, targetID, req.Reason)
+		if err != nil {
+			writeCommunityError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, report)
+	}
+}
+
+// ListReports returns the moderation queue, i.e. /admin/community/reports?status=OPEN
+// (the default).
+func ListReports(community *services.CommunityService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := models.ReportStatus(strings.ToUpper(r.URL.Query().Get("status")))
+		if status == "" {
+			status = models.ReportOpen
+		}
+
+		queue, err := community.Reports(r.Context(), status)
+		if err != nil {
+			writeCommunityError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, queue)
+	}
+}
+
+// ResolveReport closes a report by dismissing it, hiding the content, or hiding it
+// and banning its author's business.
+func ResolveReport(community *services.CommunityService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		admin, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		var req ModerationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		action := services.ModerationAction(strings.ToUpper(string(req.Action)))
+		report, err := community.ResolveReport(r.Context(), admin, mux.Vars(r)["reportID"], action, req.Note, req.BanUntil)
+		if err != nil {
+			writeCommunityError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+	}
+}
+
+// ModerateContent hides, unhides, flags or unflags the post or comment named in the route.
+func ModerateContent(community *services.CommunityService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		admin, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		var req ModerationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		targetType, targetID := contentTarget(r)
+		action := services.ModerationAction(strings.ToUpper(string(req.Action)))
+		if err := community.Moderate(r.Context(), admin, targetType, targetID, action, req.Note); err != nil {
+			writeCommunityError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// SetCommunityBan bans (PUT) or unbans (DELETE) the business named in the route.
+func SetCommunityBan(community *services.CommunityService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		admin, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+		entityID := mux.Vars(r)["entityID"]
+
+		if r.Method == http.MethodDelete {
+			if err := community.Unban(r.Context(), admin, entityID); err != nil {
+				writeCommunityError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var req BanRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+		if err := community.Ban(r.Context(), admin, entityID, req.Reason, req.Until); err != nil {
+			writeCommunityError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/middleware"
+	"github.com/wesleywinston/wds/pkg/services"
+)
+
+// parseDashboardQuery reads the reporting window and thresholds from the query
+// string, i.e. /vendor/dashboard?from=2025-01-01&to=2025-03-31&top=5&lowStock=20.
+// from and to are dates (to is inclusive) or RFC 3339 timestamps (to is exclusive).
+func parseDashboardQuery(r *http.Request, loc *time.Location) (services.DashboardQuery, error) {
+	v := r.URL.Query()
+	var q services.DashboardQuery
+
+	parseTime := func(name string, endOfDay bool) (time.Time, error) {
+		s := v.Get(name)
+		if s == "" {
+			return time.Time{}, nil
+		}
+		if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+			if endOfDay {
+				t = t.AddDate(0, 0, 1)
+			}
+			return t, nil
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return t, fmt.Errorf("%s must be a date like 2025-01-31 or an RFC 3339 timestamp", name)
+		}
+		return t, nil
+	}
+	var err error
+	if q.From, err = parseTime("from", false); err != nil {
+		return q, err
+	}
+	if q.To, err = parseTime("to", true); err != nil {
+		return q, err
+	}
+
+	for name, dst := range map[string]*int{"top": &q.TopProducts, "lowStock": &q.LowStockThreshold} {
+		if s := v.Get(name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 {
+				return q, errors.New(name + " must be a positive integer")
+			}
+			*dst = n
+		}
+	}
+	return q, nil
+}
+
+// VendorDashboard returns sales, top products, open orders, low stock and the
+// license countdown for the caller's business. Dates are interpreted in loc.
+func VendorDashboard(dashboards *services.DashboardService, loc *time.Location) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		q, err := parseDashboardQuery(r, loc)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		dashboard, err := dashboards.VendorDashboard(r.Context(), user, q)
+		if errors.Is(err, services.ErrInvalidDashboardQuery) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Printf("Error building dashboard for %s: %v", user.AssociatedEntityID, err)
+			http.Error(w, "Could not build dashboard.", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, dashboard)
+	}
+}
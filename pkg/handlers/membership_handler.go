@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/wesleywinston/wds/pkg/middleware"
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/services"
+)
+
+// InviteRequest invites a teammate by email, i.e. {"email": "chad@easystreet.com", "role": "STAFF"}.
+type InviteRequest struct {
+	Email string              `json:"email"`
+	Role  models.BusinessRole `json:"role"`
+}
+
+// InviteResponse returns the invitation along with the one-time token to forward to the invitee.
+type InviteResponse struct {
+	Invitation models.Invitation `json:"invitation"`
+	Token      string            `json:"token"`
+}
+
+// AcceptInvitationRequest redeems an invitation. Password (and FullName) set up a new
+// account, or must match the invitee's existing one.
+type AcceptInvitationRequest struct {
+	Token    string   `json:"token"`
+	Password string   `json:"password"`
+	FullName []string `json:"fullName,omitempty"`
+}
+
+// MemberRoleRequest changes a member's business role, i.e. {"role": "MANAGER"}.
+type MemberRoleRequest struct {
+	Role models.BusinessRole `json:"role"`
+}
+
+// writeMembershipError maps membership service errors onto HTTP status codes.
+func writeMembershipError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidMembership):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrMembershipNotAllowed):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrMemberNotFound):
+		http.Error(w, "Member not found.", http.StatusNotFound)
+	case errors.Is(err, services.ErrInvitationNotFound):
+		http.Error(w, "Invitation not found.", http.StatusNotFound)
+	case errors.Is(err, repository.ErrAlreadyExists):
+		http.Error(w, "An account with this email already exists.", http.StatusConflict)
+	default:
+		log.Printf("Membership request failed: %v", err)
+		http.Error(w, "Could not update business membership.", http.StatusInternalServerError)
+	}
+}
+
+// ListMembers returns everyone with access to the caller's business.
+func ListMembers(memberships *services.MembershipService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		members, err := memberships.Members(r.Context(), user)
+		if err != nil {
+			writeMembershipError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, members)
+	}
+}
+
+// UpdateMemberRole changes a member's role within the caller's business. Owners only.
+func UpdateMemberRole(memberships *services.MembershipService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		var req MemberRoleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		member, err := memberships.ChangeRole(r.Context(), user, mux.Vars(r)["userID"], req.Role)
+		if err != nil {
+			writeMembershipError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, member)
+	}
+}
+
+// RemoveMember revokes a member's access to the caller's business.
+func RemoveMember(memberships *services.MembershipService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		if err := memberships.Remove(r.Context(), user, mux.Vars(r)["userID"]); err != nil {
+			writeMembershipError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// InviteMember invites a teammate by email to join the caller's business.
+func InviteMember(memberships *services.MembershipService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		var req InviteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		invitation, token, err := memberships.Invite(r.Context(), user, req.Email, req.Role)
+		if err != nil {
+			writeMembershipError(w, err)
+			return
+		}
+		log.Printf("Invitation %s sent by %s to %s as %s", invitation.ID, user.ID, invitation.Email, invitation.Role)
+		writeJSON(w, http.StatusCreated, InviteResponse{Invitation: *invitation, Token: token})
+	}
+}
+
+// ListInvitations returns the invitations sent for the caller's business.
+func ListInvitations(memberships *services.MembershipService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		invitations, err := memberships.Invitations(r.Context(), user)
+		if err != nil {
+			writeMembershipError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, invitations)
+	}
+}
+
+// RevokeInvitation withdraws a pending invitation.
+func RevokeInvitation(memberships *services.MembershipService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		if err := memberships.RevokeInvitation(r.Context(), user, mux.Vars(r)["invitationID"]); err != nil {
+			writeMembershipError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// AcceptInvitation redeems a business or admin invitation token and signs the invitee in. It is public:
+// the token itself proves the invitee received the invitation.
+func AcceptInvitation(memberships *services.MembershipService, tokens *services.TokenIssuer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req AcceptInvitationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		user, err := memberships.AcceptInvitation(r.Context(), req.Token, req.Password, req.FullName)
+		if err != nil {
+			writeMembershipError(w, err)
+			return
+		}
+
+		token, err := tokens.Issue(*user)
+		if err != nil {
+			log.Printf("Error issuing token for %s: %v", user.ID, err)
+			http.Error(w, "Invitation accepted, but sign-in failed. Please log in.", http.StatusInternalServerError)
+			return
+		}
+		message := fmt.Sprintf("%s joined business %s.", user.Email, user.AssociatedEntityID)
+		if user.Role == models.RoleAdmin {
+			message = fmt.Sprintf("%s joined as an admin.", user.Email)
+		}
+		writeJSON(w, http.StatusOK, models.AuthResponse{
+			Message: message,
+			UserID:  user.ID,
+			Token:   token,
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/wesleywinston/wds/pkg/middleware"
+	"github.com/wesleywinston/wds/pkg/services"
+)
+
+// writeNotificationError maps notification service errors onto HTTP status codes.
+func writeNotificationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrNotificationNotFound):
+		http.Error(w, "Notification not found.", http.StatusNotFound)
+	default:
+		log.Printf("Notification request failed: %v", err)
+		http.Error(w, "Could not process request.", http.StatusInternalServerError)
+	}
+}
+
+// ListNotifications returns the caller's in-app notifications, newest first, i.e.
+// /notifications?unread=true for only the unread ones.
+func ListNotifications(notifications *services.NotificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		inbox, err := notifications.Inbox(r.Context(), user, r.URL.Query().Get("unread") == "true")
+		if err != nil {
+			writeNotificationError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, inbox)
+	}
+}
+
+// MarkNotificationRead marks one of the caller's notifications read.
+func MarkNotificationRead(notifications *services.NotificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		if err := notifications.MarkRead(r.Context(), user, mux.Vars(r)["notificationID"]); err != nil {
+			writeNotificationError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
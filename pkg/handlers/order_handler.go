@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/wesleywinston/wds/pkg/middleware"
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/services"
+)
+
+// PlaceOrderRequest is the payload a buyer submits. Unit prices are looked up by the
+// server; any price sent by the client is ignored.
+type PlaceOrderRequest struct {
+	Items           []models.OrderItem   `json:"items"`
+	ShippingAddress string               `json:"shippingAddress"`
+	PaymentMethod   models.PaymentMethod `json:"paymentMethod"`
+	DeliveryDate    string               `json:"deliveryDate"`
+}
+
+// writeOrderError maps order service errors onto HTTP status codes.
+func writeOrderError(w http.ResponseWriter, err error) {
+	var cerr *services.ComplianceError
+	switch {
+	case errors.As(err, &cerr):
+		middleware.WriteComplianceError(w, cerr)
+	case errors.Is(err, services.ErrInvalidOrder):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrOrderNotAllowed):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrIllegalTransition):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrOrderNotFound):
+		http.Error(w, "Order not found.", http.StatusNotFound)
+	case errors.Is(err, repository.ErrInsufficientStock), errors.Is(err, services.ErrPriceChanged):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("Order request failed: %v", err)
+		http.Error(w, "Could not process order.", http.StatusInternalServerError)
+	}
+}
+
+// PlaceOrder creates a PENDING order for the calling buyer and reserves its stock.
+func PlaceOrder(orders *services.OrderService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		var req PlaceOrderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		order, err := orders.Place(r.Context(), user, services.OrderDraft{
+			Items:           req.Items,
+			ShippingAddress: req.ShippingAddress,
+			PaymentMethod:   req.PaymentMethod,
+			DeliveryDate:    req.DeliveryDate,
+		})
+		if err != nil {
+			writeOrderError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, order)
+	}
+}
+
+// ListOrders returns the calling business's orders.
+func ListOrders(orders *services.OrderService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		list, err := orders.List(r.Context(), user)
+		if err != nil {
+			writeOrderError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, list)
+	}
+}
+
+// OrderStatusRequest moves an order to a new status, i.e. {"status": "ACCEPTED"}.
+type OrderStatusRequest struct {
+	Status models.OrderStatus `json:"status"`
+	Reason string             `json:"reason,omitempty"`
+}
+
+// UpdateOrderStatus moves one of the caller's orders through its lifecycle. The order
+// state machine decides which moves the caller's role may make.
+func UpdateOrderStatus(orders *services.OrderService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		var req OrderStatusRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		order, err := orders.Transition(r.Context(), user, mux.Vars(r)["orderID"], req.Status, req.Reason)
+		if err != nil {
+			writeOrderError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, order)
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/services"
+)
+
+// parseSearchQuery reads marketplace filters from the URL, i.e.
+// /marketplace/search?q=blue+dream&category=Flower&medical=true&minPrice=5&minTHC=20&strainType=SATIVA&limit=20
+func parseSearchQuery(r *http.Request) (services.SearchQuery, error) {
+	v := r.URL.Query()
+	q := services.SearchQuery{
+		Text:        v.Get("q"),
+		Category:    v.Get("category"),
+		SubCategory: v.Get("subCategory"),
+		StrainType:  models.StrainType(strings.ToUpper(v.Get("strainType"))),
+		TestStatus:  models.TestStatus(strings.ToUpper(v.Get("testStatus"))),
+		Tags:        v["tag"],
+		Cursor:      v.Get("cursor"),
+	}
+	switch q.StrainType {
+	case "", models.StrainIndica, models.StrainSativa, models.StrainHybrid:
+	default:
+		return q, errors.New("strainType must be INDICA, SATIVA or HYBRID")
+	}
+	switch q.TestStatus {
+	case "", models.TestPending, models.TestPassed, models.TestFailed:
+	default:
+		return q, errors.New("testStatus must be PENDING, PASSED or FAILED")
+	}
+
+	if s := v.Get("medical"); s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return q, errors.New("medical must be true or false")
+		}
+		q.IsMedical = &b
+	}
+	if s := v.Get("inStock"); s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return q, errors.New("inStock must be true or false")
+		}
+		q.InStock = b
+	}
+	for name, dst := range map[string]**models.Money{"minPrice": &q.MinPrice, "maxPrice": &q.MaxPrice} {
+		if s := v.Get(name); s != "" {
+			m, err := models.ParseMoney(s, models.USD)
+			if err != nil || m.IsNegative() {
+				return q, errors.New(name + " must be a non-negative amount like 12.50")
+			}
+			*dst = &m
+		}
+	}
+	for name, dst := range map[string]**float64{"minTHC": &q.MinTHC, "maxTHC": &q.MaxTHC, "minCBD": &q.MinCBD, "maxCBD": &q.MaxCBD} {
+		if s := v.Get(name); s != "" {
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil || f < 0 || f > 100 {
+				return q, errors.New(name + " must be a percentage between 0 and 100")
+			}
+			*dst = &f
+		}
+	}
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return q, errors.New("limit must be a positive integer")
+		}
+		q.Limit = n
+	}
+	return q, nil
+}
+
+// SearchMarketplace searches products across every live vendor menu.
+func SearchMarketplace(vendors repository.VendorRepository, products repository.ProductRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q, err := parseSearchQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		catalog, err := services.SearchMarketplace(r.Context(), vendors, products, q)
+		if errors.Is(err, services.ErrInvalidCursor) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Printf("Marketplace search failed: %v", err)
+			http.Error(w, "Search is unavailable right now.", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, catalog)
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/services"
+)
+
+// ListStrains searches the strain reference database by name or alias, i.e.
+// /strains?q=gg4 or /strains?type=INDICA.
+func ListStrains(strains *services.StrainService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		strainType := models.StrainType(strings.ToUpper(r.URL.Query().Get("type")))
+		switch strainType {
+		case "", models.StrainIndica, models.StrainSativa, models.StrainHybrid:
+		default:
+			http.Error(w, "type must be INDICA, SATIVA or HYBRID", http.StatusBadRequest)
+			return
+		}
+
+		list, err := strains.Search(r.Context(), r.URL.Query().Get("q"), strainType)
+		if err != nil {
+			log.Printf("Error searching strains: %v", err)
+			http.Error(w, "Could not search strains.", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, list)
+	}
+}
+
+// GetStrain returns one strain.
+func GetStrain(strains *services.StrainService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		strain, err := strains.Get(r.Context(), mux.Vars(r)["strainID"])
+		if errors.Is(err, services.ErrStrainNotFound) {
+			http.Error(w, "Strain not found.", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("Error loading strain %s: %v", mux.Vars(r)["strainID"], err)
+			http.Error(w, "Could not load strain.", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, strain)
+	}
+}
+
+// ImportStrains runs the strain import now rather than waiting for the weekly run.
+func ImportStrains(strains *services.StrainService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, err := strains.Import(r.Context())
+		if err != nil {
+			log.Printf("Strain import failed: %v", err)
+			http.Error(w, "Strain import failed: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/wesleywinston/wds/pkg/middleware"
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/services"
+)
+
+// ReorderListRequest creates or replaces a reorder list. Saved prices are set by
+// the server; any sent by the client are ignored.
+type ReorderListRequest struct {
+	Name  string               `json:"name"`
+	Items []models.ReorderItem `json:"items"`
+}
+
+// ReorderRequest orders a reorder list. The delivery details are the same as for
+// PlaceOrderRequest.
+type ReorderRequest struct {
+	ShippingAddress string                  `json:"shippingAddress"`
+	PaymentMethod   models.PaymentMethod    `json:"paymentMethod"`
+	DeliveryDate    string                  `json:"deliveryDate"`
+	AcceptedPrices  map[string]models.Money `json:"acceptedPrices"` // new unit prices the buyer agreed to, by product ID
+}
+
+// writeVaultError maps vault service errors onto HTTP status codes. Errors from
+// placing a reorder are handled like any other order's.
+func writeVaultError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrVaultNotAllowed):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrInvalidReorderList):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrVaultItemNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrReorderListNotFound):
+		http.Error(w, "Reorder list not found.", http.StatusNotFound)
+	case errors.Is(err, services.ErrReorderPricesChanged):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		writeOrderError(w, err)
+	}
+}
+
+// GetVault returns the caller's business's favorites and reorder lists, checked
+// against current prices and stock.
+func GetVault(vaults *services.VaultService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		view, err := vaults.Get(r.Context(), user)
+		if errors.Is(err, services.ErrVaultNotAllowed) {
+			writeVaultError(w, err)
+			return
+		} else if err != nil {
+			log.Printf("Error loading vault for buyer %s: %v", user.AssociatedEntityID, err)
+			http.Error(w, "Could not load vault.", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, view)
+	}
+}
+
+// SetFavoriteVendor saves (PUT) or removes (DELETE) the vendor named in the route.
+func SetFavoriteVendor(vaults *services.VaultService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		if err := vaults.SetFavoriteVendor(r.Context(), user, mux.Vars(r)["vendorID"], r.Method == http.MethodPut); err != nil {
+			writeVaultError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// SetFavoriteProduct saves (PUT) or removes (DELETE) the product named in the route.
+func SetFavoriteProduct(vaults *services.VaultService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		if err := vaults.SetFavoriteProduct(r.Context(), user, mux.Vars(r)["productID"], r.Method == http.MethodPut); err != nil {
+			writeVaultError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// CreateReorderList saves a new reorder list.
+func CreateReorderList(vaults *services.VaultService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		var req ReorderListRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		list, err := vaults.CreateList(r.Context(), user, req.Name, req.Items)
+		if err != nil {
+			writeVaultError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, list)
+	}
+}
+
+// UpdateReorderList replaces a reorder list's name and items.
+func UpdateReorderList(vaults *services.VaultService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		var req ReorderListRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		list, err := vaults.UpdateList(r.Context(), user, mux.Vars(r)["listID"], req.Name, req.Items)
+		if err != nil {
+			writeVaultError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, list)
+	}
+}
+
+// DeleteReorderList removes a reorder list.
+func DeleteReorderList(vaults *services.VaultService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		if err := vaults.DeleteList(r.Context(), user, mux.Vars(r)["listID"]); err != nil {
+			writeVaultError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// PlaceReorder turns a reorder list into a new PENDING order in one call.
+func PlaceReorder(vaults *services.VaultService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := middleware.UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		var req ReorderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			return
+		}
+
+		result, err := vaults.Reorder(r.Context(), user, mux.Vars(r)["listID"], services.ReorderDraft{
+			ShippingAddress: req.ShippingAddress,
+			PaymentMethod:   req.PaymentMethod,
+			DeliveryDate:    req.DeliveryDate,
+			AcceptedPrices:  req.AcceptedPrices,
+		})
+		if err != nil {
+			writeVaultError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, result)
+	}
+}
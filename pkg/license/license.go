@@ -0,0 +1,132 @@
+// Package license interprets Oklahoma (OMMA) commercial license IDs and encodes
+// which license types may trade with each other.
+package license
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/wesleywinston/wds/pkg/models"
+)
+
+var (
+	ErrInvalidFormat   = errors.New("license ID must look like PAAA-DJ3F-28JJ-283H")
+	ErrUnknownType     = errors.New("license ID prefix does not match a known license type")
+	ErrTypeMismatch    = errors.New("license type does not match state records")
+	ErrTradeNotAllowed = errors.New("license types are not permitted to trade")
+	ErrCannotSell      = errors.New("license type is not permitted to sell to other businesses")
+	ErrCannotPurchase  = errors.New("license type is not permitted to purchase from other businesses")
+)
+
+// idPattern matches four dash-separated groups of four characters; the first
+// group is the letter-only type prefix, i.e. "PAAA" for a processor.
+var idPattern = regexp.MustCompile(`^[A-Z]{4}(-[A-Z0-9]{4}){3}$`)
+
+// prefixes maps the first letter of a license ID to its type.
+// G - grow, P - processor, D - dispensary
+var prefixes = map[byte]models.LicenseType{
+	'G': models.LicenseTypeGrower,
+	'P': models.LicenseTypeProcessor,
+	'D': models.LicenseTypeDispensary,
+}
+
+// entityTypes maps OMMAVerificationResponse.EntityType values to license types.
+var entityTypes = map[string]models.LicenseType{
+	"grower":     models.LicenseTypeGrower,
+	"processor":  models.LicenseTypeProcessor,
+	"dispensary": models.LicenseTypeDispensary,
+}
+
+// tradeRules lists, for each selling license type, the license types it may sell to.
+// Growers supply processors and dispensaries; processors supply other processors
+// and dispensaries; dispensaries only sell to patients, never to businesses.
+var tradeRules = map[models.LicenseType][]models.LicenseType{
+	models.LicenseTypeGrower:    {models.LicenseTypeProcessor, models.LicenseTypeDispensary},
+	models.LicenseTypeProcessor: {models.LicenseTypeProcessor, models.LicenseTypeDispensary},
+}
+
+// Normalize upper-cases and trims a license ID as typed by a user.
+func Normalize(id string) string {
+	return strings.ToUpper(strings.TrimSpace(id))
+}
+
+// Parse validates the format of a license ID and returns its license type.
+func Parse(id string) (models.LicenseType, error) {
+	id = Normalize(id)
+	if !idPattern.MatchString(id) {
+		return "", ErrInvalidFormat
+	}
+	t, ok := prefixes[id[0]]
+	if !ok {
+		return "", ErrUnknownType
+	}
+	return t, nil
+}
+
+// FromEntityType converts the state API's EntityType ("Grower", "Dispensary", "Processor").
+func FromEntityType(entityType string) (models.LicenseType, error) {
+	t, ok := entityTypes[strings.ToLower(strings.TrimSpace(entityType))]
+	if !ok {
+		return "", ErrUnknownType
+	}
+	return t, nil
+}
+
+// MatchesEntityType reports an error if the type derived from id disagrees with the state's record.
+func MatchesEntityType(id string, entityType string) error {
+	fromID, err := Parse(id)
+	if err != nil {
+		return err
+	}
+	fromState, err := FromEntityType(entityType)
+	if err != nil {
+		return err
+	}
+	if fromID != fromState {
+		return fmt.Errorf("%w: ID says %s, state says %s", ErrTypeMismatch, fromID, fromState)
+	}
+	return nil
+}
+
+// CanSellTo reports whether a seller holding one license type may sell to a buyer holding another.
+func CanSellTo(seller, buyer models.LicenseType) bool {
+	for _, allowed := range tradeRules[seller] {
+		if allowed == buyer {
+			return true
+		}
+	}
+	return false
+}
+
+// CanSell reports whether a license type may sell to any business, i.e. act as a Vendor.
+func CanSell(t models.LicenseType) bool {
+	return len(tradeRules[t]) > 0
+}
+
+// CanPurchase reports whether a license type may buy from any business, i.e. act as a Buyer.
+func CanPurchase(t models.LicenseType) bool {
+	for seller := range tradeRules {
+		if CanSellTo(seller, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTrade verifies both license IDs and that the seller's type may sell to the buyer's.
+func CheckTrade(sellerLicenseID, buyerLicenseID string) error {
+	seller, err := Parse(sellerLicenseID)
+	if err != nil {
+		return fmt.Errorf("seller license: %w", err)
+	}
+	buyer, err := Parse(buyerLicenseID)
+	if err != nil {
+		return fmt.Errorf("buyer license: %w", err)
+	}
+	if !CanSellTo(seller, buyer) {
+		return fmt.Errorf("%w: %s cannot sell to %s", ErrTradeNotAllowed, seller, buyer)
+	}
+	return nil
+}
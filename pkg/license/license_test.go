@@ -0,0 +1,90 @@
+package license
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wesleywinston/wds/pkg/models"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		id      string
+		want    models.LicenseType
+		wantErr error
+	}{
+		{"GAAA-4K7M-2Q9X-8B3N", models.LicenseTypeGrower, nil},
+		{"PAAA-DJ3F-28JJ-283H", models.LicenseTypeProcessor, nil},
+		{" daaa-7h2l-5r8t-1c6w ", models.LicenseTypeDispensary, nil},
+		{"XAAA-DJ3F-28JJ-283H", "", ErrUnknownType},
+		{"P1AA-DJ3F-28JJ-283H", "", ErrInvalidFormat},
+		{"PAAA-DJ3F-28JJ", "", ErrInvalidFormat},
+		{"PAAA-DJ3F-28JJ-283H-0000", "", ErrInvalidFormat},
+		{"PAAADJ3F28JJ283H", "", ErrInvalidFormat},
+		{"", "", ErrInvalidFormat},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.id)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("Parse(%q) = %q, %v; want %q, %v", tt.id, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestMatchesEntityType(t *testing.T) {
+	if err := MatchesEntityType("GAAA-4K7M-2Q9X-8B3N", "Grower"); err != nil {
+		t.Errorf("grower ID against Grower: %v", err)
+	}
+	if err := MatchesEntityType("GAAA-4K7M-2Q9X-8B3N", "Dispensary"); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("grower ID against Dispensary: error = %v, want %v", err, ErrTypeMismatch)
+	}
+	if err := MatchesEntityType("GAAA-4K7M-2Q9X-8B3N", "Transporter"); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("unknown state entity type: error = %v, want %v", err, ErrUnknownType)
+	}
+}
+
+func TestCheckTrade(t *testing.T) {
+	const (
+		grower     = "GAAA-4K7M-2Q9X-8B3N"
+		processor  = "PAAA-DJ3F-28JJ-283H"
+		dispensary = "DAAA-7H2L-5R8T-1C6W"
+	)
+	tests := []struct {
+		seller, buyer string
+		wantErr       error
+	}{
+		{grower, processor, nil},
+		{grower, dispensary, nil},
+		{processor, processor, nil},
+		{processor, dispensary, nil},
+		{grower, grower, ErrTradeNotAllowed},
+		{processor, grower, ErrTradeNotAllowed},
+		{dispensary, dispensary, ErrTradeNotAllowed},
+		{dispensary, processor, ErrTradeNotAllowed},
+		{"not-a-license", dispensary, ErrInvalidFormat},
+		{grower, "XAAA-7H2L-5R8T-1C6W", ErrUnknownType},
+	}
+	for _, tt := range tests {
+		if err := CheckTrade(tt.seller, tt.buyer); !errors.Is(err, tt.wantErr) {
+			t.Errorf("CheckTrade(%s, %s) = %v, want %v", tt.seller, tt.buyer, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCanSellAndPurchase(t *testing.T) {
+	for _, tt := range []struct {
+		t                    models.LicenseType
+		canSell, canPurchase bool
+	}{
+		{models.LicenseTypeGrower, true, false},
+		{models.LicenseTypeProcessor, true, true},
+		{models.LicenseTypeDispensary, false, true},
+	} {
+		if got := CanSell(tt.t); got != tt.canSell {
+			t.Errorf("CanSell(%s) = %v, want %v", tt.t, got, tt.canSell)
+		}
+		if got := CanPurchase(tt.t); got != tt.canPurchase {
+			t.Errorf("CanPurchase(%s) = %v, want %v", tt.t, got, tt.canPurchase)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package mail
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+)
+
+// FakeSender is a Sender that logs and keeps every message instead of sending it.
+type FakeSender struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+// NewFakeSender returns an empty FakeSender.
+func NewFakeSender() *FakeSender {
+	return &FakeSender{}
+}
+
+func (f *FakeSender) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipients
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, msg)
+	log.Printf("Fake mail to %s: %s", strings.Join(msg.To, ", "), msg.Subject)
+	return nil
+}
+
+// Sent returns the messages sent so far, oldest first.
+func (f *FakeSender) Sent() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message(nil), f.sent...)
+}
@@ -0,0 +1,23 @@
+// Package mail sends email. Sender is the extension point: SMTPSender talks to a
+// real mail server, and FakeSender keeps messages in memory for local runs.
+package mail
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrNoRecipients = errors.New("mail: message has no recipients")
+
+// Message is a plain-text email.
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
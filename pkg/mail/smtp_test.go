@@ -0,0 +1,58 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func TestFormatHeaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		msg         Message
+		wantSubject string
+	}{
+		{
+			name:        "plain subject",
+			msg:         Message{From: "noreply@wds.example", To: []string{"a@example.com"}, Subject: "License renewed"},
+			wantSubject: "License renewed",
+		},
+		{
+			name:        "non-ASCII subject is encoded",
+			msg:         Message{From: "noreply@wds.example", To: []string{"a@example.com"}, Subject: "Café Verde license expired"},
+			wantSubject: "=?UTF-8?q?Caf=C3=A9_Verde_license_expired?=",
+		},
+		{
+			name:        "line breaks can't add headers",
+			msg:         Message{From: "noreply@wds.example\r\nBcc: x@evil.example", To: []string{"a@example.com\nBcc: y@evil.example"}, Subject: "Acme\r\nBcc: z@evil.example"},
+			wantSubject: "Acme  Bcc: z@evil.example",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := format(tt.msg)
+			header, err := textproto.NewReader(bufio.NewReader(bytes.NewReader(out))).ReadMIMEHeader()
+			if err != nil {
+				t.Fatalf("reading headers: %v\n%s", err, out)
+			}
+			if got := header.Get("Subject"); got != tt.wantSubject {
+				t.Errorf("Subject = %q, want %q", got, tt.wantSubject)
+			}
+			if bcc := header.Values("Bcc"); len(bcc) > 0 {
+				t.Errorf("message gained Bcc headers %q:\n%s", bcc, out)
+			}
+			if len(header.Values("To")) != 1 || len(header.Values("From")) != 1 {
+				t.Errorf("From/To = %q / %q", header.Values("From"), header.Values("To"))
+			}
+		})
+	}
+}
+
+func TestFormatBody(t *testing.T) {
+	out := string(format(Message{From: "a@example.com", To: []string{"b@example.com"}, Subject: "s", Body: "line one\nline two"}))
+	if _, body, _ := strings.Cut(out, "\r\n\r\n"); body != "line one\r\nline two" {
+		t.Errorf("body = %q, want CRLF line endings", body)
+	}
+}
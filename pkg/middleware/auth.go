@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/services"
+)
+
+type contextKey string
+
+const userContextKey contextKey = "user"
+
+// Policy declares who may call a route. An empty Roles allows any authenticated user;
+// an empty Statuses allows only ACTIVE accounts.
+type Policy struct {
+	Roles    []models.UserRole
+	Statuses []models.AccountStatus
+}
+
+// Roles is shorthand for a Policy that admits ACTIVE users holding any of the given roles.
+func Roles(roles ...models.UserRole) Policy {
+	return Policy{Roles: roles}
+}
+
+func (p Policy) allows(user *models.User) bool {
+	if len(p.Roles) > 0 && !slices.Contains(p.Roles, user.Role) {
+		return false
+	}
+	statuses := p.Statuses
+	if len(statuses) == 0 {
+		statuses = []models.AccountStatus{models.StatusActive}
+	}
+	return slices.Contains(statuses, user.Status)
+}
+
+// Authenticator validates bearer tokens and enforces per-route role policies.
+type Authenticator struct {
+	users  repository.UserRepository
+	tokens *services.TokenIssuer
+}
+
+// NewAuthenticator returns an Authenticator that verifies tokens with tokens and loads users from users.
+func NewAuthenticator(users repository.UserRepository, tokens *services.TokenIssuer) *Authenticator {
+	return &Authenticator{users: users, tokens: tokens}
+}
+
+// Require wraps next so it only runs for callers with a valid bearer token whose
+// user satisfies policy. Missing or bad credentials get 401; a valid user who is
+// not allowed gets 403. The user is reloaded on every request so suspensions
+// take effect without waiting for the token to expire.
+func (a *Authenticator) Require(policy Policy, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, ok := bearerToken(r)
+		if !ok {
+			unauthorized(w, "Missing bearer token.")
+			return
+		}
+
+		claims, err := a.tokens.Verify(raw)
+		if err != nil {
+			unauthorized(w, "Invalid or expired token.")
+			return
+		}
+
+		user, err := a.users.Get(r.Context(), claims.UserID)
+		if errors.Is(err, repository.ErrNotFound) {
+			unauthorized(w, "Invalid or expired token.")
+			return
+		} else if err != nil {
+			log.Printf("Error loading user %s for authorization: %v", claims.UserID, err)
+			http.Error(w, "Could not authorize request.", http.StatusInternalServerError)
+			return
+		}
+
+		if !policy.allows(user) {
+			log.Printf("Forbidden: user %s (%s, %s) denied %s %s", user.ID, user.Role, user.Status, r.Method, r.URL.Path)
+			if user.Status == models.StatusPending {
+				http.Error(w, "Your account is awaiting admin approval.", http.StatusForbidden)
+				return
+			}
+			http.Error(w, "You do not have permission to perform this action.", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
+	})
+}
+
+// WithUser returns a copy of ctx carrying user.
+func WithUser(ctx context.Context, user *models.User) context.Context {
+	return context.WithValue(ctx, userContextKey, user)
+}
+
+// UserFromContext returns the authenticated user stored by Require.
+func UserFromContext(ctx context.Context) (*models.User, bool) {
+	user, ok := ctx.Value(userContextKey).(*models.User)
+	return user, ok
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="wds"`)
+	http.Error(w, message, http.StatusUnauthorized)
+}
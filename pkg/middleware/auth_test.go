@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/services"
+)
+
+func TestRequire(t *testing.T) {
+	ctx := context.Background()
+	users := repository.NewMemoryUserRepository()
+	tokens := services.NewTokenIssuer([]byte("test-secret"), time.Hour)
+	auth := NewAuthenticator(users, tokens)
+
+	tokenFor := map[string]string{}
+	for _, u := range []models.User{
+		{ID: "vendor", Email: "v@example.com", Role: models.RoleVendor, Status: models.StatusActive},
+		{ID: "buyer", Email: "b@example.com", Role: models.RoleBuyer, Status: models.StatusActive},
+		{ID: "pending", Email: "p@example.com", Role: models.RoleVendor, Status: models.StatusPending},
+		{ID: "suspended", Email: "s@example.com", Role: models.RoleVendor, Status: models.StatusSuspended},
+	} {
+		if err := users.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+		token, err := tokens.Issue(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokenFor[u.ID] = token
+	}
+	// A token for a user that was deleted after it was issued.
+	tokenFor["deleted"], _ = tokens.Issue(models.User{ID: "deleted", Role: models.RoleVendor})
+
+	var reached *models.User
+	handler := auth.Require(Roles(models.RoleVendor), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached, _ = UserFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"no header", "", http.StatusUnauthorized},
+		{"not a bearer token", "Basic dXNlcjpwYXNz", http.StatusUnauthorized},
+		{"bad token", "Bearer not.a.token", http.StatusUnauthorized},
+		{"unknown user", "Bearer " + tokenFor["deleted"], http.StatusUnauthorized},
+		{"wrong role", "Bearer " + tokenFor["buyer"], http.StatusForbidden},
+		{"pending account", "Bearer " + tokenFor["pending"], http.StatusForbidden},
+		{"suspended account", "Bearer " + tokenFor["suspended"], http.StatusForbidden},
+		{"allowed", "bearer " + tokenFor["vendor"], http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached = nil
+			req := httptest.NewRequest(http.MethodGet, "/api/products", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if (reached != nil) != (tt.want == http.StatusOK) {
+				t.Errorf("handler reached = %v, want %v", reached != nil, tt.want == http.StatusOK)
+			}
+			if tt.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without a WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestPolicyStatuses(t *testing.T) {
+	pending := &models.User{Role: models.RoleBuyer, Status: models.StatusPending}
+	if (Policy{}).allows(pending) {
+		t.Error("the default policy admitted a PENDING user")
+	}
+	if !(Policy{Statuses: []models.AccountStatus{models.StatusPending, models.StatusActive}}).allows(pending) {
+		t.Error("a policy listing PENDING refused a PENDING user")
+	}
+}
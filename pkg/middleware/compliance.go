@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/wesleywinston/wds/pkg/services"
+)
+
+// RequireCompliance wraps next so it only runs while the caller's business passes
+// gate's license and suspension checks. It must sit inside Authenticator.Require,
+// which puts the user in the context. Failures get a 403 from WriteComplianceError.
+func RequireCompliance(gate *services.ComplianceGate, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := UserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated.", http.StatusUnauthorized)
+			return
+		}
+
+		err := gate.CheckUser(r.Context(), user)
+		var cerr *services.ComplianceError
+		if errors.As(err, &cerr) {
+			log.Printf("Forbidden: user %s denied %s %s: %s", user.ID, r.Method, r.URL.Path, cerr.Reason)
+			WriteComplianceError(w, cerr)
+			return
+		} else if err != nil {
+			log.Printf("Error checking compliance for user %s: %v", user.ID, err)
+			http.Error(w, "Could not authorize request.", http.StatusInternalServerError)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WriteComplianceError writes err as a 403 with a JSON body naming the business
+// and the reason, i.e.
+//
+//	{"error": "compliance_check_failed", "reason": "LICENSE_EXPIRED", "entityType": "VENDOR", ...}
+func WriteComplianceError(w http.ResponseWriter, err *services.ComplianceError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+		*services.ComplianceError
+	}{"compliance_check_failed", err})
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/services"
+	"github.com/wesleywinston/wds/pkg/utils"
+)
+
+// RequestIDHeader carries the request ID in both directions: a caller (or load
+// balancer) may supply one, and every response echoes the ID we used.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestMetadata tags every request with an ID and stores where it came from in
+// the context, so audit events can be traced back to the request that caused them.
+func RequestMetadata(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = utils.NewID("req")
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		meta := models.RequestMetadata{
+			RequestID:    requestID,
+			Method:       r.Method,
+			Path:         r.URL.Path,
+			RemoteAddr:   r.RemoteAddr,
+			ForwardedFor: r.Header.Get("X-Forwarded-For"),
+			UserAgent:    r.UserAgent(),
+		}
+		next.ServeHTTP(w, r.WithContext(services.WithRequestMetadata(r.Context(), meta)))
+	})
+}
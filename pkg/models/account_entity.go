@@ -0,0 +1,125 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// AccountEntityType tags which concrete type an AccountEntity holds.
+type AccountEntityType string
+
+const (
+	AccountEntityVendor AccountEntityType = "VENDOR"
+	AccountEntityBuyer  AccountEntityType = "BUYER"
+)
+
+var ErrUnknownAccountEntity = errors.New("unknown account entity type")
+
+// AccountEntityRecord is an AccountEntity as a tagged union of its concrete
+// types. Neither encoding/json nor Firestore can decode into an interface, so
+// this is how User.AccountData is written to both:
+//
+//	{"type": "VENDOR", "vendor": {...}}
+//
+// Exactly one of Vendor and Buyer is set, matching Type.
+type AccountEntityRecord struct {
+	Type   AccountEntityType `json:"type"`
+	Vendor *Vendor           `json:"vendor,omitempty"`
+	Buyer  *Buyer            `json:"buyer,omitempty"`
+}
+
+// NewAccountEntityRecord tags entity with its type. A nil entity gives a nil record.
+func NewAccountEntityRecord(entity AccountEntity) (*AccountEntityRecord, error) {
+	switch e := entity.(type) {
+	case nil:
+		return nil, nil
+	case Vendor:
+		return &AccountEntityRecord{Type: AccountEntityVendor, Vendor: &e}, nil
+	case *Vendor:
+		if e == nil {
+			return nil, nil
+		}
+		return &AccountEntityRecord{Type: AccountEntityVendor, Vendor: e}, nil
+	case Buyer:
+		return &AccountEntityRecord{Type: AccountEntityBuyer, Buyer: &e}, nil
+	case *Buyer:
+		if e == nil {
+			return nil, nil
+		}
+		return &AccountEntityRecord{Type: AccountEntityBuyer, Buyer: e}, nil
+	}
+	return nil, fmt.Errorf("%w: %T", ErrUnknownAccountEntity, entity)
+}
+
+// Entity returns the concrete Vendor or Buyer the record holds, by value, which is
+// how the rest of the code stores AccountData. A nil record gives a nil entity.
+func (r *AccountEntityRecord) Entity() (AccountEntity, error) {
+	if r == nil {
+		return nil, nil
+	}
+	switch {
+	case r.Type == AccountEntityVendor && r.Vendor != nil:
+		return *r.Vendor, nil
+	case r.Type == AccountEntityBuyer && r.Buyer != nil:
+		return *r.Buyer, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownAccountEntity, r.Type)
+}
+
+// VendorAccount returns the user's AccountData as a Vendor, if it is one.
+func (u User) VendorAccount() (Vendor, bool) {
+	switch e := u.AccountData.(type) {
+	case Vendor:
+		return e, true
+	case *Vendor:
+		if e != nil {
+			return *e, true
+		}
+	}
+	return Vendor{}, false
+}
+
+// BuyerAccount returns the user's AccountData as a Buyer, if it is one.
+func (u User) BuyerAccount() (Buyer, bool) {
+	switch e := u.AccountData.(type) {
+	case Buyer:
+		return e, true
+	case *Buyer:
+		if e != nil {
+			return *e, true
+		}
+	}
+	return Buyer{}, false
+}
+
+// MarshalJSON writes AccountData as an AccountEntityRecord.
+func (u User) MarshalJSON() ([]byte, error) {
+	type plain User // no methods, so no recursion
+	record, err := NewAccountEntityRecord(u.AccountData)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		plain
+		AccountData *AccountEntityRecord `json:"accountData"`
+	}{plain(u), record})
+}
+
+// UnmarshalJSON reads AccountData back from an AccountEntityRecord.
+func (u *User) UnmarshalJSON(data []byte) error {
+	type plain User
+	aux := struct {
+		*plain
+		AccountData *AccountEntityRecord `json:"accountData"`
+	}{plain: (*plain)(u)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	entity, err := aux.AccountData.Entity()
+	if err != nil {
+		return err
+	}
+	u.AccountData = entity
+	return nil
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUserAccountDataJSON(t *testing.T) {
+	expires := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	vendor := Vendor{ID: "vendor_1", BusinessName: "Green Acres", OKStateLicenseID: "GAAA-4K7M-2Q9X-8B3N", LicenseType: LicenseTypeGrower, LicenseExpirationDate: expires, MenuEnabled: true}
+	buyer := Buyer{ID: "buyer_1", BusinessName: "Corner Dispensary", OKStateLicenseID: "DAAA-7H2L-5R8T-1C6W", LicenseType: LicenseTypeDispensary, LicenseExpirationDate: expires}
+
+	tests := []struct {
+		name     string
+		data     AccountEntity
+		wantTag  string
+		wantType AccountEntity
+	}{
+		{"vendor", vendor, `"type":"VENDOR"`, vendor},
+		{"vendor pointer", &vendor, `"type":"VENDOR"`, vendor},
+		{"buyer", buyer, `"type":"BUYER"`, buyer},
+		{"buyer pointer", &buyer, `"type":"BUYER"`, buyer},
+		{"no business", nil, `"accountData":null`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := User{ID: "user_1", Email: "a@example.com", Role: RoleVendor, AccountData: tt.data}
+			data, err := json.Marshal(user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), tt.wantTag) {
+				t.Errorf("JSON %s doesn't contain %s", data, tt.wantTag)
+			}
+
+			var got User
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if got.ID != user.ID || got.Email != user.Email {
+				t.Errorf("round trip lost the user's own fields: %+v", got)
+			}
+			switch want := tt.wantType.(type) {
+			case Vendor:
+				v, ok := got.VendorAccount()
+				if !ok || v.ID != want.ID || v.BusinessName != want.BusinessName || !v.LicenseExpirationDate.Equal(want.LicenseExpirationDate) || !v.MenuEnabled {
+					t.Errorf("VendorAccount() = %+v, %v; want %+v", v, ok, want)
+				}
+				if _, ok := got.BuyerAccount(); ok {
+					t.Error("BuyerAccount() of a vendor user reports a buyer")
+				}
+			case Buyer:
+				b, ok := got.BuyerAccount()
+				if !ok || b.ID != want.ID || b.LicenseType != want.LicenseType {
+					t.Errorf("BuyerAccount() = %+v, %v; want %+v", b, ok, want)
+				}
+				if _, ok := got.VendorAccount(); ok {
+					t.Error("VendorAccount() of a buyer user reports a vendor")
+				}
+			case nil:
+				if got.AccountData != nil {
+					t.Errorf("AccountData = %#v, want nil", got.AccountData)
+				}
+			}
+		})
+	}
+}
+
+func TestUserAccountDataUnknownType(t *testing.T) {
+	tests := []string{
+		`{"id":"user_1","accountData":{"type":"ADMIN"}}`,
+		`{"id":"user_1","accountData":{"type":"VENDOR","buyer":{"id":"buyer_1"}}}`,
+	}
+	for _, data := range tests {
+		var user User
+		if err := json.Unmarshal([]byte(data), &user); !errors.Is(err, ErrUnknownAccountEntity) {
+			t.Errorf("Unmarshal(%s) = %v, want ErrUnknownAccountEntity", data, err)
+		}
+	}
+}
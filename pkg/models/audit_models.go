@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEvent is one entry in the append-only audit log: who did what to which
+// record, what it looked like before and after, and the request that caused it.
+type AuditEvent struct {
+	ID         string           `json:"id"`
+	At         time.Time        `json:"at"`
+	ActorID    string           `json:"actorID"` // User ID, or "system" for scheduled jobs
+	ActorRole  UserRole         `json:"actorRole,omitempty"`
+	Action     AuditAction      `json:"action"`
+	EntityType AuditEntityType  `json:"entityType"`
+	EntityID   string           `json:"entityID"`
+	Before     json.RawMessage  `json:"before,omitempty"` // JSON snapshot; empty for creations
+	After      json.RawMessage  `json:"after,omitempty"`  // JSON snapshot; empty for deletions
+	Request    *RequestMetadata `json:"request,omitempty"`
+}
+
+// RequestMetadata identifies the HTTP request behind an audited action.
+type RequestMetadata struct {
+	RequestID    string `json:"requestID"`
+	Method       string `json:"method"`
+	Path         string `json:"path"`
+	RemoteAddr   string `json:"remoteAddr"`
+	ForwardedFor string `json:"forwardedFor,omitempty"`
+	UserAgent    string `json:"userAgent,omitempty"`
+}
+
+type AuditAction string
+type AuditEntityType string
@@ -6,26 +6,67 @@ import "time"
 // Catalog represents a collection of products, often filtered by a Buyer's needs
 // or a Vendor's offerings. This is more of a logical view container.
 type Catalog struct {
-	TotalProducts int       `json:"totalProducts"` // used for pagination and determine how to layout the UI
-	FilterApplied string    `json:"filterApplied"` // used for pagination and determine how to layout the UI
-	Products      []Product `json:"products"`
+	TotalProducts int            `json:"totalProducts"` // used for pagination and determine how to layout the UI
+	FilterApplied string         `json:"filterApplied"` // used for pagination and determine how to layout the UI
+	Products      []Product      `json:"products"`
+	NextCursor    string         `json:"nextCursor,omitempty"` // opaque; pass back to fetch the next page, empty on the last page
+	Facets        *CatalogFacets `json:"facets,omitempty"`     // counts across every match, not just this page
+}
+
+// CatalogFacets counts search matches per filterable value so the UI can show
+// "Flower (42)" style filter options.
+type CatalogFacets struct {
+	Categories     map[string]int `json:"categories"`
+	SubCategories  map[string]int `json:"subCategories"`
+	Medical        map[string]int `json:"medical"` // keyed "true" / "false"
+	InStock        map[string]int `json:"inStock"` // keyed "true" / "false"
+	ComplianceTags map[string]int `json:"complianceTags"`
+	StrainTypes    map[string]int `json:"strainTypes"`
+	TestStatuses   map[string]int `json:"testStatuses"`
+}
+
+// ComplianceAttributes are the regulated facts about a product's batch. Once a
+// COA is uploaded, the lab-reported fields (potency, lab ID, batch number and
+// test status) come from it and vendor edits to them are ignored.
+type ComplianceAttributes struct {
+	THCPercent  *float64   `json:"thcPercent,omitempty"` // total THC by weight; nil if not reported
+	CBDPercent  *float64   `json:"cbdPercent,omitempty"` // total CBD by weight; nil if not reported
+	StrainType  StrainType `json:"strainType,omitempty"`
+	BatchNumber string     `json:"batchNumber,omitempty"`
+	HarvestDate time.Time  `json:"harvestDate,omitzero"`
+	PackageDate time.Time  `json:"packageDate,omitzero"`
+	LabID       string     `json:"labID,omitempty"`      // the testing lab's license ID
+	TestStatus  TestStatus `json:"testStatus,omitempty"` // vendors may only set PENDING; PASSED and FAILED come from a COA
+}
+
+type StrainType string
+type TestStatus string
+
+// PricePoint is a price a product took effect at.
+type PricePoint struct {
+	Price Money     `json:"price"`
+	At    time.Time `json:"at"`
 }
 
 type Product struct { // (Represents a single SKU offered by a Vendor)
 	// Must include inventory and specific compliance details.
-	ID               string    `json:"id"`
-	VendorID         string    `json:"vendorID"` // Foreign key to the Vendor document.
-	Name             string    `json:"name"`
-	Description      string    `json:"description"`
-	Category         string    `json:"category"`
-	SubCategory      string    `json:"subCategory"`
-	IsMedical        bool	   `json:"isMedical"`
-	PricePerUnit     float64   `json:"pricePerUnit"`
-	AvailableUnits   int       `json:"availableUnits"`
-	MinOrderQuantity int       `json:"minOrderQuantity"`
-	MaxOrderQuantity int       `json:"maxOrderQuantity"`
-	CoaLink          string    `json:"coaLink"`
-	ComplianceTags   []string  `json:"complianceTags"` // ['THC: 25%', 'Sativa', 'Lab ID: 12345']
-	UpdatedAt        time.Time `json:"updatedAt"`      // timestamp as a string
+	ID               string               `json:"id"`
+	VendorID         string               `json:"vendorID"` // Foreign key to the Vendor document.
+	Name             string               `json:"name"`
+	Description      string               `json:"description"`
+	Category         string               `json:"category"`
+	SubCategory      string               `json:"subCategory"`
+	IsMedical        bool                 `json:"isMedical"`
+	PricePerUnit     Money                `json:"pricePerUnit"`
+	AvailableUnits   int                  `json:"availableUnits"`
+	MinOrderQuantity int                  `json:"minOrderQuantity"`
+	MaxOrderQuantity int                  `json:"maxOrderQuantity"`
+	CoaLink          string               `json:"coaLink"`
+	COA              *COADocument         `json:"coa,omitempty"`      // set by uploading a certificate of analysis; holds the verified lab results
+	StrainID         string               `json:"strainID,omitempty"` // the canonical Strain this product is grown from
+	Compliance       ComplianceAttributes `json:"compliance"`
+	ComplianceTags   []string             `json:"complianceTags"` // free-form extras, i.e. ['Organic', 'Indoor']; attributes like 'THC: 25%' belong in Compliance
+	PriceHistory     []PricePoint         `json:"-"`              // every PricePerUnit the product has had, oldest first; served by the product detail endpoint
+	UpdatedAt        time.Time            `json:"updatedAt"`      // timestamp as a string
 	// Stock            int      `json:"stock"`
 }
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// LabResults are the test results parsed from a product's certificate of
+// analysis. Buyers see these instead of vendor-typed ComplianceTags.
+type LabResults struct {
+	LabID        string              `json:"labID"` // the testing lab's OMMA license or accreditation ID
+	LabName      string              `json:"labName,omitempty"`
+	BatchNumber  string              `json:"batchNumber"`
+	SampleID     string              `json:"sampleID,omitempty"`
+	TestedOn     string              `json:"testedOn,omitempty"` // as printed on the COA
+	TotalTHC     float64             `json:"totalTHC"`           // percent by weight
+	TotalCBD     float64             `json:"totalCBD"`           // percent by weight
+	Cannabinoids []AnalyteResult     `json:"cannabinoids"`
+	Terpenes     []AnalyteResult     `json:"terpenes"`
+	Contaminants []ContaminantResult `json:"contaminants"`
+	Passed       bool                `json:"passed"` // every contaminant screen passed
+}
+
+// AnalyteResult is one measured compound, i.e. {"name": "THCa", "value": 24.1, "unit": "%"}.
+// Values reported as not detected (ND) or below the limit of quantitation (<LOQ) are 0.
+type AnalyteResult struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+// ContaminantResult is one safety screen, i.e. lead, E. coli or a pesticide.
+type ContaminantResult struct {
+	Name     string `json:"name"`
+	Category string `json:"category"` // "Heavy Metals", "Microbials", "Pesticides", ...
+	Result   string `json:"result"`   // as printed: "ND", "<LOQ", "0.12"
+	Unit     string `json:"unit,omitempty"`
+	Passed   bool   `json:"passed"`
+}
+
+// COADocument is an uploaded certificate of analysis and what we parsed from it.
+type COADocument struct {
+	BlobKey     string     `json:"-"` // where the original file lives in the blob store
+	FileName    string     `json:"fileName"`
+	ContentType string     `json:"contentType"`
+	Size        int64      `json:"size"`
+	SHA256      string     `json:"sha256"`
+	UploadedBy  string     `json:"uploadedBy"` // User ID
+	UploadedAt  time.Time  `json:"uploadedAt"`
+	Results     LabResults `json:"results"`
+}
+
+// ApplyLabResults replaces the lab-reported compliance attributes with what the
+// COA says, so buyers filter on measured potency rather than the vendor's claim.
+func (c *ComplianceAttributes) ApplyLabResults(r LabResults) {
+	thc, cbd := r.TotalTHC, r.TotalCBD
+	c.THCPercent, c.CBDPercent = &thc, &cbd
+	c.LabID = r.LabID
+	c.BatchNumber = r.BatchNumber
+	c.TestStatus = TestFailed
+	if r.Passed {
+		c.TestStatus = TestPassed
+	}
+}
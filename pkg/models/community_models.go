@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+type PostKind string
+type Reaction string
+type ContentStatus string
+type ReportStatus string
+
+// Post is an announcement on the community feed. Posts belong to a business: any
+// member can delete them, and they disappear from the feed while the business is
+// out of compliance or banned.
+type Post struct {
+	ID         string                `json:"id"`
+	Kind       PostKind              `json:"kind"`
+	Body       string                `json:"body"`
+	ProductIDs []string              `json:"productIDs"`       // products the post is about, i.e. a new drop
+	EventAt    time.Time             `json:"eventAt,omitzero"` // EVENT posts only
+	AuthorID   string                `json:"authorID"`         // User ID
+	EntityID   string                `json:"entityID"`         // Vendor or Buyer ID
+	EntityName string                `json:"entityName"`       // BusinessName when posted
+	Status     ContentStatus         `json:"status"`
+	Flag       string                `json:"flag,omitempty"` // moderator's warning shown with the post, i.e. "Unverified potency claims"
+	Reactions  map[Reaction][]string `json:"-"`              // user IDs by reaction
+	Comments   int                   `json:"comments"`       // visible comments
+	CreatedAt  time.Time             `json:"createdAt"`
+	UpdatedAt  time.Time             `json:"updatedAt"`
+}
+
+// Comment is a reply to a post.
+type Comment struct {
+	ID         string        `json:"id"`
+	PostID     string        `json:"postID"`
+	Body       string        `json:"body"`
+	AuthorID   string        `json:"authorID"`
+	EntityID   string        `json:"entityID"`
+	EntityName string        `json:"entityName"`
+	Status     ContentStatus `json:"status"`
+	Flag       string        `json:"flag,omitempty"`
+	CreatedAt  time.Time     `json:"createdAt"`
+}
+
+// Report is a user's complaint about a post or comment, waiting in the admins'
+// moderation queue until it is resolved.
+type Report struct {
+	ID         string          `json:"id"`
+	TargetType AuditEntityType `json:"targetType"` // AuditEntityPost or AuditEntityComment
+	TargetID   string          `json:"targetID"`
+	PostID     string          `json:"postID"` // the post itself, or the one a comment is on
+	Reason     string          `json:"reason"`
+	ReporterID string          `json:"reporterID"`
+	Status     ReportStatus    `json:"status"`
+	Resolution string          `json:"resolution,omitempty"` // the moderator's note
+	ResolvedBy string          `json:"resolvedBy,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	ResolvedAt time.Time       `json:"resolvedAt,omitzero"`
+}
+
+// CommunityBan stops a business posting, commenting and reacting, and hides what
+// it has already posted, until Until (or indefinitely if Until is zero).
+type CommunityBan struct {
+	Reason   string    `json:"reason"`
+	BannedBy string    `json:"bannedBy"` // User ID
+	BannedAt time.Time `json:"bannedAt"`
+	Until    time.Time `json:"until,omitzero"`
+}
+
+// Active reports whether the ban is in effect at t.
+func (b *CommunityBan) Active(t time.Time) bool {
+	return b != nil && (b.Until.IsZero() || t.Before(b.Until))
+}
@@ -13,6 +13,7 @@ const (
 	StatusActive    AccountStatus = "ACTIVE"
 	StatusInactive  AccountStatus = "INACTIVE"
 	StatusSuspended AccountStatus = "SUSPENDED"
+	StatusRejected  AccountStatus = "REJECTED"
 )
 
 // --- Entity Compliance Statuses ---
@@ -22,6 +23,113 @@ const (
 	CompliancePending  AccountComplianceStatus = "PENDING"
 )
 
+// --- Business Roles ---
+// A user's role inside their Vendor/Buyer, separate from their platform UserRole.
+const (
+	BusinessRoleOwner   BusinessRole = "OWNER"
+	BusinessRoleManager BusinessRole = "MANAGER"
+	BusinessRoleStaff   BusinessRole = "STAFF"
+)
+
+// Invitation Statuses
+const (
+	InvitationPending  InvitationStatus = "PENDING"
+	InvitationAccepted InvitationStatus = "ACCEPTED"
+	InvitationRevoked  InvitationStatus = "REVOKED"
+)
+
+// Strain Types
+const (
+	StrainIndica StrainType = "INDICA"
+	StrainSativa StrainType = "SATIVA"
+	StrainHybrid StrainType = "HYBRID"
+)
+
+// Lab Test Statuses
+const (
+	TestPending TestStatus = "PENDING"
+	TestPassed  TestStatus = "PASSED"
+	TestFailed  TestStatus = "FAILED"
+)
+
+// Community Post Kinds
+const (
+	PostAnnouncement PostKind = "ANNOUNCEMENT"
+	PostNewDrop      PostKind = "NEW_DROP"
+	PostRestock      PostKind = "RESTOCK"
+	PostEvent        PostKind = "EVENT"
+)
+
+// Community Reactions
+const (
+	ReactionLike   Reaction = "LIKE"
+	ReactionFire   Reaction = "FIRE"
+	ReactionThanks Reaction = "THANKS"
+)
+
+// Community Content Statuses
+const (
+	ContentVisible ContentStatus = "VISIBLE"
+	ContentHidden  ContentStatus = "HIDDEN"  // hidden by a moderator
+	ContentDeleted ContentStatus = "DELETED" // deleted by its author
+)
+
+// Community Report Statuses
+const (
+	ReportOpen      ReportStatus = "OPEN"
+	ReportActioned  ReportStatus = "ACTIONED"
+	ReportDismissed ReportStatus = "DISMISSED"
+)
+
+// Notification Kinds
+const (
+	NotificationLicenseExpiring     NotificationKind = "LICENSE_EXPIRING"       // a reminder at one of the configured lead times
+	NotificationLicenseLapsed       NotificationKind = "LICENSE_LAPSED"         // the expiration date has passed
+	NotificationLicenseStatusChange NotificationKind = "LICENSE_STATUS_CHANGED" // the state's records changed our compliance status
+)
+
+// --- Audit Actions ---
+const (
+	AuditUserCreated           AuditAction = "user.created"
+	AuditUserStatusChanged     AuditAction = "user.status_changed"
+	AuditLicenseChecked        AuditAction = "license.checked"
+	AuditBusinessRegistered    AuditAction = "business.registered"
+	AuditBusinessStatusChanged AuditAction = "business.status_changed"
+	AuditProductCreated        AuditAction = "product.created"
+	AuditProductUpdated        AuditAction = "product.updated"
+	AuditProductDeleted        AuditAction = "product.deleted"
+	AuditCOAUploaded           AuditAction = "product.coa_uploaded"
+	AuditMenuUpdated           AuditAction = "menu.updated"
+	AuditOrderPlaced           AuditAction = "order.placed"
+	AuditOrderStatusChanged    AuditAction = "order.status_changed"
+	AuditStrainsImported       AuditAction = "strain.imported"
+	AuditContentModerated      AuditAction = "community.moderated"
+	AuditReportResolved        AuditAction = "community.report_resolved"
+	AuditCommunityBanned       AuditAction = "community.banned"
+	AuditCommunityUnbanned     AuditAction = "community.unbanned"
+)
+
+// Audit Entity Types
+const (
+	AuditEntityUser    AuditEntityType = "user"
+	AuditEntityVendor  AuditEntityType = "vendor"
+	AuditEntityBuyer   AuditEntityType = "buyer"
+	AuditEntityLicense AuditEntityType = "license"
+	AuditEntityProduct AuditEntityType = "product"
+	AuditEntityOrder   AuditEntityType = "order"
+	AuditEntityStrain  AuditEntityType = "strain"
+	AuditEntityPost    AuditEntityType = "post"
+	AuditEntityComment AuditEntityType = "comment"
+)
+
+// --- License Types ---
+// Derived from the first letter of an OMMA license ID (G - grow, P - processor, D - dispensary).
+const (
+	LicenseTypeGrower     LicenseType = "GROWER"
+	LicenseTypeProcessor  LicenseType = "PROCESSOR"
+	LicenseTypeDispensary LicenseType = "DISPENSARY"
+)
+
 // Order Status
 const (
 	OrderStatusPending    OrderStatus = "PENDING"
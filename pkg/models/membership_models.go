@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Membership links a User to the Vendor or Buyer they work for, with their role
+// inside that business. The business document holds the list; each member's
+// User.AssociatedEntityID points back at the business.
+type Membership struct {
+	UserID  string       `json:"userID"`
+	Email   string       `json:"email"`
+	Role    BusinessRole `json:"role"`
+	AddedAt time.Time    `json:"addedAt"`
+}
+
+// Invitation asks someone, by email, to join a business. The raw token is only
+// ever handed to the inviter (to forward) and the invitee; we store its hash.
+type Invitation struct {
+	ID         string           `json:"id"`
+	EntityID   string           `json:"entityID"`   // Vendor or Buyer the invitee will join
+	EntityRole UserRole         `json:"entityRole"` // VENDOR or BUYER; becomes the new user's Role
+	Email      string           `json:"email"`
+	Role       BusinessRole     `json:"role"`
+	InvitedBy  string           `json:"invitedBy"` // User ID
+	Status     InvitationStatus `json:"status"`
+	TokenHash  string           `json:"-"`
+	CreatedAt  time.Time        `json:"createdAt"`
+	ExpiresAt  time.Time        `json:"expiresAt"`
+	AcceptedBy string           `json:"acceptedBy,omitempty"` // User ID
+}
+
+type BusinessRole string
+type InvitationStatus string
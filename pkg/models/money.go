@@ -0,0 +1,221 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+const USD Currency = "USD"
+
+var (
+	ErrInvalidMoney        = errors.New("invalid money amount")
+	ErrCurrencyMismatch    = errors.New("currency mismatch")
+	ErrUnsupportedCurrency = errors.New("only USD amounts are supported")
+)
+
+// Money is an exact amount stored as a whole number of cents. Never use float64
+// for prices or totals; it drifts by fractions of a cent on invoices.
+//
+// The fields are exported so Firestore stores Money as a {Cents, Currency} map.
+// JSON uses {"amount": "12.34", "currency": "USD"}, and also accepts a bare
+// number or string such as 12.34 or "12.34" (taken as USD) on input.
+//
+// The zero value is $0.00. An empty Currency is treated as USD. USD is the only
+// currency we trade in, and decoding any other fails with ErrUnsupportedCurrency,
+// so amounts from clients can always be added and compared.
+type Money struct {
+	Cents    int64
+	Currency Currency
+}
+
+// NewMoney returns an amount of cents in currency c.
+func NewMoney(cents int64, c Currency) Money {
+	return Money{Cents: cents, Currency: c}
+}
+
+// USDCents returns an amount of US cents.
+func USDCents(cents int64) Money {
+	return Money{Cents: cents, Currency: USD}
+}
+
+// ParseMoney parses a decimal string with at most two fractional digits, i.e. "12.34", "-0.5" or "7".
+func ParseMoney(s string, c Currency) (Money, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" || len(frac) > 2 || strings.ContainsAny(whole+frac, "+-eE") {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	f, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	if w > (math.MaxInt64-f)/100 {
+		return Money{}, fmt.Errorf("%w: %q is too large", ErrInvalidMoney, s)
+	}
+
+	cents := w*100 + f
+	if neg {
+		cents = -cents
+	}
+	return Money{Cents: cents, Currency: c}, nil
+}
+
+func (m Money) currency() Currency {
+	if m.Currency == "" {
+		return USD
+	}
+	return m.Currency
+}
+
+// mustMatch panics if m and o are in different currencies; mixing currencies is a
+// programming error, not a runtime condition.
+func (m Money) mustMatch(o Money) {
+	if m.currency() != o.currency() {
+		panic(fmt.Sprintf("%v: %s and %s", ErrCurrencyMismatch, m.currency(), o.currency()))
+	}
+}
+
+// Add returns m + o.
+func (m Money) Add(o Money) Money {
+	m.mustMatch(o)
+	return Money{Cents: m.Cents + o.Cents, Currency: m.currency()}
+}
+
+// Sub returns m - o.
+func (m Money) Sub(o Money) Money {
+	m.mustMatch(o)
+	return Money{Cents: m.Cents - o.Cents, Currency: m.currency()}
+}
+
+// Mul returns m multiplied by a whole quantity, i.e. a unit price times units ordered.
+func (m Money) Mul(quantity int64) Money {
+	return Money{Cents: m.Cents * quantity, Currency: m.currency()}
+}
+
+// MulRate returns m multiplied by a percentage rate such as 0.07, rounded to the
+// nearest cent with halves rounded away from zero. Rates are taken to six decimal
+// places (0.04125 is exact) and the product is computed in integers, so the
+// result never depends on float rounding.
+func (m Money) MulRate(rate float64) Money {
+	const scale = 1_000_000
+	ppm := int64(math.Round(rate * scale))
+
+	product := m.Cents * ppm
+	cents := product / scale
+	if rem := product % scale; rem*2 >= scale {
+		cents++
+	} else if rem*2 <= -scale {
+		cents--
+	}
+	return Money{Cents: cents, Currency: m.currency()}
+}
+
+// Cmp returns -1, 0 or +1 as m is less than, equal to or greater than o.
+func (m Money) Cmp(o Money) int {
+	m.mustMatch(o)
+	switch {
+	case m.Cents < o.Cents:
+		return -1
+	case m.Cents > o.Cents:
+		return 1
+	}
+	return 0
+}
+
+// Equal reports whether m and o are the same amount in the same currency. Unlike
+// Cmp it doesn't panic on a currency mismatch, so it suits checking a stored price
+// against one a client sent.
+func (m Money) Equal(o Money) bool {
+	return m.Cents == o.Cents && m.currency() == o.currency()
+}
+
+// IsZero reports whether m is exactly zero.
+func (m Money) IsZero() bool { return m.Cents == 0 }
+
+// IsNegative reports whether m is below zero.
+func (m Money) IsNegative() bool { return m.Cents < 0 }
+
+// Amount formats m as a plain decimal, i.e. "12.34" or "-0.05".
+func (m Money) Amount() string {
+	sign, cents := "", m.Cents
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// String formats m with its currency, i.e. "12.34 USD".
+func (m Money) String() string {
+	return m.Amount() + " " + string(m.currency())
+}
+
+type moneyJSON struct {
+	Amount   string   `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Amount(), Currency: m.currency()})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return nil
+	case bytes.HasPrefix(data, []byte("{")):
+		var v moneyJSON
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		if v.Currency == "" {
+			v.Currency = USD
+		}
+		if v.Currency != USD {
+			return fmt.Errorf("%w: %q", ErrUnsupportedCurrency, v.Currency)
+		}
+		parsed, err := ParseMoney(v.Amount, v.Currency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case bytes.HasPrefix(data, []byte(`"`)):
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		parsed, err := ParseMoney(s, USD)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	default:
+		// A bare JSON number; parse its literal text so 10.33 never passes through float64.
+		parsed, err := ParseMoney(string(data), USD)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "12.34", want: 1234},
+		{in: "7", want: 700},
+		{in: "0.5", want: 50},
+		{in: ".05", want: 5},
+		{in: "-0.5", want: -50},
+		{in: " 10.33 ", want: 1033},
+		{in: "", wantErr: true},
+		{in: ".", wantErr: true},
+		{in: "1.234", wantErr: true},
+		{in: "1e3", wantErr: true},
+		{in: "+1", wantErr: true},
+		{in: "--1", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "92233720368547758.08", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in, USD)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidMoney) {
+				t.Errorf("ParseMoney(%q) error = %v, want %v", tt.in, err, ErrInvalidMoney)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(USDCents(tt.want)) {
+			t.Errorf("ParseMoney(%q) = %v, %v; want %v", tt.in, got, err, USDCents(tt.want))
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Money
+		want int64
+	}{
+		{"add", USDCents(1050).Add(USDCents(275)), 1325},
+		{"add treats empty currency as USD", Money{Cents: 100}.Add(USDCents(1)), 101},
+		{"sub below zero", USDCents(100).Sub(USDCents(250)), -150},
+		{"mul", USDCents(1033).Mul(3), 3099},
+		{"excise 7%", USDCents(2000).MulRate(0.07), 140},
+		{"half cent rounds up", USDCents(50).MulRate(0.01), 1},
+		{"just under half a cent rounds down", USDCents(49).MulRate(0.01), 0},
+		{"negative half rounds away from zero", USDCents(-50).MulRate(0.01), -1},
+		{"six-place rate is exact", USDCents(10000).MulRate(0.04125), 413},
+	}
+	for _, tt := range tests {
+		if !tt.got.Equal(USDCents(tt.want)) {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, USDCents(tt.want))
+		}
+	}
+}
+
+func TestMoneyCmp(t *testing.T) {
+	tests := []struct {
+		a, b Money
+		want int
+	}{
+		{USDCents(1), USDCents(2), -1},
+		{USDCents(2), USDCents(2), 0},
+		{USDCents(3), Money{Cents: 2}, 1},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Cmp(tt.b); got != tt.want {
+			t.Errorf("%v.Cmp(%v) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyEqual(t *testing.T) {
+	tests := []struct {
+		a, b Money
+		want bool
+	}{
+		{USDCents(1234), USDCents(1234), true},
+		{USDCents(1234), Money{Cents: 1234}, true}, // an empty currency is USD
+		{USDCents(1234), USDCents(1235), false},
+		{USDCents(1234), NewMoney(1234, "CAD"), false},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Equal(tt.b); got != tt.want {
+			t.Errorf("%v.Equal(%v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyCurrencyMismatchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("adding USD and EUR did not panic")
+		}
+	}()
+	USDCents(1).Add(NewMoney(1, "EUR"))
+}
+
+func TestMoneyAmount(t *testing.T) {
+	tests := []struct {
+		cents int64
+		want  string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{-5, "-0.05"},
+		{123456, "1234.56"},
+	}
+	for _, tt := range tests {
+		if got := USDCents(tt.cents).Amount(); got != tt.want {
+			t.Errorf("USDCents(%d).Amount() = %q, want %q", tt.cents, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr error // nil for success
+	}{
+		{in: `{"amount": "12.34", "currency": "USD"}`, want: 1234},
+		{in: `{"amount": "12.34"}`, want: 1234},
+		{in: `"10.33"`, want: 1033},
+		{in: `10.33`, want: 1033},
+		{in: `{"amount": "12.34", "currency": "EUR"}`, wantErr: ErrUnsupportedCurrency},
+		{in: `{"amount": "12.345", "currency": "USD"}`, wantErr: ErrInvalidMoney},
+		{in: `"ten"`, wantErr: ErrInvalidMoney},
+		{in: `1e2`, wantErr: ErrInvalidMoney},
+	}
+	for _, tt := range tests {
+		var got Money
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("unmarshal %s: error = %v, want %v", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(USDCents(tt.want)) {
+			t.Errorf("unmarshal %s = %v, %v; want %v", tt.in, got, err, USDCents(tt.want))
+		}
+	}
+
+	out, err := json.Marshal(Money{Cents: 1050})
+	if err != nil || string(out) != `{"amount":"10.50","currency":"USD"}` {
+		t.Errorf("marshal $10.50 = %s, %v", out, err)
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type NotificationKind string
+
+// Notification is one message about a business's license, kept as the in-app inbox
+// for every recipient and delivered to them through any other channels configured.
+type Notification struct {
+	ID         string            `json:"id"`
+	Kind       NotificationKind  `json:"kind"`
+	EntityID   string            `json:"entityID"` // Vendor or Buyer ID
+	EntityType AccountEntityType `json:"entityType"`
+	EntityName string            `json:"entityName"`
+	Subject    string            `json:"subject"`
+	Body       string            `json:"body"`
+	Key        string            `json:"-"` // identifies the reminder so it's only sent once, i.e. "expiring/30/2027-04-18"
+	Recipients []string          `json:"-"` // User IDs
+	ReadBy     []string          `json:"-"` // User IDs
+	CreatedAt  time.Time         `json:"createdAt"`
+}
@@ -2,9 +2,10 @@ package models
 
 // AuthRequest is a structure to decode incoming JSON payloads for signup/login
 type AuthRequest struct {
-	Email string `json:"email"`
-	Password string `json:"password"`
-	Role string `json:"role,omitempty"` 
+	Email    string   `json:"email"`
+	Password string   `json:"password"`
+	Role     string   `json:"role,omitempty"`
+	FullName []string `json:"fullName,omitempty"` // signup only; firstName is always 0, lastName is always 1
 }
 
 // AuthResponse is a standard structure for returning a success message or token
@@ -12,4 +13,4 @@ type AuthResponse struct {
 	Message string `json:"message"`
 	Token   string `json:"token,omitempty"`
 	UserID  string `json:"userId,omitempty"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Strain is a canonical cultivar in the strain reference database. Products link
+// to one by StrainID so "GG4", "Gorilla Glue #4" and "Original Glue" are all
+// recognised as the same plant.
+type Strain struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Key       string     `json:"-"` // normalized Name, used to match imports and product names
+	Aliases   []string   `json:"aliases"`
+	Type      StrainType `json:"type,omitempty"`
+	Lineage   []string   `json:"lineage"`  // parent strain names, i.e. ["Chem's Sister", "Sour Dubb", "Chocolate Diesel"]
+	Terpenes  []string   `json:"terpenes"` // typical dominant terpenes, most prominent first
+	Sources   []string   `json:"sources"`  // datasets this record was imported from
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
@@ -22,27 +22,29 @@ type Vendor struct {
 	ID                    string                  `json:"id"`
 	BusinessName          string                  `json:"businessName"`
 	OKStateLicenseID      string                  `json:"okStateLicenseID"`
+	LicenseType           LicenseType             `json:"licenseType"`
 	LicenseExpirationDate time.Time               `json:"licenseExpirationDate"`
 	Status                AccountStatus           `json:"status"`
 	ComplianceStatus      AccountComplianceStatus `json:"complianceStatus"`
 	ContactInfo           ContactInfo             `json:"contactInfo"`
 	MenuEnabled           bool                    `json:"menuEnabled"` // Flag to show/hide the Vendor's products on the marketplace.
 	CreatedAt             time.Time               `json:"createdAt"`
-	VendorCatalog		  Catalog				  `json:"catalog"`
+	VendorCatalog         Catalog                 `json:"catalog"`
+	Members               []Membership            `json:"members"` // the user accounts for this business, i.e Easy Street has Wes, Brighton, Chad, etc.
+	CommunityBan          *CommunityBan           `json:"communityBan,omitempty"`
 	// ID, BusinessName, OKStateLicenseID, LicenseExpirationDate, ComplianceStatus, ContactInfo, MenuEnabled, CreatedAt
-	// add a Users[] object that represents the accounts for each business, i.e Easy Street has Wes, Brighton, Chad, etc.
 }
 
 //
 // {
-	// {
-		// "Blue Dream",
-		// "item_32489348": {
+// {
+// "Blue Dream",
+// "item_32489348": {
 
-		// }
-	// }
+// }
+// }
 
-	// }
+// }
 // }
 //
 
@@ -50,12 +52,14 @@ type Buyer struct {
 	ID                    string                  `json:"id"`
 	BusinessName          string                  `json:"businessName"`
 	OKStateLicenseID      string                  `json:"okStateLicenseID"`
+	LicenseType           LicenseType             `json:"licenseType"`
 	LicenseExpirationDate time.Time               `json:"licenseExpirationDate"`
 	Status                AccountStatus           `json:"status"`
 	ComplianceStatus      AccountComplianceStatus `json:"complianceStatus"`
 	ContactInfo           ContactInfo             `json:"contactInfo"`
 	CreatedAt             time.Time               `json:"createdAt"`
-	// Users                 []User      `json:"users"`
+	Members               []Membership            `json:"members"` // the user accounts for this business
+	CommunityBan          *CommunityBan           `json:"communityBan,omitempty"`
 	// MenuEnabled           bool        `json:"menuEnabled"` // Flag to show/hide the Vendor's products on the marketplace.
 }
 
@@ -99,55 +103,96 @@ type Name struct {
 }
 
 type OrderItem struct {
-	ProductID string  `json:"productID"` // Foreign key to the Product document.
-	Quantity  int     `json:"quantity"`
-	Price     float64 `json:"price"` // Unit price at time of order (to account for price changes, promos. and deals)
+	ProductID string `json:"productID"` // Foreign key to the Product document.
+	Quantity  int    `json:"quantity"`
+	Price     Money  `json:"price"`     // Unit price at time of order (to account for price changes, promos. and deals)
+	LineTotal Money  `json:"lineTotal"` // Price * Quantity
 }
 
 type Timeline struct {
-	PlacedAt    string `json:"placedAt"`
-	AcceptedAt  string `json:"acceptedAt"`
-	ShippedAt   string `json:"shippedAt"`
-	DeliveredAt string `json:"deliveredAt"`
-	CancelledAt string `json:"cancelledAt"`
-	CompletedAt string `json:"completedAt"`
+	PlacedAt     string `json:"placedAt"`
+	AcceptedAt   string `json:"acceptedAt"`
+	ProcessingAt string `json:"processingAt"`
+	ShippedAt    string `json:"shippedAt"`
+	DeliveredAt  string `json:"deliveredAt"`
+	CancelledAt  string `json:"cancelledAt"`
+	CompletedAt  string `json:"completedAt"`
 }
 
 // User represents a user entity in our system.
 // Fields are exported (capitalized) so they can be accessed
 // and manipulated by other packages like main.
 type User struct {
-	ID           string   `json:"id"`           // Unique Firestore/Database ID.
-	FullName     []string `json:"fullName"`     // Full name of the user.
-	Email        string   `json:"email"`        // Email address.
-	PasswordHash string   `json:"passwordHash"` // Hashed password.
+	ID           string   `json:"id"`       // Unique Firestore/Database ID.
+	FullName     []string `json:"fullName"` // Full name of the user.
+	Email        string   `json:"email"`    // Email address.
+	PasswordHash string   `json:"-"`        // Hashed password. Never serialized to API responses.
 	// FirstName          string `json:"firstName"`          // First name of the user.
 	// LastName           string `json:"lastName"`           // Last name of the user.
-	Role               UserRole      `json:"role"`               // Defines permissions and UI views. // coorelats with type Vendor and type Buyer
-	Status             AccountStatus `json:"status"`             // Account status, linked to license validity.
-	AccountData        AccountEntity `json:"accountData"`        // 'BUYER', 'VENDOR', 'ADMIN'
-	AssociatedEntityID string        `json:"associatedEntityID"` // Foreign key reference to the Vendor or Buyer document this user belongs to.
+	Role               UserRole              `json:"role"`               // Defines permissions and UI views. // coorelats with type Vendor and type Buyer
+	Status             AccountStatus         `json:"status"`             // Account status, linked to license validity.
+	AccountData        AccountEntity         `json:"accountData"`        // the Vendor or Buyer; see repository.LoadAccountData
+	AssociatedEntityID string                `json:"associatedEntityID"` // Foreign key reference to the Vendor or Buyer document this user belongs to.
+	CreatedAt          time.Time             `json:"createdAt"`
+	StatusReason       string                `json:"statusReason,omitempty"`  // Why the account was last approved, rejected or suspended.
+	StatusHistory      []AccountStatusChange `json:"statusHistory,omitempty"` // Every admin review decision, oldest first.
+}
+
+// AccountStatusChange records one admin review decision on a user account.
+type AccountStatusChange struct {
+	From    AccountStatus `json:"from"`
+	To      AccountStatus `json:"to"`
+	ActorID string        `json:"actorID"` // the admin's User ID
+	Reason  string        `json:"reason,omitempty"`
+	At      time.Time     `json:"at"`
 }
 
 type Order struct {
-	ID                  string      `json:"id"`
-	BuyerID             string      `json:"buyerID"`  // Foreign key to the User document.
-	VendorID            string      `json:"vendorID"` // Foreign key to the Vendor document.
-	Status              string      `json:"status"`   // originates from type OrderStatus (PENDING, ACCEPTED, PROCESSING, SHIPPED, DELIVERED, CANCELLED, COMPLETED)
-	OrderStatusTimeline Timeline    `json:"orderStatusTimeline"`
-	Items               []OrderItem `json:"items"`
-	SubTotal            float64     `json:"subTotal"`      // sum of all line items
-	ExciseTax           float64     `json:"exciseTax"`     // sum of all line items * excise tax rate
-	SalesTax            float64     `json:"salesTax"`      // sum of all line items * sales tax rate
-	TotalPrice          float64     `json:"totalPrice"`    // sum of all line items + excise tax + sales tax
-	PaymentStatus       string      `json:"paymentStatus"` // 'PENDING', 'PAID', 'REFUNDED'
-	PaymentMethod       string      `json:"paymentMethod"` // 'CASH', 'CREDIT_CARD', 'DEBIT_CARD', 'CHECK', 'OTHER'
-	ShippingAddress     string      `json:"shippingAddress"`
-	ShippingCost        float64     `json:"shippingCost"`
+	ID                  string              `json:"id"`
+	BuyerID             string              `json:"buyerID"`  // Foreign key to the Buyer document.
+	PlacedBy            string              `json:"placedBy"` // Foreign key to the User document who placed the order.
+	VendorID            string              `json:"vendorID"` // Foreign key to the Vendor document.
+	Status              OrderStatus         `json:"status"`   // PENDING, ACCEPTED, PROCESSING, SHIPPED, DELIVERED, CANCELLED, COMPLETED; only changed through the order state machine
+	History             []OrderStatusChange `json:"history"`  // every status transition, oldest first, for audit
+	OrderStatusTimeline Timeline            `json:"orderStatusTimeline"`
+	Items               []OrderItem         `json:"items"`
+	SubTotal            Money               `json:"subTotal"`      // sum of all line items
+	ExciseTax           Money               `json:"exciseTax"`     // sum of all line items * excise tax rate
+	SalesTax            Money               `json:"salesTax"`      // sum of all line items * sales tax rate
+	TotalPrice          Money               `json:"totalPrice"`    // sum of all line items + excise tax + sales tax + shipping
+	Tax                 AppliedTax          `json:"tax"`           // rates used for ExciseTax and SalesTax, kept so totals can be reproduced later
+	PaymentStatus       string              `json:"paymentStatus"` // 'PENDING', 'PAID', 'REFUNDED'
+	PaymentMethod       string              `json:"paymentMethod"` // 'CASH', 'CREDIT_CARD', 'DEBIT_CARD', 'CHECK', 'OTHER'
+	ShippingAddress     string              `json:"shippingAddress"`
+	ShippingCost        Money               `json:"shippingCost"`
 	// PlacedAt            string      `json:"placedAt"` // timestamp as a string
 	// placedAt, acceptedAt, shippedAt, deliveredAt, cancelledAt, completedAt
-	DeliveryDate string `json:"deliveryDate"` // Scheduled delivery date or pickup
-	UpdatedAt    string `json:"updatedAt"`    // timestamp as a string
+	DeliveryDate  string `json:"deliveryDate"`  // Scheduled delivery date or pickup
+	ReservedUntil string `json:"reservedUntil"` // timestamp as a string; reserved stock is released if the order isn't accepted by then
+	UpdatedAt     string `json:"updatedAt"`     // timestamp as a string
+}
+
+// AppliedTax records the rates and exemptions the tax engine used for an Order.
+type AppliedTax struct {
+	RateVersion       string    `json:"rateVersion"`  // version of the rate table in effect
+	Jurisdiction      string    `json:"jurisdiction"` // derived from the shipping address ZIP
+	ExciseRate        float64   `json:"exciseRate"`
+	StateSalesTaxRate float64   `json:"stateSalesTaxRate"`
+	LocalSalesTaxRate float64   `json:"localSalesTaxRate"`
+	ExciseExempt      bool      `json:"exciseExempt"`
+	SalesTaxExempt    bool      `json:"salesTaxExempt"`
+	ExemptionReason   string    `json:"exemptionReason,omitempty"`
+	CalculatedAt      time.Time `json:"calculatedAt"`
+}
+
+// OrderStatusChange records one transition of an Order's status.
+type OrderStatusChange struct {
+	From      OrderStatus `json:"from"`
+	To        OrderStatus `json:"to"`
+	ActorID   string      `json:"actorID"`   // User ID, or "system" for automatic transitions
+	ActorRole UserRole    `json:"actorRole"` // empty for automatic transitions
+	Reason    string      `json:"reason,omitempty"`
+	At        time.Time   `json:"at"`
 }
 
 // --- Helper Struct for External API Response ---
@@ -163,6 +208,7 @@ type OMMAVerificationResponse struct {
 type UserRole string
 type AccountStatus string
 type AccountComplianceStatus string // compliance status of business
+type LicenseType string             // grower, processor or dispensary; decides who a business may trade with
 type OrderStatus string
 type PaymentStatus string // Tracks B2B payment status.
 type PaymentMethod string // Tracks B2B payment method.
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Vault is a buyer business's saved shopping state: the vendors and products its
+// members have favorited and the reorder lists they build. There is one per Buyer,
+// keyed by the buyer's ID, shared by everyone on the business's team.
+type Vault struct {
+	BuyerID          string        `json:"buyerID"`
+	FavoriteVendors  []string      `json:"favoriteVendors"`  // Vendor IDs, most recently saved last
+	FavoriteProducts []string      `json:"favoriteProducts"` // Product IDs, most recently saved last
+	ReorderLists     []ReorderList `json:"reorderLists"`
+	UpdatedAt        time.Time     `json:"updatedAt"`
+}
+
+// ReorderList is a named set of SKUs a buyer orders together, i.e. "Weekly flower".
+// Every item comes from VendorID, since an order is placed with a single vendor.
+type ReorderList struct {
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	VendorID  string        `json:"vendorID"`
+	Items     []ReorderItem `json:"items"`
+	CreatedBy string        `json:"createdBy"` // User ID
+	CreatedAt time.Time     `json:"createdAt"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+	// LastOrderID is the order most recently placed from this list.
+	LastOrderID string `json:"lastOrderID,omitempty"`
+}
+
+// ReorderItem is one line on a reorder list. SavedPrice is the unit price when the
+// line was saved or last ordered, so a reorder can flag prices that have moved.
+type ReorderItem struct {
+	ProductID  string `json:"productID"`
+	Quantity   int    `json:"quantity"`
+	SavedPrice Money  `json:"savedPrice"`
+}
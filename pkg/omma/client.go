@@ -0,0 +1,154 @@
+package omma
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+)
+
+// ErrLicenseNotFound is returned when the state has no record of the license.
+var ErrLicenseNotFound = errors.New("omma: license not found")
+
+const (
+	defaultTimeout    = 10 * time.Second
+	defaultMaxRetries = 3
+	defaultBackoff    = 250 * time.Millisecond
+)
+
+// Client calls the OMMA license verification API.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithTimeout sets the per-attempt HTTP timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) { c.httpClient.Timeout = d }
+}
+
+// WithRetries sets how many times a failed request is retried and the initial backoff,
+// which doubles (with jitter) after each attempt.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// WithAPIKey sends key in the X-API-Key header on every request.
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithHTTPClient replaces the underlying *http.Client.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// NewClient returns a Client for the API rooted at baseURL, i.e. "https://api.omma.ok.gov/v1".
+func NewClient(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Verify fetches the state's record for licenseID. Network errors, 429s and 5xx
+// responses are retried; a 404 returns ErrLicenseNotFound immediately.
+func (c *Client) Verify(ctx context.Context, licenseID string) (*models.OMMAVerificationResponse, error) {
+	endpoint := c.baseURL + "/licenses/" + url.PathEscape(licenseID)
+
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			if err := c.wait(ctx, attempt); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, retry, err := c.do(ctx, endpoint)
+		if err == nil {
+			return resp, nil
+		}
+		if !retry {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("omma: giving up after %d attempts: %w", c.maxRetries+1, lastErr)
+}
+
+// do performs a single request and reports whether a failure is worth retrying.
+func (c *Client) do(ctx context.Context, endpoint string) (*models.OMMAVerificationResponse, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		// The caller's context ending is final; anything else is a transient network failure.
+		if ctx.Err() != nil {
+			return nil, false, ctx.Err()
+		}
+		return nil, true, fmt.Errorf("omma: request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusOK:
+		var out models.OMMAVerificationResponse
+		if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+			return nil, false, fmt.Errorf("omma: decoding response: %w", err)
+		}
+		return &out, false, nil
+	case res.StatusCode == http.StatusNotFound:
+		return nil, false, ErrLicenseNotFound
+	case res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500:
+		return nil, true, fmt.Errorf("omma: server returned %s", res.Status)
+	default:
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return nil, false, fmt.Errorf("omma: unexpected status %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+}
+
+func (c *Client) wait(ctx context.Context, attempt int) error {
+	delay := c.backoff << (attempt - 1)
+	if j := delay / 2; j > 0 {
+		delay += rand.N(j)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,118 @@
+package omma
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingTransport counts the requests the client makes and runs after, if
+// set, once each response has come back.
+type countingTransport struct {
+	calls atomic.Int32
+	after func(attempt int32)
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := http.DefaultTransport.RoundTrip(req)
+	n := t.calls.Add(1)
+	if t.after != nil {
+		t.after(n)
+	}
+	return res, err
+}
+
+func TestClientVerify(t *testing.T) {
+	now := time.Now()
+	const recovering = "DAAA-1111-2222-RCVR"
+
+	tests := []struct {
+		name      string
+		licenseID string
+		setup     func(f *FakeServer, tr *countingTransport)
+		wantErr   error // checked with errors.Is; nil for success unless anyErr
+		anyErr    bool  // any error will do
+		wantCalls int32
+		wantType  string
+	}{
+		{name: "active license", licenseID: "GAAA-4K7M-2Q9X-8B3N", wantCalls: 1, wantType: "Grower"},
+		{name: "expired license is still a record", licenseID: "DAAA-3E9P-6V1Y-4Z2K", wantCalls: 1, wantType: "Dispensary"},
+		{name: "unknown license is not retried", licenseID: "ZZZZ-0000-0000-0000", wantErr: ErrLicenseNotFound, wantCalls: 1},
+		{name: "503 gives up after every retry", licenseID: FailingLicenseID, anyErr: true, wantCalls: 3},
+		{
+			name:      "503 then recovery succeeds on retry",
+			licenseID: recovering,
+			setup: func(f *FakeServer, tr *countingTransport) {
+				license := SeedLicenses(now)[1]
+				license.LicenseID = recovering
+				f.Put(license)
+				f.SetFailing(recovering, true)
+				tr.after = func(attempt int32) {
+					if attempt == 1 {
+						f.SetFailing(recovering, false)
+					}
+				}
+			},
+			wantCalls: 2,
+			wantType:  "Processor",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFakeServer(SeedLicenses(now))
+			defer f.Close()
+			tr := &countingTransport{}
+			if tt.setup != nil {
+				tt.setup(f, tr)
+			}
+			c := NewClient(f.URL, WithRetries(2, time.Millisecond), WithHTTPClient(&http.Client{Transport: tr}))
+
+			got, err := c.Verify(context.Background(), tt.licenseID)
+			switch {
+			case tt.anyErr:
+				if err == nil {
+					t.Fatalf("Verify(%q) succeeded, want an error", tt.licenseID)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Verify(%q) error = %v, want %v", tt.licenseID, err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("Verify(%q): %v", tt.licenseID, err)
+			case got.LicenseID != tt.licenseID || got.EntityType != tt.wantType:
+				t.Errorf("Verify(%q) = %+v, want license %s of type %s", tt.licenseID, got, tt.licenseID, tt.wantType)
+			}
+			if n := tr.calls.Load(); n != tt.wantCalls {
+				t.Errorf("Verify(%q) made %d requests, want %d", tt.licenseID, n, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestClientVerifyCanceled(t *testing.T) {
+	f := NewFakeServer(nil)
+	defer f.Close()
+	c := NewClient(f.URL, WithRetries(5, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.Verify(ctx, FailingLicenseID); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Verify with an expiring context: error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestClientVerifyTinyBackoff(t *testing.T) {
+	f := NewFakeServer(nil)
+	defer f.Close()
+
+	for _, backoff := range []time.Duration{0, time.Nanosecond, 2 * time.Nanosecond} {
+		c := NewClient(f.URL, WithRetries(3, backoff))
+		if _, err := c.Verify(context.Background(), FailingLicenseID); err == nil || errors.Is(err, ErrLicenseNotFound) {
+			t.Errorf("backoff %v: error = %v, want the retries to give up", backoff, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package omma
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+)
+
+// FakeServer is an in-process stand-in for the OMMA verification API. It serves
+// GET /licenses/{id} from a seeded dataset so local runs go over real HTTP.
+type FakeServer struct {
+	*httptest.Server
+
+	mu       sync.RWMutex
+	licenses map[string]models.OMMAVerificationResponse
+	failing  map[string]bool
+}
+
+// SeedLicenses returns the default dataset: one active license of each type,
+// an expired dispensary, and the sample processor license used on the home page.
+func SeedLicenses(now time.Time) []models.OMMAVerificationResponse {
+	return []models.OMMAVerificationResponse{
+		{LicenseID: "GAAA-4K7M-2Q9X-8B3N", IsActive: true, ExpirationDate: now.AddDate(0, 6, 0), EntityType: "Grower"},
+		{LicenseID: "PAAA-DJ3F-28JJ-283H", IsActive: true, ExpirationDate: now.AddDate(0, 3, 0), EntityType: "Processor"},
+		{LicenseID: "DAAA-7H2L-5R8T-1C6W", IsActive: true, ExpirationDate: now.AddDate(0, 6, 0), EntityType: "Dispensary"},
+		{LicenseID: "DAAA-3E9P-6V1Y-4Z2K", IsActive: false, ExpirationDate: now.AddDate(-1, 0, 0), EntityType: "Dispensary"},
+	}
+}
+
+// FailingLicenseID always answers 503 so callers can exercise retry and failure handling.
+const FailingLicenseID = "DAAA-0000-0000-FAIL"
+
+// NewFakeServer starts a FakeServer seeded with licenses.
+func NewFakeServer(licenses []models.OMMAVerificationResponse) *FakeServer {
+	f := &FakeServer{
+		licenses: make(map[string]models.OMMAVerificationResponse),
+		failing:  map[string]bool{FailingLicenseID: true},
+	}
+	for _, l := range licenses {
+		f.licenses[l.LicenseID] = l
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveLicense))
+	return f
+}
+
+// Put adds or replaces a license record, i.e. to simulate a renewal.
+func (f *FakeServer) Put(license models.OMMAVerificationResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.licenses[license.LicenseID] = license
+}
+
+// SetFailing makes lookups of licenseID answer 503 until cleared.
+func (f *FakeServer) SetFailing(licenseID string, failing bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failing[licenseID] = failing
+}
+
+func (f *FakeServer) serveLicense(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutPrefix(r.URL.Path, "/licenses/")
+	if r.Method != http.MethodGet || !ok || id == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	f.mu.RLock()
+	license, found := f.licenses[id]
+	failing := f.failing[id]
+	f.mu.RUnlock()
+
+	if failing {
+		http.Error(w, "upstream registry unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if !found {
+		http.Error(w, "license not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(license)
+}
@@ -0,0 +1,729 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/wesleywinston/wds/pkg/models"
+)
+
+// Firestore collection names.
+const (
+	usersCollection         = "users"
+	vendorsCollection       = "vendors"
+	buyersCollection        = "buyers"
+	productsCollection      = "products"
+	ordersCollection        = "orders"
+	invitationsCollection   = "invitations"
+	strainsCollection       = "strains"
+	vaultsCollection        = "vaults"
+	postsCollection         = "community_posts"
+	commentsCollection      = "community_comments"
+	reportsCollection       = "community_reports"
+	notificationsCollection = "notifications"
+	auditCollection         = "audit_log"
+)
+
+// NewFirestoreStore returns a Store whose repositories all share the given Firestore client.
+func NewFirestoreStore(client *firestore.Client) *Store {
+	return &Store{
+		Users:         &FirestoreUserRepository{col: firestoreCollection[userDocument]{client, usersCollection}},
+		Vendors:       &FirestoreVendorRepository{col: firestoreCollection[models.Vendor]{client, vendorsCollection}},
+		Buyers:        &FirestoreBuyerRepository{col: firestoreCollection[models.Buyer]{client, buyersCollection}},
+		Products:      &FirestoreProductRepository{col: firestoreCollection[models.Product]{client, productsCollection}},
+		Orders:        &FirestoreOrderRepository{col: firestoreCollection[models.Order]{client, ordersCollection}, products: firestoreCollection[models.Product]{client, productsCollection}},
+		Invitations:   &FirestoreInvitationRepository{col: firestoreCollection[models.Invitation]{client, invitationsCollection}},
+		Strains:       &FirestoreStrainRepository{col: firestoreCollection[models.Strain]{client, strainsCollection}},
+		Vaults:        &FirestoreVaultRepository{col: firestoreCollection[models.Vault]{client, vaultsCollection}},
+		Posts:         &FirestorePostRepository{col: firestoreCollection[models.Post]{client, postsCollection}},
+		Comments:      &FirestoreCommentRepository{col: firestoreCollection[models.Comment]{client, commentsCollection}},
+		Reports:       &FirestoreReportRepository{col: firestoreCollection[models.Report]{client, reportsCollection}},
+		Notifications: &FirestoreNotificationRepository{col: firestoreCollection[models.Notification]{client, notificationsCollection}},
+		Audit:         &FirestoreAuditRepository{col: firestoreCollection[models.AuditEvent]{client, auditCollection}},
+	}
+}
+
+// firestoreError translates Firestore status codes into the repository's sentinel errors.
+func firestoreError(err error) error {
+	switch status.Code(err) {
+	case codes.OK:
+		return nil
+	case codes.NotFound:
+		return ErrNotFound
+	case codes.AlreadyExists:
+		return ErrAlreadyExists
+	}
+	return err
+}
+
+// firestoreCollection is a typed view over a single Firestore collection whose
+// documents are keyed by the record's ID.
+type firestoreCollection[T any] struct {
+	client *firestore.Client
+	name   string
+}
+
+func (c firestoreCollection[T]) ref() *firestore.CollectionRef {
+	return c.client.Collection(c.name)
+}
+
+func (c firestoreCollection[T]) create(ctx context.Context, id string, v T) error {
+	_, err := c.ref().Doc(id).Create(ctx, v)
+	return firestoreError(err)
+}
+
+func (c firestoreCollection[T]) get(ctx context.Context, id string) (*T, error) {
+	snap, err := c.ref().Doc(id).Get(ctx)
+	if err != nil {
+		return nil, firestoreError(err)
+	}
+	var v T
+	if err := snap.DataTo(&v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// update overwrites an existing document, failing with ErrNotFound rather than creating it.
+func (c firestoreCollection[T]) update(ctx context.Context, id string, v T) error {
+	doc := c.ref().Doc(id)
+	err := c.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if _, err := tx.Get(doc); err != nil {
+			return err
+		}
+		return tx.Set(doc, v)
+	})
+	return firestoreError(err)
+}
+
+// modify reads a document, applies fn and writes the result back in one
+// transaction. Firestore retries the transaction if the document changes
+// underneath it, so fn may run more than once.
+func (c firestoreCollection[T]) modify(ctx context.Context, id string, fn func(*T) error) (*T, error) {
+	doc := c.ref().Doc(id)
+	var out T
+	err := c.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(doc)
+		if err != nil {
+			return err
+		}
+		var v T
+		if err := snap.DataTo(&v); err != nil {
+			return err
+		}
+		if err := fn(&v); err != nil {
+			return err
+		}
+		out = v
+		return tx.Set(doc, v)
+	})
+	if err != nil {
+		return nil, firestoreError(err)
+	}
+	return &out, nil
+}
+
+// set creates or overwrites a document.
+func (c firestoreCollection[T]) set(ctx context.Context, id string, v T) error {
+	_, err := c.ref().Doc(id).Set(ctx, v)
+	return firestoreError(err)
+}
+
+func (c firestoreCollection[T]) delete(ctx context.Context, id string) error {
+	_, err := c.ref().Doc(id).Delete(ctx, firestore.Exists)
+	return firestoreError(err)
+}
+
+func (c firestoreCollection[T]) query(ctx context.Context, q firestore.Query) ([]T, error) {
+	snaps, err := q.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, firestoreError(err)
+	}
+	out := make([]T, 0, len(snaps))
+	for _, snap := range snaps {
+		var v T
+		if err := snap.DataTo(&v); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// FirestoreUserRepository is a UserRepository backed by the "users" collection.
+type FirestoreUserRepository struct {
+	col firestoreCollection[userDocument]
+}
+
+// userDocument is how a User is stored. Firestore can't hydrate the AccountData
+// interface, so the document shadows it with the tagged AccountEntityRecord; the
+// embedded User's other fields are stored flat, as before.
+type userDocument struct {
+	models.User
+	AccountData *models.AccountEntityRecord
+}
+
+func newUserDocument(user models.User) (userDocument, error) {
+	record, err := models.NewAccountEntityRecord(user.AccountData)
+	if err != nil {
+		return userDocument{}, err
+	}
+	user.AccountData = nil
+	return userDocument{User: user, AccountData: record}, nil
+}
+
+func (d userDocument) user() (models.User, error) {
+	entity, err := d.AccountData.Entity()
+	if err != nil {
+		return models.User{}, fmt.Errorf("user %s: %w", d.ID, err)
+	}
+	d.User.AccountData = entity
+	return d.User, nil
+}
+
+// users converts query results back into Users.
+func users(docs []userDocument, err error) ([]models.User, error) {
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.User, 0, len(docs))
+	for _, doc := range docs {
+		user, err := doc.user()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, user)
+	}
+	return out, nil
+}
+
+// Create writes the user inside a transaction so the email uniqueness check and the insert are atomic.
+func (f *FirestoreUserRepository) Create(ctx context.Context, user models.User) error {
+	doc, err := newUserDocument(user)
+	if err != nil {
+		return err
+	}
+	ref := f.col.ref().Doc(user.ID)
+	err = f.col.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		existing, err := tx.Documents(f.col.ref().Where("Email", "==", strings.ToLower(user.Email)).Limit(1)).GetAll()
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			return ErrAlreadyExists
+		}
+		return tx.Create(ref, doc)
+	})
+	return firestoreError(err)
+}
+
+func (f *FirestoreUserRepository) Get(ctx context.Context, id string) (*models.User, error) {
+	doc, err := f.col.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := doc.user()
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (f *FirestoreUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	list, err := users(f.col.query(ctx, f.col.ref().Where("Email", "==", strings.ToLower(email)).Limit(1)))
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, ErrNotFound
+	}
+	return &list[0], nil
+}
+
+func (f *FirestoreUserRepository) Update(ctx context.Context, user models.User) error {
+	doc, err := newUserDocument(user)
+	if err != nil {
+		return err
+	}
+	return f.col.update(ctx, user.ID, doc)
+}
+
+func (f *FirestoreUserRepository) Modify(ctx context.Context, id string, fn func(*models.User) error) (*models.User, error) {
+	doc, err := f.col.modify(ctx, id, func(d *userDocument) error {
+		user, err := d.user()
+		if err != nil {
+			return err
+		}
+		if err := keepEmail(fn)(&user); err != nil {
+			return err
+		}
+		*d, err = newUserDocument(user)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	user, err := doc.user()
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (f *FirestoreUserRepository) Delete(ctx context.Context, id string) error {
+	return f.col.delete(ctx, id)
+}
+
+func (f *FirestoreUserRepository) List(ctx context.Context) ([]models.User, error) {
+	return users(f.col.query(ctx, f.col.ref().OrderBy(firestore.DocumentID, firestore.Asc)))
+}
+
+func (f *FirestoreUserRepository) ListByStatus(ctx context.Context, status models.AccountStatus) ([]models.User, error) {
+	return users(f.col.query(ctx, f.col.ref().Where("Status", "==", string(status))))
+}
+
+// FirestoreVendorRepository is a VendorRepository backed by the "vendors" collection.
+type FirestoreVendorRepository struct {
+	col firestoreCollection[models.Vendor]
+}
+
+func (f *FirestoreVendorRepository) Create(ctx context.Context, vendor models.Vendor) error {
+	return f.col.create(ctx, vendor.ID, vendor)
+}
+
+func (f *FirestoreVendorRepository) Get(ctx context.Context, id string) (*models.Vendor, error) {
+	return f.col.get(ctx, id)
+}
+
+func (f *FirestoreVendorRepository) Update(ctx context.Context, vendor models.Vendor) error {
+	return f.col.update(ctx, vendor.ID, vendor)
+}
+
+func (f *FirestoreVendorRepository) Modify(ctx context.Context, id string, fn func(*models.Vendor) error) (*models.Vendor, error) {
+	return f.col.modify(ctx, id, fn)
+}
+
+func (f *FirestoreVendorRepository) Delete(ctx context.Context, id string) error {
+	return f.col.delete(ctx, id)
+}
+
+func (f *FirestoreVendorRepository) List(ctx context.Context) ([]models.Vendor, error) {
+	return f.col.query(ctx, f.col.ref().OrderBy(firestore.DocumentID, firestore.Asc))
+}
+
+// FirestoreBuyerRepository is a BuyerRepository backed by the "buyers" collection.
+type FirestoreBuyerRepository struct {
+	col firestoreCollection[models.Buyer]
+}
+
+func (f *FirestoreBuyerRepository) Create(ctx context.Context, buyer models.Buyer) error {
+	return f.col.create(ctx, buyer.ID, buyer)
+}
+
+func (f *FirestoreBuyerRepository) Get(ctx context.Context, id string) (*models.Buyer, error) {
+	return f.col.get(ctx, id)
+}
+
+func (f *FirestoreBuyerRepository) Update(ctx context.Context, buyer models.Buyer) error {
+	return f.col.update(ctx, buyer.ID, buyer)
+}
+
+func (f *FirestoreBuyerRepository) Modify(ctx context.Context, id string, fn func(*models.Buyer) error) (*models.Buyer, error) {
+	return f.col.modify(ctx, id, fn)
+}
+
+func (f *FirestoreBuyerRepository) Delete(ctx context.Context, id string) error {
+	return f.col.delete(ctx, id)
+}
+
+func (f *FirestoreBuyerRepository) List(ctx context.Context) ([]models.Buyer, error) {
+	return f.col.query(ctx, f.col.ref().OrderBy(firestore.DocumentID, firestore.Asc))
+}
+
+// FirestoreProductRepository is a ProductRepository backed by the "products" collection.
+type FirestoreProductRepository struct {
+	col firestoreCollection[models.Product]
+}
+
+func (f *FirestoreProductRepository) Create(ctx context.Context, product models.Product) error {
+	return f.col.create(ctx, product.ID, product)
+}
+
+func (f *FirestoreProductRepository) Get(ctx context.Context, id string) (*models.Product, error) {
+	return f.col.get(ctx, id)
+}
+
+func (f *FirestoreProductRepository) Update(ctx context.Context, product models.Product) error {
+	_, err := f.Modify(ctx, product.ID, func(p *models.Product) error {
+		*p = product
+		return nil
+	})
+	return err
+}
+
+func (f *FirestoreProductRepository) Modify(ctx context.Context, id string, fn func(*models.Product) error) (*models.Product, error) {
+	return f.col.modify(ctx, id, keepStock(fn))
+}
+
+func (f *FirestoreProductRepository) Delete(ctx context.Context, id string) error {
+	return f.col.delete(ctx, id)
+}
+
+func (f *FirestoreProductRepository) List(ctx context.Context) ([]models.Product, error) {
+	return f.col.query(ctx, f.col.ref().OrderBy(firestore.DocumentID, firestore.Asc))
+}
+
+func (f *FirestoreProductRepository) ListByVendor(ctx context.Context, vendorID string) ([]models.Product, error) {
+	return f.col.query(ctx, f.col.ref().Where("VendorID", "==", vendorID))
+}
+
+// firestoreInLimit is the most values Firestore accepts in one "in" filter.
+const firestoreInLimit = 30
+
+// Search runs filter as a query, split into one query per firestoreInLimit vendors.
+func (f *FirestoreProductRepository) Search(ctx context.Context, filter ProductFilter) ([]models.Product, error) {
+	q := f.col.ref().Query
+	if filter.Category != "" {
+		q = q.Where("Category", "==", filter.Category)
+	}
+	if filter.IsMedical != nil {
+		q = q.Where("IsMedical", "==", *filter.IsMedical)
+	}
+	if len(filter.VendorIDs) == 0 {
+		return f.col.query(ctx, q.OrderBy(firestore.DocumentID, firestore.Asc))
+	}
+
+	var out []models.Product
+	for chunk := range slices.Chunk(filter.VendorIDs, firestoreInLimit) {
+		found, err := f.col.query(ctx, q.Where("VendorID", "in", chunk))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, found...)
+	}
+	slices.SortFunc(out, func(a, b models.Product) int { return strings.Compare(a.ID, b.ID) })
+	return out, nil
+}
+
+// AdjustStock reads every product and writes the new counts in one transaction,
+// so concurrent orders for the same SKU are serialized by Firestore.
+func (f *FirestoreProductRepository) AdjustStock(ctx context.Context, deltas map[string]int) error {
+	return f.ReserveStock(ctx, deltas, nil)
+}
+
+func (f *FirestoreProductRepository) ReserveStock(ctx context.Context, deltas map[string]int, prices map[string]models.Money) error {
+	err := f.col.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		updated := make(map[*firestore.DocumentRef]int, len(deltas))
+		for id, delta := range deltas {
+			doc := f.col.ref().Doc(id)
+			snap, err := tx.Get(doc)
+			if err != nil {
+				return err
+			}
+			var product models.Product
+			if err := snap.DataTo(&product); err != nil {
+				return err
+			}
+			if price, ok := prices[id]; ok && !product.PricePerUnit.Equal(price) {
+				return ErrPriceChanged
+			}
+			if product.AvailableUnits+delta < 0 {
+				return ErrInsufficientStock
+			}
+			updated[doc] = product.AvailableUnits + delta
+		}
+		for doc, units := range updated {
+			if err := tx.Update(doc, []firestore.Update{{Path: "AvailableUnits", Value: units}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return firestoreError(err)
+}
+
+// FirestoreOrderRepository is an OrderRepository backed by the "orders" collection.
+// Transition also writes stock to the "products" collection.
+type FirestoreOrderRepository struct {
+	col      firestoreCollection[models.Order]
+	products firestoreCollection[models.Product]
+}
+
+func (f *FirestoreOrderRepository) Create(ctx context.Context, order models.Order) error {
+	return f.col.create(ctx, order.ID, order)
+}
+
+func (f *FirestoreOrderRepository) Get(ctx context.Context, id string) (*models.Order, error) {
+	return f.col.get(ctx, id)
+}
+
+func (f *FirestoreOrderRepository) Update(ctx context.Context, order models.Order) error {
+	return f.col.update(ctx, order.ID, order)
+}
+
+// Transition reads the order and every restocked product before writing, as
+// Firestore transactions require, and is retried by Firestore if any of them
+// changes underneath it.
+func (f *FirestoreOrderRepository) Transition(ctx context.Context, order models.Order, from models.OrderStatus, restock map[string]int) error {
+	err := f.col.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc := f.col.ref().Doc(order.ID)
+		snap, err := tx.Get(doc)
+		if err != nil {
+			return err
+		}
+		var stored models.Order
+		if err := snap.DataTo(&stored); err != nil {
+			return err
+		}
+		if stored.Status != from {
+			return ErrConflict
+		}
+
+		updated := make(map[*firestore.DocumentRef]int, len(restock))
+		for id, delta := range restock {
+			productDoc := f.products.ref().Doc(id)
+			snap, err := tx.Get(productDoc)
+			if status.Code(err) == codes.NotFound {
+				continue
+			} else if err != nil {
+				return err
+			}
+			var product models.Product
+			if err := snap.DataTo(&product); err != nil {
+				return err
+			}
+			updated[productDoc] = product.AvailableUnits + delta
+		}
+
+		if err := tx.Set(doc, order); err != nil {
+			return err
+		}
+		for productDoc, units := range updated {
+			if err := tx.Update(productDoc, []firestore.Update{{Path: "AvailableUnits", Value: units}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return firestoreError(err)
+}
+
+func (f *FirestoreOrderRepository) ListByBuyer(ctx context.Context, buyerID string) ([]models.Order, error) {
+	return f.col.query(ctx, f.col.ref().Where("BuyerID", "==", buyerID))
+}
+
+func (f *FirestoreOrderRepository) ListByVendor(ctx context.Context, vendorID string) ([]models.Order, error) {
+	return f.col.query(ctx, f.col.ref().Where("VendorID", "==", vendorID))
+}
+
+func (f *FirestoreOrderRepository) ListByStatus(ctx context.Context, status models.OrderStatus) ([]models.Order, error) {
+	return f.col.query(ctx, f.col.ref().Where("Status", "==", string(status)))
+}
+
+// FirestoreInvitationRepository is an InvitationRepository backed by the "invitations" collection.
+type FirestoreInvitationRepository struct {
+	col firestoreCollection[models.Invitation]
+}
+
+func (f *FirestoreInvitationRepository) Create(ctx context.Context, invitation models.Invitation) error {
+	return f.col.create(ctx, invitation.ID, invitation)
+}
+
+func (f *FirestoreInvitationRepository) Get(ctx context.Context, id string) (*models.Invitation, error) {
+	return f.col.get(ctx, id)
+}
+
+func (f *FirestoreInvitationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.Invitation, error) {
+	invitations, err := f.col.query(ctx, f.col.ref().Where("TokenHash", "==", tokenHash).Limit(1))
+	if err != nil {
+		return nil, err
+	}
+	if len(invitations) == 0 {
+		return nil, ErrNotFound
+	}
+	return &invitations[0], nil
+}
+
+func (f *FirestoreInvitationRepository) Update(ctx context.Context, invitation models.Invitation) error {
+	return f.col.update(ctx, invitation.ID, invitation)
+}
+
+func (f *FirestoreInvitationRepository) ListByEntity(ctx context.Context, entityID string) ([]models.Invitation, error) {
+	return f.col.query(ctx, f.col.ref().Where("EntityID", "==", entityID))
+}
+
+// FirestoreStrainRepository is a StrainRepository backed by the "strains" collection.
+type FirestoreStrainRepository struct {
+	col firestoreCollection[models.Strain]
+}
+
+func (f *FirestoreStrainRepository) Create(ctx context.Context, strain models.Strain) error {
+	return f.col.create(ctx, strain.ID, strain)
+}
+
+func (f *FirestoreStrainRepository) Get(ctx context.Context, id string) (*models.Strain, error) {
+	return f.col.get(ctx, id)
+}
+
+func (f *FirestoreStrainRepository) Update(ctx context.Context, strain models.Strain) error {
+	return f.col.update(ctx, strain.ID, strain)
+}
+
+func (f *FirestoreStrainRepository) List(ctx context.Context) ([]models.Strain, error) {
+	return f.col.query(ctx, f.col.ref().OrderBy(firestore.DocumentID, firestore.Asc))
+}
+
+// FirestoreVaultRepository is a VaultRepository backed by the "vaults" collection,
+// keyed by buyer ID.
+type FirestoreVaultRepository struct {
+	col firestoreCollection[models.Vault]
+}
+
+func (f *FirestoreVaultRepository) Get(ctx context.Context, buyerID string) (*models.Vault, error) {
+	return f.col.get(ctx, buyerID)
+}
+
+func (f *FirestoreVaultRepository) Save(ctx context.Context, vault models.Vault) error {
+	return f.col.set(ctx, vault.BuyerID, vault)
+}
+
+// FirestorePostRepository is a PostRepository backed by the "community_posts" collection.
+type FirestorePostRepository struct {
+	col firestoreCollection[models.Post]
+}
+
+func (f *FirestorePostRepository) Create(ctx context.Context, post models.Post) error {
+	return f.col.create(ctx, post.ID, post)
+}
+
+func (f *FirestorePostRepository) Get(ctx context.Context, id string) (*models.Post, error) {
+	return f.col.get(ctx, id)
+}
+
+func (f *FirestorePostRepository) Update(ctx context.Context, post models.Post) error {
+	return f.col.update(ctx, post.ID, post)
+}
+
+func (f *FirestorePostRepository) Page(ctx context.Context, filter PostFilter, limit int) ([]models.Post, error) {
+	q := f.col.ref().Query
+	if filter.Kind != "" {
+		q = q.Where("Kind", "==", string(filter.Kind))
+	}
+	if filter.EntityID != "" {
+		q = q.Where("EntityID", "==", filter.EntityID)
+	}
+	if !filter.Before.IsZero() {
+		q = q.Where("CreatedAt", "<", filter.Before)
+	}
+	return f.col.query(ctx, q.OrderBy("CreatedAt", firestore.Desc).OrderBy(firestore.DocumentID, firestore.Desc).Limit(limit))
+}
+
+// FirestoreCommentRepository is a CommentRepository backed by the "community_comments" collection.
+type FirestoreCommentRepository struct {
+	col firestoreCollection[models.Comment]
+}
+
+func (f *FirestoreCommentRepository) Create(ctx context.Context, comment models.Comment) error {
+	return f.col.create(ctx, comment.ID, comment)
+}
+
+func (f *FirestoreCommentRepository) Get(ctx context.Context, id string) (*models.Comment, error) {
+	return f.col.get(ctx, id)
+}
+
+func (f *FirestoreCommentRepository) Update(ctx context.Context, comment models.Comment) error {
+	return f.col.update(ctx, comment.ID, comment)
+}
+
+func (f *FirestoreCommentRepository) ListByPost(ctx context.Context, postID string) ([]models.Comment, error) {
+	return f.col.query(ctx, f.col.ref().Where("PostID", "==", postID))
+}
+
+// FirestoreReportRepository is a ReportRepository backed by the "community_reports" collection.
+type FirestoreReportRepository struct {
+	col firestoreCollection[models.Report]
+}
+
+func (f *FirestoreReportRepository) Create(ctx context.Context, report models.Report) error {
+	return f.col.create(ctx, report.ID, report)
+}
+
+func (f *FirestoreReportRepository) Get(ctx context.Context, id string) (*models.Report, error) {
+	return f.col.get(ctx, id)
+}
+
+func (f *FirestoreReportRepository) Update(ctx context.Context, report models.Report) error {
+	return f.col.update(ctx, report.ID, report)
+}
+
+func (f *FirestoreReportRepository) ListByStatus(ctx context.Context, status models.ReportStatus) ([]models.Report, error) {
+	return f.col.query(ctx, f.col.ref().Where("Status", "==", string(status)))
+}
+
+// FirestoreNotificationRepository is a NotificationRepository backed by the "notifications" collection.
+type FirestoreNotificationRepository struct {
+	col firestoreCollection[models.Notification]
+}
+
+func (f *FirestoreNotificationRepository) Create(ctx context.Context, notification models.Notification) error {
+	return f.col.create(ctx, notification.ID, notification)
+}
+
+func (f *FirestoreNotificationRepository) Get(ctx context.Context, id string) (*models.Notification, error) {
+	return f.col.get(ctx, id)
+}
+
+func (f *FirestoreNotificationRepository) Update(ctx context.Context, notification models.Notification) error {
+	return f.col.update(ctx, notification.ID, notification)
+}
+
+func (f *FirestoreNotificationRepository) ListByRecipient(ctx context.Context, userID string) ([]models.Notification, error) {
+	return f.col.query(ctx, f.col.ref().Where("Recipients", "array-contains", userID))
+}
+
+func (f *FirestoreNotificationRepository) ListByEntity(ctx context.Context, entityID string) ([]models.Notification, error) {
+	return f.col.query(ctx, f.col.ref().Where("EntityID", "==", entityID))
+}
+
+// FirestoreAuditRepository is an AuditRepository backed by the "audit_log" collection.
+// Events are only ever created; Firestore security rules should deny updates and deletes.
+type FirestoreAuditRepository struct {
+	col firestoreCollection[models.AuditEvent]
+}
+
+func (f *FirestoreAuditRepository) Append(ctx context.Context, event models.AuditEvent) error {
+	return f.col.create(ctx, event.ID, event)
+}
+
+func (f *FirestoreAuditRepository) Query(ctx context.Context, filter AuditFilter) ([]models.AuditEvent, error) {
+	q := f.col.ref().Query
+	if filter.ActorID != "" {
+		q = q.Where("ActorID", "==", filter.ActorID)
+	}
+	if filter.Action != "" {
+		q = q.Where("Action", "==", string(filter.Action))
+	}
+	if filter.EntityType != "" {
+		q = q.Where("EntityType", "==", string(filter.EntityType))
+	}
+	if filter.EntityID != "" {
+		q = q.Where("EntityID", "==", filter.EntityID)
+	}
+	if !filter.Since.IsZero() {
+		q = q.Where("At", ">=", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		q = q.Where("At", "<", filter.Until)
+	}
+	q = q.OrderBy("At", firestore.Asc)
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+	return f.col.query(ctx, q)
+}
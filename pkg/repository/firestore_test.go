@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/utils"
+)
+
+// accountDataCases are users carrying each kind of AccountData.
+func accountDataCases() []models.User {
+	expires := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	vendor := models.Vendor{ID: "vendor_1", BusinessName: "Green Acres", OKStateLicenseID: "GAAA-4K7M-2Q9X-8B3N", LicenseExpirationDate: expires, MenuEnabled: true}
+	buyer := models.Buyer{ID: "buyer_1", BusinessName: "Corner Dispensary", OKStateLicenseID: "DAAA-7H2L-5R8T-1C6W", LicenseExpirationDate: expires}
+	return []models.User{
+		{ID: "user_vendor", Email: "vendor@example.com", Role: models.RoleVendor, AssociatedEntityID: vendor.ID, AccountData: vendor},
+		{ID: "user_buyer", Email: "buyer@example.com", Role: models.RoleBuyer, AssociatedEntityID: buyer.ID, AccountData: &buyer},
+		{ID: "user_admin", Email: "admin@example.com", Role: models.RoleAdmin},
+	}
+}
+
+// checkAccountData compares the business a user came back with to the one it was stored with.
+func checkAccountData(t *testing.T, got, want models.User) {
+	t.Helper()
+	if got.ID != want.ID || got.AssociatedEntityID != want.AssociatedEntityID {
+		t.Errorf("user = %+v, want %+v", got, want)
+	}
+	wantVendor, isVendor := want.VendorAccount()
+	wantBuyer, isBuyer := want.BuyerAccount()
+	gotVendor, gotIsVendor := got.VendorAccount()
+	gotBuyer, gotIsBuyer := got.BuyerAccount()
+	switch {
+	case gotIsVendor != isVendor || gotIsBuyer != isBuyer:
+		t.Errorf("%s AccountData = %#v, want %#v", want.ID, got.AccountData, want.AccountData)
+	case isVendor && (gotVendor.ID != wantVendor.ID || gotVendor.BusinessName != wantVendor.BusinessName ||
+		!gotVendor.LicenseExpirationDate.Equal(wantVendor.LicenseExpirationDate) || gotVendor.MenuEnabled != wantVendor.MenuEnabled):
+		t.Errorf("%s vendor = %+v, want %+v", want.ID, gotVendor, wantVendor)
+	case isBuyer && (gotBuyer.ID != wantBuyer.ID || gotBuyer.OKStateLicenseID != wantBuyer.OKStateLicenseID):
+		t.Errorf("%s buyer = %+v, want %+v", want.ID, gotBuyer, wantBuyer)
+	case !isVendor && !isBuyer && got.AccountData != nil:
+		t.Errorf("%s AccountData = %#v, want nil", want.ID, got.AccountData)
+	}
+}
+
+func TestUserDocument(t *testing.T) {
+	for _, user := range accountDataCases() {
+		doc, err := newUserDocument(user)
+		if err != nil {
+			t.Fatalf("newUserDocument(%s): %v", user.ID, err)
+		}
+		if doc.User.AccountData != nil {
+			t.Errorf("%s: the interface field is stored as well as the record", user.ID)
+		}
+		got, err := doc.user()
+		if err != nil {
+			t.Fatalf("user(%s): %v", user.ID, err)
+		}
+		checkAccountData(t, got, user)
+	}
+
+	bad := userDocument{User: models.User{ID: "user_bad"}, AccountData: &models.AccountEntityRecord{Type: models.AccountEntityVendor}}
+	if _, err := bad.user(); err == nil {
+		t.Error("a vendor record without a vendor decoded without error")
+	}
+}
+
+// TestFirestoreUserRepositoryAccountData needs the Firestore emulator:
+//
+//	gcloud emulators firestore start --host-port=localhost:8081
+//	FIRESTORE_EMULATOR_HOST=localhost:8081 go test ./pkg/repository/
+func TestFirestoreUserRepositoryAccountData(t *testing.T) {
+	if os.Getenv("FIRESTORE_EMULATOR_HOST") == "" {
+		t.Skip("FIRESTORE_EMULATOR_HOST is not set")
+	}
+	ctx := context.Background()
+	client, err := firestore.NewClient(ctx, "wds-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	repo := NewFirestoreStore(client).Users
+
+	for _, user := range accountDataCases() {
+		// IDs and emails are unique per run so reruns against one emulator don't collide.
+		user.ID = utils.NewID(user.ID)
+		user.Email = user.ID + "@example.com"
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Create %s: %v", user.ID, err)
+		}
+		got, err := repo.Get(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("Get %s: %v", user.ID, err)
+		}
+		checkAccountData(t, *got, user)
+
+		modified, err := repo.Modify(ctx, user.ID, func(u *models.User) error {
+			u.StatusReason = "checked"
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Modify %s: %v", user.ID, err)
+		}
+		checkAccountData(t, *modified, user)
+	}
+}
@@ -0,0 +1,718 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/wesleywinston/wds/pkg/models"
+)
+
+// NewMemoryStore returns a Store backed entirely by process memory, for tests and local development.
+func NewMemoryStore() *Store {
+	products := NewMemoryProductRepository()
+	return &Store{
+		Users:         NewMemoryUserRepository(),
+		Vendors:       NewMemoryVendorRepository(),
+		Buyers:        NewMemoryBuyerRepository(),
+		Products:      products,
+		Orders:        NewMemoryOrderRepository(products),
+		Invitations:   NewMemoryInvitationRepository(),
+		Strains:       NewMemoryStrainRepository(),
+		Vaults:        NewMemoryVaultRepository(),
+		Posts:         NewMemoryPostRepository(),
+		Comments:      NewMemoryCommentRepository(),
+		Reports:       NewMemoryReportRepository(),
+		Notifications: NewMemoryNotificationRepository(),
+		Audit:         NewMemoryAuditRepository(),
+	}
+}
+
+// memoryTable is a mutex-guarded map of records keyed by ID. Records are stored and
+// returned by value so callers cannot mutate stored state without calling update.
+type memoryTable[T any] struct {
+	mu   sync.RWMutex
+	rows map[string]T
+	id   func(T) string
+}
+
+func newMemoryTable[T any](id func(T) string) *memoryTable[T] {
+	return &memoryTable[T]{rows: make(map[string]T), id: id}
+}
+
+func (t *memoryTable[T]) create(v T) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.rows[t.id(v)]; ok {
+		return ErrAlreadyExists
+	}
+	t.rows[t.id(v)] = v
+	return nil
+}
+
+func (t *memoryTable[T]) get(id string) (*T, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	v, ok := t.rows[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &v, nil
+}
+
+func (t *memoryTable[T]) update(v T) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.rows[t.id(v)]; !ok {
+		return ErrNotFound
+	}
+	t.rows[t.id(v)] = v
+	return nil
+}
+
+// modify applies fn to the record with id and stores the result, all under the
+// table lock, so concurrent modifications can't overwrite each other. Nothing is
+// stored if fn fails.
+func (t *memoryTable[T]) modify(id string, fn func(*T) error) (*T, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	v, ok := t.rows[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if err := fn(&v); err != nil {
+		return nil, err
+	}
+	t.rows[id] = v
+	return &v, nil
+}
+
+// put stores v whether or not a record with its ID already exists.
+func (t *memoryTable[T]) put(v T) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rows[t.id(v)] = v
+}
+
+func (t *memoryTable[T]) delete(id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.rows[id]; !ok {
+		return ErrNotFound
+	}
+	delete(t.rows, id)
+	return nil
+}
+
+// list returns every record accepted by keep (or all records if keep is nil), ordered by ID.
+func (t *memoryTable[T]) list(keep func(T) bool) []T {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	out := make([]T, 0, len(t.rows))
+	for _, v := range t.rows {
+		if keep == nil || keep(v) {
+			out = append(out, v)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return t.id(out[i]) < t.id(out[j]) })
+	return out
+}
+
+// MemoryUserRepository is an in-memory UserRepository for tests and local development.
+type MemoryUserRepository struct {
+	mu      sync.RWMutex
+	users   map[string]models.User
+	byEmail map[string]string // lower-cased email -> user ID
+}
+
+// NewMemoryUserRepository returns an empty MemoryUserRepository.
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users:   make(map[string]models.User),
+		byEmail: make(map[string]string),
+	}
+}
+
+func (m *MemoryUserRepository) Create(ctx context.Context, user models.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	email := strings.ToLower(user.Email)
+	if _, ok := m.users[user.ID]; ok {
+		return ErrAlreadyExists
+	}
+	if _, ok := m.byEmail[email]; ok {
+		return ErrAlreadyExists
+	}
+	m.users[user.ID] = user
+	m.byEmail[email] = user.ID
+	return nil
+}
+
+func (m *MemoryUserRepository) Get(ctx context.Context, id string) (*models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+func (m *MemoryUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	id, ok := m.byEmail[strings.ToLower(email)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	user := m.users[id]
+	return &user, nil
+}
+
+func (m *MemoryUserRepository) Update(ctx context.Context, user models.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.users[user.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	oldEmail, newEmail := strings.ToLower(existing.Email), strings.ToLower(user.Email)
+	if oldEmail != newEmail {
+		if _, taken := m.byEmail[newEmail]; taken {
+			return ErrAlreadyExists
+		}
+		delete(m.byEmail, oldEmail)
+		m.byEmail[newEmail] = user.ID
+	}
+	m.users[user.ID] = user
+	return nil
+}
+
+func (m *MemoryUserRepository) Modify(ctx context.Context, id string, fn func(*models.User) error) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if err := keepEmail(fn)(&user); err != nil {
+		return nil, err
+	}
+	m.users[id] = user
+	return &user, nil
+}
+
+func (m *MemoryUserRepository) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(m.byEmail, strings.ToLower(user.Email))
+	delete(m.users, id)
+	return nil
+}
+
+func (m *MemoryUserRepository) List(ctx context.Context) ([]models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make([]models.User, 0, len(m.users))
+	for _, user := range m.users {
+		out = append(out, user)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (m *MemoryUserRepository) ListByStatus(ctx context.Context, status models.AccountStatus) ([]models.User, error) {
+	all, _ := m.List(ctx)
+	out := all[:0]
+	for _, user := range all {
+		if user.Status == status {
+			out = append(out, user)
+		}
+	}
+	return out, nil
+}
+
+// MemoryVendorRepository is an in-memory VendorRepository.
+type MemoryVendorRepository struct {
+	table *memoryTable[models.Vendor]
+}
+
+// NewMemoryVendorRepository returns an empty MemoryVendorRepository.
+func NewMemoryVendorRepository() *MemoryVendorRepository {
+	return &MemoryVendorRepository{table: newMemoryTable(func(v models.Vendor) string { return v.ID })}
+}
+
+func (m *MemoryVendorRepository) Create(ctx context.Context, vendor models.Vendor) error {
+	return m.table.create(vendor)
+}
+
+func (m *MemoryVendorRepository) Get(ctx context.Context, id string) (*models.Vendor, error) {
+	return m.table.get(id)
+}
+
+func (m *MemoryVendorRepository) Update(ctx context.Context, vendor models.Vendor) error {
+	return m.table.update(vendor)
+}
+
+func (m *MemoryVendorRepository) Modify(ctx context.Context, id string, fn func(*models.Vendor) error) (*models.Vendor, error) {
+	return m.table.modify(id, fn)
+}
+
+func (m *MemoryVendorRepository) Delete(ctx context.Context, id string) error {
+	return m.table.delete(id)
+}
+
+func (m *MemoryVendorRepository) List(ctx context.Context) ([]models.Vendor, error) {
+	return m.table.list(nil), nil
+}
+
+// MemoryBuyerRepository is an in-memory BuyerRepository.
+type MemoryBuyerRepository struct {
+	table *memoryTable[models.Buyer]
+}
+
+// NewMemoryBuyerRepository returns an empty MemoryBuyerRepository.
+func NewMemoryBuyerRepository() *MemoryBuyerRepository {
+	return &MemoryBuyerRepository{table: newMemoryTable(func(b models.Buyer) string { return b.ID })}
+}
+
+func (m *MemoryBuyerRepository) Create(ctx context.Context, buyer models.Buyer) error {
+	return m.table.create(buyer)
+}
+
+func (m *MemoryBuyerRepository) Get(ctx context.Context, id string) (*models.Buyer, error) {
+	return m.table.get(id)
+}
+
+func (m *MemoryBuyerRepository) Update(ctx context.Context, buyer models.Buyer) error {
+	return m.table.update(buyer)
+}
+
+func (m *MemoryBuyerRepository) Modify(ctx context.Context, id string, fn func(*models.Buyer) error) (*models.Buyer, error) {
+	return m.table.modify(id, fn)
+}
+
+func (m *MemoryBuyerRepository) Delete(ctx context.Context, id string) error {
+	return m.table.delete(id)
+}
+
+func (m *MemoryBuyerRepository) List(ctx context.Context) ([]models.Buyer, error) {
+	return m.table.list(nil), nil
+}
+
+// MemoryProductRepository is an in-memory ProductRepository.
+type MemoryProductRepository struct {
+	table *memoryTable[models.Product]
+}
+
+// NewMemoryProductRepository returns an empty MemoryProductRepository.
+func NewMemoryProductRepository() *MemoryProductRepository {
+	return &MemoryProductRepository{table: newMemoryTable(func(p models.Product) string { return p.ID })}
+}
+
+func (m *MemoryProductRepository) Create(ctx context.Context, product models.Product) error {
+	return m.table.create(product)
+}
+
+func (m *MemoryProductRepository) Get(ctx context.Context, id string) (*models.Product, error) {
+	return m.table.get(id)
+}
+
+func (m *MemoryProductRepository) Update(ctx context.Context, product models.Product) error {
+	_, err := m.Modify(ctx, product.ID, func(p *models.Product) error {
+		*p = product
+		return nil
+	})
+	return err
+}
+
+func (m *MemoryProductRepository) Modify(ctx context.Context, id string, fn func(*models.Product) error) (*models.Product, error) {
+	return m.table.modify(id, keepStock(fn))
+}
+
+func (m *MemoryProductRepository) Delete(ctx context.Context, id string) error {
+	return m.table.delete(id)
+}
+
+func (m *MemoryProductRepository) List(ctx context.Context) ([]models.Product, error) {
+	return m.table.list(nil), nil
+}
+
+func (m *MemoryProductRepository) ListByVendor(ctx context.Context, vendorID string) ([]models.Product, error) {
+	return m.table.list(func(p models.Product) bool { return p.VendorID == vendorID }), nil
+}
+
+func (m *MemoryProductRepository) Search(ctx context.Context, filter ProductFilter) ([]models.Product, error) {
+	return m.table.list(func(p models.Product) bool {
+		return (len(filter.VendorIDs) == 0 || slices.Contains(filter.VendorIDs, p.VendorID)) &&
+			(filter.Category == "" || p.Category == filter.Category) &&
+			(filter.IsMedical == nil || p.IsMedical == *filter.IsMedical)
+	}), nil
+}
+
+func (m *MemoryProductRepository) AdjustStock(ctx context.Context, deltas map[string]int) error {
+	return m.ReserveStock(ctx, deltas, nil)
+}
+
+func (m *MemoryProductRepository) ReserveStock(ctx context.Context, deltas map[string]int, prices map[string]models.Money) error {
+	m.table.mu.Lock()
+	defer m.table.mu.Unlock()
+
+	// Check every product before touching any, so a failure leaves stock unchanged.
+	for id, delta := range deltas {
+		product, ok := m.table.rows[id]
+		if !ok {
+			return ErrNotFound
+		}
+		if price, ok := prices[id]; ok && !product.PricePerUnit.Equal(price) {
+			return ErrPriceChanged
+		}
+		if product.AvailableUnits+delta < 0 {
+			return ErrInsufficientStock
+		}
+	}
+	for id, delta := range deltas {
+		product := m.table.rows[id]
+		product.AvailableUnits += delta
+		m.table.rows[id] = product
+	}
+	return nil
+}
+
+// MemoryOrderRepository is an in-memory OrderRepository. Transition returns stock
+// to products.
+type MemoryOrderRepository struct {
+	table    *memoryTable[models.Order]
+	products *MemoryProductRepository
+}
+
+// NewMemoryOrderRepository returns an empty MemoryOrderRepository whose orders
+// reserve stock in products.
+func NewMemoryOrderRepository(products *MemoryProductRepository) *MemoryOrderRepository {
+	return &MemoryOrderRepository{table: newMemoryTable(func(o models.Order) string { return o.ID }), products: products}
+}
+
+func (m *MemoryOrderRepository) Create(ctx context.Context, order models.Order) error {
+	return m.table.create(order)
+}
+
+func (m *MemoryOrderRepository) Get(ctx context.Context, id string) (*models.Order, error) {
+	return m.table.get(id)
+}
+
+func (m *MemoryOrderRepository) Update(ctx context.Context, order models.Order) error {
+	return m.table.update(order)
+}
+
+func (m *MemoryOrderRepository) Transition(ctx context.Context, order models.Order, from models.OrderStatus, restock map[string]int) error {
+	m.table.mu.Lock()
+	defer m.table.mu.Unlock()
+
+	stored, ok := m.table.rows[order.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Status != from {
+		return ErrConflict
+	}
+	m.table.rows[order.ID] = order
+
+	m.products.table.mu.Lock()
+	defer m.products.table.mu.Unlock()
+	for id, delta := range restock {
+		if product, ok := m.products.table.rows[id]; ok {
+			product.AvailableUnits += delta
+			m.products.table.rows[id] = product
+		}
+	}
+	return nil
+}
+
+func (m *MemoryOrderRepository) ListByBuyer(ctx context.Context, buyerID string) ([]models.Order, error) {
+	return m.table.list(func(o models.Order) bool { return o.BuyerID == buyerID }), nil
+}
+
+func (m *MemoryOrderRepository) ListByVendor(ctx context.Context, vendorID string) ([]models.Order, error) {
+	return m.table.list(func(o models.Order) bool { return o.VendorID == vendorID }), nil
+}
+
+func (m *MemoryOrderRepository) ListByStatus(ctx context.Context, status models.OrderStatus) ([]models.Order, error) {
+	return m.table.list(func(o models.Order) bool { return o.Status == status }), nil
+}
+
+// MemoryInvitationRepository is an in-memory InvitationRepository.
+type MemoryInvitationRepository struct {
+	table *memoryTable[models.Invitation]
+}
+
+// NewMemoryInvitationRepository returns an empty MemoryInvitationRepository.
+func NewMemoryInvitationRepository() *MemoryInvitationRepository {
+	return &MemoryInvitationRepository{table: newMemoryTable(func(i models.Invitation) string { return i.ID })}
+}
+
+func (m *MemoryInvitationRepository) Create(ctx context.Context, invitation models.Invitation) error {
+	return m.table.create(invitation)
+}
+
+func (m *MemoryInvitationRepository) Get(ctx context.Context, id string) (*models.Invitation, error) {
+	return m.table.get(id)
+}
+
+func (m *MemoryInvitationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.Invitation, error) {
+	found := m.table.list(func(i models.Invitation) bool { return i.TokenHash == tokenHash })
+	if len(found) == 0 {
+		return nil, ErrNotFound
+	}
+	return &found[0], nil
+}
+
+func (m *MemoryInvitationRepository) Update(ctx context.Context, invitation models.Invitation) error {
+	return m.table.update(invitation)
+}
+
+func (m *MemoryInvitationRepository) ListByEntity(ctx context.Context, entityID string) ([]models.Invitation, error) {
+	return m.table.list(func(i models.Invitation) bool { return i.EntityID == entityID }), nil
+}
+
+// MemoryStrainRepository is an in-memory StrainRepository.
+type MemoryStrainRepository struct {
+	table *memoryTable[models.Strain]
+}
+
+// NewMemoryStrainRepository returns an empty MemoryStrainRepository.
+func NewMemoryStrainRepository() *MemoryStrainRepository {
+	return &MemoryStrainRepository{table: newMemoryTable(func(s models.Strain) string { return s.ID })}
+}
+
+func (m *MemoryStrainRepository) Create(ctx context.Context, strain models.Strain) error {
+	return m.table.create(strain)
+}
+
+func (m *MemoryStrainRepository) Get(ctx context.Context, id string) (*models.Strain, error) {
+	return m.table.get(id)
+}
+
+func (m *MemoryStrainRepository) Update(ctx context.Context, strain models.Strain) error {
+	return m.table.update(strain)
+}
+
+func (m *MemoryStrainRepository) List(ctx context.Context) ([]models.Strain, error) {
+	return m.table.list(nil), nil
+}
+
+// MemoryVaultRepository is an in-memory VaultRepository.
+type MemoryVaultRepository struct {
+	table *memoryTable[models.Vault]
+}
+
+// NewMemoryVaultRepository returns an empty MemoryVaultRepository.
+func NewMemoryVaultRepository() *MemoryVaultRepository {
+	return &MemoryVaultRepository{table: newMemoryTable(func(v models.Vault) string { return v.BuyerID })}
+}
+
+func (m *MemoryVaultRepository) Get(ctx context.Context, buyerID string) (*models.Vault, error) {
+	return m.table.get(buyerID)
+}
+
+func (m *MemoryVaultRepository) Save(ctx context.Context, vault models.Vault) error {
+	m.table.put(vault)
+	return nil
+}
+
+// MemoryPostRepository is an in-memory PostRepository.
+type MemoryPostRepository struct {
+	table *memoryTable[models.Post]
+}
+
+// NewMemoryPostRepository returns an empty MemoryPostRepository.
+func NewMemoryPostRepository() *MemoryPostRepository {
+	return &MemoryPostRepository{table: newMemoryTable(func(p models.Post) string { return p.ID })}
+}
+
+// clonePost copies a post's slices and reactions, which memoryTable would
+// otherwise share between the stored post and every copy handed out.
+func clonePost(p models.Post) models.Post {
+	p.ProductIDs = slices.Clone(p.ProductIDs)
+	if p.Reactions != nil {
+		reactions := make(map[models.Reaction][]string, len(p.Reactions))
+		for reaction, users := range p.Reactions {
+			reactions[reaction] = slices.Clone(users)
+		}
+		p.Reactions = reactions
+	}
+	return p
+}
+
+func (m *MemoryPostRepository) Create(ctx context.Context, post models.Post) error {
+	return m.table.create(clonePost(post))
+}
+
+func (m *MemoryPostRepository) Get(ctx context.Context, id string) (*models.Post, error) {
+	post, err := m.table.get(id)
+	if err != nil {
+		return nil, err
+	}
+	cloned := clonePost(*post)
+	return &cloned, nil
+}
+
+func (m *MemoryPostRepository) Update(ctx context.Context, post models.Post) error {
+	return m.table.update(clonePost(post))
+}
+
+func (m *MemoryPostRepository) Page(ctx context.Context, filter PostFilter, limit int) ([]models.Post, error) {
+	posts := m.table.list(func(p models.Post) bool {
+		return (filter.Kind == "" || p.Kind == filter.Kind) &&
+			(filter.EntityID == "" || p.EntityID == filter.EntityID) &&
+			(filter.Before.IsZero() || p.CreatedAt.Before(filter.Before))
+	})
+	sort.Slice(posts, func(i, j int) bool {
+		if !posts[i].CreatedAt.Equal(posts[j].CreatedAt) {
+			return posts[i].CreatedAt.After(posts[j].CreatedAt)
+		}
+		return posts[i].ID > posts[j].ID
+	})
+	posts = posts[:min(limit, len(posts))]
+	for i := range posts {
+		posts[i] = clonePost(posts[i])
+	}
+	return posts, nil
+}
+
+// MemoryCommentRepository is an in-memory CommentRepository.
+type MemoryCommentRepository struct {
+	table *memoryTable[models.Comment]
+}
+
+// NewMemoryCommentRepository returns an empty MemoryCommentRepository.
+func NewMemoryCommentRepository() *MemoryCommentRepository {
+	return &MemoryCommentRepository{table: newMemoryTable(func(c models.Comment) string { return c.ID })}
+}
+
+func (m *MemoryCommentRepository) Create(ctx context.Context, comment models.Comment) error {
+	return m.table.create(comment)
+}
+
+func (m *MemoryCommentRepository) Get(ctx context.Context, id string) (*models.Comment, error) {
+	return m.table.get(id)
+}
+
+func (m *MemoryCommentRepository) Update(ctx context.Context, comment models.Comment) error {
+	return m.table.update(comment)
+}
+
+func (m *MemoryCommentRepository) ListByPost(ctx context.Context, postID string) ([]models.Comment, error) {
+	return m.table.list(func(c models.Comment) bool { return c.PostID == postID }), nil
+}
+
+// MemoryReportRepository is an in-memory ReportRepository.
+type MemoryReportRepository struct {
+	table *memoryTable[models.Report]
+}
+
+// NewMemoryReportRepository returns an empty MemoryReportRepository.
+func NewMemoryReportRepository() *MemoryReportRepository {
+	return &MemoryReportRepository{table: newMemoryTable(func(r models.Report) string { return r.ID })}
+}
+
+func (m *MemoryReportRepository) Create(ctx context.Context, report models.Report) error {
+	return m.table.create(report)
+}
+
+func (m *MemoryReportRepository) Get(ctx context.Context, id string) (*models.Report, error) {
+	return m.table.get(id)
+}
+
+func (m *MemoryReportRepository) Update(ctx context.Context, report models.Report) error {
+	return m.table.update(report)
+}
+
+func (m *MemoryReportRepository) ListByStatus(ctx context.Context, status models.ReportStatus) ([]models.Report, error) {
+	return m.table.list(func(r models.Report) bool { return r.Status == status }), nil
+}
+
+// MemoryNotificationRepository is an in-memory NotificationRepository.
+type MemoryNotificationRepository struct {
+	table *memoryTable[models.Notification]
+}
+
+// NewMemoryNotificationRepository returns an empty MemoryNotificationRepository.
+func NewMemoryNotificationRepository() *MemoryNotificationRepository {
+	return &MemoryNotificationRepository{table: newMemoryTable(func(n models.Notification) string { return n.ID })}
+}
+
+func (m *MemoryNotificationRepository) Create(ctx context.Context, notification models.Notification) error {
+	return m.table.create(notification)
+}
+
+func (m *MemoryNotificationRepository) Get(ctx context.Context, id string) (*models.Notification, error) {
+	return m.table.get(id)
+}
+
+func (m *MemoryNotificationRepository) Update(ctx context.Context, notification models.Notification) error {
+	return m.table.update(notification)
+}
+
+func (m *MemoryNotificationRepository) ListByRecipient(ctx context.Context, userID string) ([]models.Notification, error) {
+	return m.table.list(func(n models.Notification) bool { return slices.Contains(n.Recipients, userID) }), nil
+}
+
+func (m *MemoryNotificationRepository) ListByEntity(ctx context.Context, entityID string) ([]models.Notification, error) {
+	return m.table.list(func(n models.Notification) bool { return n.EntityID == entityID }), nil
+}
+
+// MemoryAuditRepository is an in-memory AuditRepository. Events are kept in the
+// order they were appended.
+type MemoryAuditRepository struct {
+	mu     sync.RWMutex
+	events []models.AuditEvent
+}
+
+// NewMemoryAuditRepository returns an empty MemoryAuditRepository.
+func NewMemoryAuditRepository() *MemoryAuditRepository {
+	return &MemoryAuditRepository{}
+}
+
+func (m *MemoryAuditRepository) Append(ctx context.Context, event models.AuditEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *MemoryAuditRepository) Query(ctx context.Context, filter AuditFilter) ([]models.AuditEvent, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := []models.AuditEvent{}
+	for _, event := range m.events {
+		if filter.Matches(event) {
+			out = append(out, event)
+			if filter.Limit > 0 && len(out) == filter.Limit {
+				break
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,443 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+)
+
+func TestMemoryUserRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryUserRepository()
+	if err := repo.Create(ctx, models.User{ID: "u1", Email: "Alice@Example.com", Status: models.StatusActive}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if err := repo.Create(ctx, models.User{ID: "u1", Email: "other@example.com"}); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("duplicate ID: error = %v, want %v", err, ErrAlreadyExists)
+	}
+	if err := repo.Create(ctx, models.User{ID: "u2", Email: "alice@EXAMPLE.com"}); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("duplicate email in another case: error = %v, want %v", err, ErrAlreadyExists)
+	}
+	if err := repo.Update(ctx, models.User{ID: "nope"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update of a missing user: error = %v, want %v", err, ErrNotFound)
+	}
+
+	stored, err := repo.GetByEmail(ctx, "ALICE@example.com")
+	if err != nil {
+		t.Fatalf("GetByEmail ignoring case: %v", err)
+	}
+	if stored.ID != "u1" {
+		t.Fatalf("GetByEmail returned %q, want u1", stored.ID)
+	}
+
+	// Changing the email frees the old one and claims the new one.
+	stored.Email = "alice@new.example.com"
+	if err := repo.Update(ctx, *stored); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if _, err := repo.GetByEmail(ctx, "alice@example.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("old email after Update: error = %v, want %v", err, ErrNotFound)
+	}
+	if got, err := repo.GetByEmail(ctx, "alice@new.example.com"); err != nil || got.ID != "u1" {
+		t.Errorf("new email after Update: %v, %v", got, err)
+	}
+	if err := repo.Create(ctx, models.User{ID: "u2", Email: "alice@example.com", Status: models.StatusPending}); err != nil {
+		t.Errorf("reusing the old email: %v", err)
+	}
+	if err := repo.Update(ctx, models.User{ID: "u2", Email: "Alice@New.example.com"}); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("Update onto a taken email: error = %v, want %v", err, ErrAlreadyExists)
+	}
+
+	if pending, _ := repo.ListByStatus(ctx, models.StatusPending); len(pending) != 1 || pending[0].ID != "u2" {
+		t.Errorf("ListByStatus(PENDING) = %v, want just u2", pending)
+	}
+	if all, _ := repo.List(ctx); len(all) != 2 {
+		t.Errorf("List returned %d users, want 2", len(all))
+	}
+}
+
+func TestMemoryUserRepositoryModifyAndDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryUserRepository()
+	if err := repo.Create(ctx, models.User{ID: "u1", Email: "alice@example.com"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Modify can't move a user off their email, which would bypass the index.
+	got, err := repo.Modify(ctx, "u1", func(u *models.User) error {
+		u.AssociatedEntityID = "v1"
+		u.Email = "mallory@example.com"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Modify: %v", err)
+	}
+	if got.AssociatedEntityID != "v1" || got.Email != "alice@example.com" {
+		t.Errorf("after Modify: entity %q, email %q; want v1, alice@example.com", got.AssociatedEntityID, got.Email)
+	}
+	errLinked := errors.New("already linked")
+	if _, err := repo.Modify(ctx, "u1", func(u *models.User) error { u.AssociatedEntityID = "v2"; return errLinked }); !errors.Is(err, errLinked) {
+		t.Errorf("failing Modify: error = %v, want %v", err, errLinked)
+	}
+	if stored, _ := repo.Get(ctx, "u1"); stored.AssociatedEntityID != "v1" {
+		t.Errorf("failing Modify stored entity %q", stored.AssociatedEntityID)
+	}
+	if _, err := repo.Modify(ctx, "nope", func(u *models.User) error { return nil }); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Modify of a missing user: error = %v, want %v", err, ErrNotFound)
+	}
+
+	// Deleting a user frees their email.
+	if err := repo.Delete(ctx, "u1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.Get(ctx, "u1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: error = %v, want %v", err, ErrNotFound)
+	}
+	if err := repo.Delete(ctx, "u1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete: error = %v, want %v", err, ErrNotFound)
+	}
+	if err := repo.Create(ctx, models.User{ID: "u2", Email: "Alice@example.com"}); err != nil {
+		t.Errorf("reusing a deleted user's email: %v", err)
+	}
+}
+
+func TestLoadAccountData(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	if err := store.Vendors.Create(ctx, models.Vendor{ID: "vendor_1", BusinessName: "Renamed Farms"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Buyers.Create(ctx, models.Buyer{ID: "buyer_1", BusinessName: "Corner Dispensary"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []models.User{
+		{ID: "user_vendor", Email: "vendor@example.com", Role: models.RoleVendor, AssociatedEntityID: "vendor_1", AccountData: models.Vendor{ID: "vendor_1", BusinessName: "Green Acres"}},
+		{ID: "user_buyer", Email: "buyer@example.com", Role: models.RoleBuyer, AssociatedEntityID: "buyer_1"},
+		{ID: "user_admin", Email: "admin@example.com", Role: models.RoleAdmin},
+	} {
+		if err := store.Users.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		userID   string
+		wantName string // "" for no AccountData
+	}{
+		{"user_vendor", "Renamed Farms"}, // the stored copy still says Green Acres
+		{"user_buyer", "Corner Dispensary"},
+		{"user_admin", ""},
+	}
+	for _, tt := range tests {
+		user, err := store.Users.Get(ctx, tt.userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := LoadAccountData(ctx, store.Vendors, store.Buyers, user); err != nil {
+			t.Fatalf("LoadAccountData(%s): %v", tt.userID, err)
+		}
+		name := ""
+		if v, ok := user.VendorAccount(); ok {
+			name = v.BusinessName
+		} else if b, ok := user.BuyerAccount(); ok {
+			name = b.BusinessName
+		}
+		if name != tt.wantName {
+			t.Errorf("%s: business = %q, want %q", tt.userID, name, tt.wantName)
+		}
+	}
+
+	// A business that no longer exists leaves AccountData nil rather than failing.
+	if err := store.Buyers.Delete(ctx, "buyer_1"); err != nil {
+		t.Fatal(err)
+	}
+	user, _ := store.Users.Get(ctx, "user_buyer")
+	if err := LoadAccountData(ctx, store.Vendors, store.Buyers, user); err != nil || user.AccountData != nil {
+		t.Errorf("LoadAccountData for a deleted buyer = %v with %#v, want nil", err, user.AccountData)
+	}
+}
+
+func TestMemoryVendorRepositoryModify(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryVendorRepository()
+	if err := repo.Create(ctx, models.Vendor{ID: "v1", BusinessName: "Green Acres"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	errStop := errors.New("stop")
+
+	tests := []struct {
+		name     string
+		id       string
+		fn       func(*models.Vendor) error
+		wantErr  error
+		wantName string
+	}{
+		{"writes the change", "v1", func(v *models.Vendor) error { v.BusinessName = "Green Acres Farm"; return nil }, nil, "Green Acres Farm"},
+		{"fn error leaves the row alone", "v1", func(v *models.Vendor) error { v.BusinessName = "Lost"; return errStop }, errStop, "Green Acres Farm"},
+		{"missing vendor", "nope", func(v *models.Vendor) error { return nil }, ErrNotFound, "Green Acres Farm"},
+	}
+	for _, tt := range tests {
+		if _, err := repo.Modify(ctx, tt.id, tt.fn); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if got, _ := repo.Get(ctx, "v1"); got.BusinessName != tt.wantName {
+			t.Errorf("%s: BusinessName = %q, want %q", tt.name, got.BusinessName, tt.wantName)
+		}
+	}
+
+	if err := repo.Delete(ctx, "v1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := repo.Delete(ctx, "v1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete: error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestMemoryProductRepositorySearch(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryProductRepository()
+	for _, p := range []models.Product{
+		{ID: "p1", VendorID: "v1", Category: "FLOWER", IsMedical: true},
+		{ID: "p2", VendorID: "v1", Category: "EDIBLE"},
+		{ID: "p3", VendorID: "v2", Category: "FLOWER"},
+	} {
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatalf("Create %s: %v", p.ID, err)
+		}
+	}
+	medical, recreational := true, false
+
+	tests := []struct {
+		name   string
+		filter ProductFilter
+		want   []string
+	}{
+		{"everything", ProductFilter{}, []string{"p1", "p2", "p3"}},
+		{"vendor", ProductFilter{VendorIDs: []string{"v1"}}, []string{"p1", "p2"}},
+		{"category", ProductFilter{Category: "FLOWER"}, []string{"p1", "p3"}},
+		{"medical", ProductFilter{IsMedical: &medical}, []string{"p1"}},
+		{"all together", ProductFilter{VendorIDs: []string{"v1", "v2"}, Category: "FLOWER", IsMedical: &recreational}, []string{"p3"}},
+	}
+	for _, tt := range tests {
+		got, err := repo.Search(ctx, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if ids := productIDs(got); !slices.Equal(ids, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, ids, tt.want)
+		}
+	}
+}
+
+func productIDs(products []models.Product) []string {
+	ids := make([]string, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+func TestMemoryProductRepositoryReserveStock(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		deltas    map[string]int
+		prices    map[string]models.Money
+		wantErr   error
+		wantUnits map[string]int
+	}{
+		{"reserve", map[string]int{"p1": -3, "p2": -1}, nil, nil, map[string]int{"p1": 7, "p2": 4}},
+		{"release", map[string]int{"p1": 2}, nil, nil, map[string]int{"p1": 12, "p2": 5}},
+		{"take the last unit", map[string]int{"p2": -5}, nil, nil, map[string]int{"p1": 10, "p2": 0}},
+		{"insufficient stock changes nothing", map[string]int{"p1": -3, "p2": -6}, nil, ErrInsufficientStock, map[string]int{"p1": 10, "p2": 5}},
+		{"missing product changes nothing", map[string]int{"p1": -3, "nope": -1}, nil, ErrNotFound, map[string]int{"p1": 10, "p2": 5}},
+		{"at the agreed price", map[string]int{"p1": -3}, map[string]models.Money{"p1": models.USDCents(1250)}, nil, map[string]int{"p1": 7, "p2": 5}},
+		{"changed price changes nothing", map[string]int{"p1": -3, "p2": -1}, map[string]models.Money{"p1": models.USDCents(1250), "p2": models.USDCents(900)}, ErrPriceChanged, map[string]int{"p1": 10, "p2": 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMemoryProductRepository()
+			for id, units := range map[string]int{"p1": 10, "p2": 5} {
+				if err := repo.Create(ctx, models.Product{ID: id, AvailableUnits: units, PricePerUnit: models.USDCents(1250)}); err != nil {
+					t.Fatalf("Create %s: %v", id, err)
+				}
+			}
+
+			if err := repo.ReserveStock(ctx, tt.deltas, tt.prices); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReserveStock error = %v, want %v", err, tt.wantErr)
+			}
+			for id, want := range tt.wantUnits {
+				if got, _ := repo.Get(ctx, id); got.AvailableUnits != want {
+					t.Errorf("%s AvailableUnits = %d, want %d", id, got.AvailableUnits, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryProductRepositoryUpdateKeepsStock(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryProductRepository()
+	if err := repo.Create(ctx, models.Product{ID: "p1", Name: "Old", AvailableUnits: 10}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	stale, _ := repo.Get(ctx, "p1")
+	if err := repo.AdjustStock(ctx, map[string]int{"p1": -4}); err != nil {
+		t.Fatalf("AdjustStock: %v", err)
+	}
+
+	stale.Name = "New"
+	if err := repo.Update(ctx, *stale); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got, _ := repo.Get(ctx, "p1"); got.Name != "New" || got.AvailableUnits != 6 {
+		t.Errorf("after a stale Update: name %q, units %d; want %q, 6", got.Name, got.AvailableUnits, "New")
+	}
+}
+
+func TestMemoryOrderRepositoryTransition(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	if err := store.Products.Create(ctx, models.Product{ID: "p1", AvailableUnits: 5}); err != nil {
+		t.Fatal(err)
+	}
+	order := models.Order{ID: "o1", Status: models.OrderStatusPending}
+	if err := store.Orders.Create(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+
+	cancelled := order
+	cancelled.Status = models.OrderStatusCancelled
+	restock := map[string]int{"p1": 3, "deleted": 2}
+	if err := store.Orders.Transition(ctx, cancelled, models.OrderStatusPending, restock); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+	// The order has already left PENDING, so a second release must change nothing.
+	if err := store.Orders.Transition(ctx, cancelled, models.OrderStatusPending, restock); !errors.Is(err, ErrConflict) {
+		t.Errorf("second Transition: error = %v, want %v", err, ErrConflict)
+	}
+	if err := store.Orders.Transition(ctx, models.Order{ID: "nope"}, models.OrderStatusPending, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing order: error = %v, want %v", err, ErrNotFound)
+	}
+
+	if got, _ := store.Orders.Get(ctx, "o1"); got.Status != models.OrderStatusCancelled {
+		t.Errorf("order status = %s, want CANCELLED", got.Status)
+	}
+	if got, _ := store.Products.Get(ctx, "p1"); got.AvailableUnits != 8 {
+		t.Errorf("units = %d, want 8", got.AvailableUnits)
+	}
+}
+
+func TestMemoryPostRepositoryPage(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryPostRepository()
+	t0 := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for _, p := range []models.Post{
+		{ID: "a", Kind: models.PostAnnouncement, EntityID: "v1", CreatedAt: t0},
+		{ID: "b", Kind: models.PostNewDrop, EntityID: "v1", CreatedAt: t0.Add(time.Hour)},
+		{ID: "c", Kind: models.PostNewDrop, EntityID: "v2", CreatedAt: t0.Add(time.Hour)},
+		{ID: "d", Kind: models.PostEvent, EntityID: "v2", CreatedAt: t0.Add(2 * time.Hour)},
+	} {
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatalf("Create %s: %v", p.ID, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter PostFilter
+		limit  int
+		want   []string
+	}{
+		{"newest first, ties by ID descending", PostFilter{}, 10, []string{"d", "c", "b", "a"}},
+		{"limit", PostFilter{}, 2, []string{"d", "c"}},
+		{"before is exclusive", PostFilter{Before: t0.Add(time.Hour)}, 10, []string{"a"}},
+		{"kind", PostFilter{Kind: models.PostNewDrop}, 10, []string{"c", "b"}},
+		{"entity", PostFilter{EntityID: "v1"}, 10, []string{"b", "a"}},
+		{"no match", PostFilter{EntityID: "v3"}, 10, []string{}},
+	}
+	for _, tt := range tests {
+		got, err := repo.Page(ctx, tt.filter, tt.limit)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		ids := make([]string, len(got))
+		for i, p := range got {
+			ids[i] = p.ID
+		}
+		if !slices.Equal(ids, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, ids, tt.want)
+		}
+	}
+}
+
+// Posts handed out by the repository must not share reactions with the stored
+// post, or a caller changing them races with every other reader.
+func TestMemoryPostRepositoryCopies(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryPostRepository()
+	post := models.Post{ID: "a", ProductIDs: []string{"p1"}, Reactions: map[models.Reaction][]string{models.ReactionLike: {"u1"}}}
+	if err := repo.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	post.Reactions[models.ReactionLike][0] = "changed"
+
+	got, _ := repo.Get(ctx, "a")
+	got.Reactions[models.ReactionFire] = []string{"u2"}
+	got.ProductIDs[0] = "changed"
+	paged, _ := repo.Page(ctx, PostFilter{}, 1)
+	paged[0].Reactions[models.ReactionLike][0] = "changed"
+
+	stored, _ := repo.Get(ctx, "a")
+	if len(stored.Reactions) != 1 || !slices.Equal(stored.Reactions[models.ReactionLike], []string{"u1"}) || !slices.Equal(stored.ProductIDs, []string{"p1"}) {
+		t.Errorf("stored post changed through a copy: %+v", stored)
+	}
+}
+
+func TestMemoryAuditRepositoryQuery(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryAuditRepository()
+	t0 := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i, e := range []models.AuditEvent{
+		{ID: "e1", ActorID: "admin", Action: models.AuditUserStatusChanged, EntityType: models.AuditEntityUser, EntityID: "u1"},
+		{ID: "e2", ActorID: "u1", Action: models.AuditProductCreated, EntityType: models.AuditEntityProduct, EntityID: "p1"},
+		{ID: "e3", ActorID: "u1", Action: models.AuditProductUpdated, EntityType: models.AuditEntityProduct, EntityID: "p1"},
+	} {
+		e.At = t0.Add(time.Duration(i) * time.Hour)
+		if err := repo.Append(ctx, e); err != nil {
+			t.Fatalf("Append %s: %v", e.ID, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter AuditFilter
+		want   []string
+	}{
+		{"everything, oldest first", AuditFilter{}, []string{"e1", "e2", "e3"}},
+		{"actor", AuditFilter{ActorID: "u1"}, []string{"e2", "e3"}},
+		{"action", AuditFilter{Action: models.AuditProductUpdated}, []string{"e3"}},
+		{"entity", AuditFilter{EntityType: models.AuditEntityProduct, EntityID: "p1"}, []string{"e2", "e3"}},
+		{"since is inclusive, until exclusive", AuditFilter{Since: t0.Add(time.Hour), Until: t0.Add(2 * time.Hour)}, []string{"e2"}},
+		{"limit", AuditFilter{Limit: 2}, []string{"e1", "e2"}},
+	}
+	for _, tt := range tests {
+		got, err := repo.Query(ctx, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		ids := make([]string, len(got))
+		for i, e := range got {
+			ids[i] = e.ID
+		}
+		if !slices.Equal(ids, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, ids, tt.want)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+)
+
+var (
+	ErrNotFound          = errors.New("record not found")
+	ErrAlreadyExists     = errors.New("record already exists")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrPriceChanged      = errors.New("price changed")
+	ErrConflict          = errors.New("record changed concurrently")
+)
+
+// UserRepository persists platform users. Email addresses are unique across all users.
+type UserRepository interface {
+	Create(ctx context.Context, user models.User) error
+	Get(ctx context.Context, id string) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	Update(ctx context.Context, user models.User) error
+	// Modify reads the user, applies fn and writes it back atomically, so a check
+	// made in fn still holds when the change is stored. Changes fn makes to Email
+	// are discarded; use Update to change an address. fn may be called more than once.
+	Modify(ctx context.Context, id string, fn func(*models.User) error) (*models.User, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]models.User, error)
+	ListByStatus(ctx context.Context, status models.AccountStatus) ([]models.User, error)
+}
+
+// VendorRepository persists licensed selling businesses.
+type VendorRepository interface {
+	Create(ctx context.Context, vendor models.Vendor) error
+	Get(ctx context.Context, id string) (*models.Vendor, error)
+	Update(ctx context.Context, vendor models.Vendor) error
+	// Modify reads the vendor, applies fn and writes it back atomically, so
+	// writers changing different fields don't overwrite each other. fn may be
+	// called more than once.
+	Modify(ctx context.Context, id string, fn func(*models.Vendor) error) (*models.Vendor, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]models.Vendor, error)
+}
+
+// BuyerRepository persists licensed purchasing businesses.
+type BuyerRepository interface {
+	Create(ctx context.Context, buyer models.Buyer) error
+	Get(ctx context.Context, id string) (*models.Buyer, error)
+	Update(ctx context.Context, buyer models.Buyer) error
+	// Modify is VendorRepository.Modify for buyers.
+	Modify(ctx context.Context, id string, fn func(*models.Buyer) error) (*models.Buyer, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]models.Buyer, error)
+}
+
+// LoadAccountData refreshes user.AccountData from the Vendor or Buyer its
+// AssociatedEntityID names, as stored right now, since the copy saved with the
+// user goes stale as the business changes. It is left nil for users without a
+// business, or whose business no longer exists.
+func LoadAccountData(ctx context.Context, vendors VendorRepository, buyers BuyerRepository, user *models.User) error {
+	user.AccountData = nil
+	if user.AssociatedEntityID == "" {
+		return nil
+	}
+	var err error
+	switch user.Role {
+	case models.RoleVendor:
+		var vendor *models.Vendor
+		if vendor, err = vendors.Get(ctx, user.AssociatedEntityID); err == nil {
+			user.AccountData = *vendor
+		}
+	case models.RoleBuyer:
+		var buyer *models.Buyer
+		if buyer, err = buyers.Get(ctx, user.AssociatedEntityID); err == nil {
+			user.AccountData = *buyer
+		}
+	}
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// ProductFilter narrows a product search. Empty fields match everything.
+type ProductFilter struct {
+	VendorIDs []string // any of these vendors
+	Category  string   // exact; categories are stored canonical, see services.CanonicalCategory
+	IsMedical *bool
+}
+
+// ProductRepository persists vendor SKUs.
+type ProductRepository interface {
+	Create(ctx context.Context, product models.Product) error
+	Get(ctx context.Context, id string) (*models.Product, error)
+	// Update replaces every field but AvailableUnits, which only AdjustStock
+	// changes, so an edit can't undo stock reserved since the product was read.
+	Update(ctx context.Context, product models.Product) error
+	// Modify reads the product, applies fn and writes it back atomically, so edits
+	// to different fields don't overwrite each other. As with Update, changes fn
+	// makes to AvailableUnits are discarded. fn may be called more than once.
+	Modify(ctx context.Context, id string, fn func(*models.Product) error) (*models.Product, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]models.Product, error)
+	ListByVendor(ctx context.Context, vendorID string) ([]models.Product, error)
+	// Search returns the products matching filter, ordered by ID.
+	Search(ctx context.Context, filter ProductFilter) ([]models.Product, error)
+	// AdjustStock atomically adds each delta to the product's AvailableUnits. If any
+	// product is missing or would go negative, nothing is changed and the error is
+	// ErrNotFound or ErrInsufficientStock.
+	AdjustStock(ctx context.Context, deltas map[string]int) error
+	// ReserveStock is AdjustStock for an order: it also fails with ErrPriceChanged,
+	// changing nothing, if any product's PricePerUnit differs from its entry in
+	// prices, so an order is never charged a price other than the one it was priced at.
+	ReserveStock(ctx context.Context, deltas map[string]int, prices map[string]models.Money) error
+}
+
+// keepEmail wraps a user modification so it leaves Email as stored.
+func keepEmail(fn func(*models.User) error) func(*models.User) error {
+	return func(u *models.User) error {
+		email := u.Email
+		if err := fn(u); err != nil {
+			return err
+		}
+		u.Email = email
+		return nil
+	}
+}
+
+// keepStock wraps a product modification so it leaves AvailableUnits as stored.
+func keepStock(fn func(*models.Product) error) func(*models.Product) error {
+	return func(p *models.Product) error {
+		units := p.AvailableUnits
+		if err := fn(p); err != nil {
+			return err
+		}
+		p.AvailableUnits = units
+		return nil
+	}
+}
+
+// OrderRepository persists B2B orders.
+type OrderRepository interface {
+	Create(ctx context.Context, order models.Order) error
+	Get(ctx context.Context, id string) (*models.Order, error)
+	Update(ctx context.Context, order models.Order) error
+	// Transition stores order if the stored copy still has status from, and in the
+	// same transaction adds each entry of restock to that product's AvailableUnits,
+	// skipping products deleted since. If the order has moved on it fails with
+	// ErrConflict and changes nothing, so two callers can't both release its stock.
+	Transition(ctx context.Context, order models.Order, from models.OrderStatus, restock map[string]int) error
+	ListByBuyer(ctx context.Context, buyerID string) ([]models.Order, error)
+	ListByVendor(ctx context.Context, vendorID string) ([]models.Order, error)
+	ListByStatus(ctx context.Context, status models.OrderStatus) ([]models.Order, error)
+}
+
+// InvitationRepository persists invitations for users to join a business.
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation models.Invitation) error
+	Get(ctx context.Context, id string) (*models.Invitation, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.Invitation, error)
+	Update(ctx context.Context, invitation models.Invitation) error
+	ListByEntity(ctx context.Context, entityID string) ([]models.Invitation, error)
+}
+
+// StrainRepository persists the strain reference database.
+type StrainRepository interface {
+	Create(ctx context.Context, strain models.Strain) error
+	Get(ctx context.Context, id string) (*models.Strain, error)
+	Update(ctx context.Context, strain models.Strain) error
+	List(ctx context.Context) ([]models.Strain, error)
+}
+
+// VaultRepository persists buyers' vaults, one per buyer business.
+type VaultRepository interface {
+	// Get returns ErrNotFound for a buyer that has never saved anything.
+	Get(ctx context.Context, buyerID string) (*models.Vault, error)
+	// Save creates or replaces the buyer's vault.
+	Save(ctx context.Context, vault models.Vault) error
+}
+
+// PostRepository persists community feed posts.
+type PostRepository interface {
+	Create(ctx context.Context, post models.Post) error
+	Get(ctx context.Context, id string) (*models.Post, error)
+	Update(ctx context.Context, post models.Post) error
+	// Page returns up to limit posts matching filter, newest first (ties by ID,
+	// descending).
+	Page(ctx context.Context, filter PostFilter, limit int) ([]models.Post, error)
+}
+
+// PostFilter narrows a page of posts. Empty fields match everything.
+type PostFilter struct {
+	Kind     models.PostKind
+	EntityID string
+	Before   time.Time // only posts created strictly before this
+}
+
+// CommentRepository persists comments on community posts.
+type CommentRepository interface {
+	Create(ctx context.Context, comment models.Comment) error
+	Get(ctx context.Context, id string) (*models.Comment, error)
+	Update(ctx context.Context, comment models.Comment) error
+	ListByPost(ctx context.Context, postID string) ([]models.Comment, error)
+}
+
+// ReportRepository persists users' reports of community content.
+type ReportRepository interface {
+	Create(ctx context.Context, report models.Report) error
+	Get(ctx context.Context, id string) (*models.Report, error)
+	Update(ctx context.Context, report models.Report) error
+	ListByStatus(ctx context.Context, status models.ReportStatus) ([]models.Report, error)
+}
+
+// NotificationRepository persists license notifications.
+type NotificationRepository interface {
+	Create(ctx context.Context, notification models.Notification) error
+	Get(ctx context.Context, id string) (*models.Notification, error)
+	Update(ctx context.Context, notification models.Notification) error
+	ListByRecipient(ctx context.Context, userID string) ([]models.Notification, error)
+	ListByEntity(ctx context.Context, entityID string) ([]models.Notification, error)
+}
+
+// AuditFilter narrows an audit log query. Empty fields match everything; a zero
+// Limit returns every match.
+type AuditFilter struct {
+	ActorID    string
+	Action     models.AuditAction
+	EntityType models.AuditEntityType
+	EntityID   string
+	Since      time.Time // inclusive
+	Until      time.Time // exclusive
+	Limit      int
+}
+
+// Matches reports whether event passes the filter (ignoring Limit).
+func (f AuditFilter) Matches(event models.AuditEvent) bool {
+	return (f.ActorID == "" || event.ActorID == f.ActorID) &&
+		(f.Action == "" || event.Action == f.Action) &&
+		(f.EntityType == "" || event.EntityType == f.EntityType) &&
+		(f.EntityID == "" || event.EntityID == f.EntityID) &&
+		(f.Since.IsZero() || !event.At.Before(f.Since)) &&
+		(f.Until.IsZero() || event.At.Before(f.Until))
+}
+
+// AuditRepository persists the audit log. It is append-only: there is no way to
+// change or remove an event once written.
+type AuditRepository interface {
+	Append(ctx context.Context, event models.AuditEvent) error
+	// Query returns matching events oldest first.
+	Query(ctx context.Context, filter AuditFilter) ([]models.AuditEvent, error)
+}
+
+// Store bundles one repository per model so it can be handed to main as a unit.
+type Store struct {
+	Users         UserRepository
+	Vendors       VendorRepository
+	Buyers        BuyerRepository
+	Products      ProductRepository
+	Orders        OrderRepository
+	Invitations   InvitationRepository
+	Strains       StrainRepository
+	Vaults        VaultRepository
+	Posts         PostRepository
+	Comments      CommentRepository
+	Reports       ReportRepository
+	Notifications NotificationRepository
+	Audit         AuditRepository
+}
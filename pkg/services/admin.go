@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/utils"
+)
+
+var (
+	ErrAdminExists              = errors.New("an admin account already exists")
+	ErrInvalidBootstrapToken    = errors.New("invalid bootstrap token")
+	ErrInvalidAccount           = errors.New("invalid account request")
+	ErrUserNotFound             = errors.New("user not found")
+	ErrIllegalAccountTransition = errors.New("illegal account status transition")
+	ErrBusinessNotFound         = errors.New("business not found")
+)
+
+// accountTransitions lists, for each account status, the statuses an admin may
+// move it to. New vendor and buyer accounts wait in PENDING_APPROVAL until they
+// are approved or rejected; active accounts can be suspended and reinstated.
+var accountTransitions = map[models.AccountStatus][]models.AccountStatus{
+	models.StatusPending:   {models.StatusActive, models.StatusRejected, models.StatusSuspended},
+	models.StatusActive:    {models.StatusSuspended},
+	models.StatusSuspended: {models.StatusActive},
+}
+
+// AccountReview is one entry in the admin review queue: a pending account and,
+// if they have registered it yet, the business they belong to.
+type AccountReview struct {
+	User     models.User          `json:"user"`
+	Business models.AccountEntity `json:"business,omitempty"`
+}
+
+// AdminService provisions admin accounts and runs the account review workflow.
+type AdminService struct {
+	users          repository.UserRepository
+	vendors        repository.VendorRepository
+	buyers         repository.BuyerRepository
+	invitations    repository.InvitationRepository
+	audit          *AuditLog
+	gate           *ComplianceGate
+	bootstrapToken string
+	invitationTTL  time.Duration
+
+	// mu serializes bootstrap and status changes so two requests can't both
+	// create the first admin or both act on the same pending account.
+	mu sync.Mutex
+}
+
+// NewAdminService returns an AdminService that records account changes to audit
+// and clears gate's cached check when it suspends or reinstates a business.
+// bootstrapToken must be presented to create the first admin; admin invitations
+// expire after invitationTTL.
+func NewAdminService(store *repository.Store, audit *AuditLog, gate *ComplianceGate, bootstrapToken string, invitationTTL time.Duration) *AdminService {
+	return &AdminService{
+		audit:          audit,
+		gate:           gate,
+		users:          store.Users,
+		vendors:        store.Vendors,
+		buyers:         store.Buyers,
+		invitations:    store.Invitations,
+		bootstrapToken: bootstrapToken,
+		invitationTTL:  invitationTTL,
+	}
+}
+
+// Bootstrap creates the platform's first admin. It only works while no admin
+// exists, and only for a caller holding the deployment's bootstrap token.
+func (s *AdminService) Bootstrap(ctx context.Context, token, email, password string, fullName []string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.bootstrapToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.bootstrapToken)) != 1 {
+		return nil, ErrInvalidBootstrapToken
+	}
+	users, err := s.users.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if slices.ContainsFunc(users, func(u models.User) bool { return u.Role == models.RoleAdmin }) {
+		return nil, ErrAdminExists
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" || !strings.Contains(email, "@") {
+		return nil, fmt.Errorf("%w: a valid email address is required", ErrInvalidAccount)
+	}
+	hash, err := HashPassword(password)
+	if errors.Is(err, ErrPasswordTooShort) || errors.Is(err, ErrPasswordTooLong) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAccount, err)
+	} else if err != nil {
+		return nil, err
+	}
+
+	admin := models.User{
+		ID:           utils.NewID("user"),
+		FullName:     fullName,
+		Email:        email,
+		PasswordHash: hash,
+		Role:         models.RoleAdmin,
+		Status:       models.StatusActive,
+		CreatedAt:    time.Now(),
+	}
+	if err := s.users.Create(ctx, admin); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, UserEvent(&admin, models.AuditUserCreated, models.AuditEntityUser, admin.ID), nil, admin)
+	return &admin, nil
+}
+
+// InviteAdmin invites email to become an admin, returning the invitation and the
+// raw token to send them. Admin invitations belong to no business, so their
+// EntityID is empty, and they can only be redeemed by a new account.
+func (s *AdminService) InviteAdmin(ctx context.Context, actor *models.User, email string) (*models.Invitation, string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" || !strings.Contains(email, "@") {
+		return nil, "", fmt.Errorf("%w: a valid email address is required", ErrInvalidAccount)
+	}
+	if _, err := s.users.GetByEmail(ctx, email); err == nil {
+		return nil, "", fmt.Errorf("%w: %s already has an account", ErrInvalidAccount, email)
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, "", err
+	}
+
+	return issueInvitation(ctx, s.invitations, models.Invitation{
+		EntityRole: models.RoleAdmin,
+		Email:      email,
+		InvitedBy:  actor.ID,
+	}, s.invitationTTL)
+}
+
+// ReviewQueue returns every vendor and buyer account awaiting approval, oldest first.
+func (s *AdminService) ReviewQueue(ctx context.Context) ([]AccountReview, error) {
+	pending, err := s.users.ListByStatus(ctx, models.StatusPending)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(pending, func(a, b models.User) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	queue := make([]AccountReview, 0, len(pending))
+	for _, user := range pending {
+		if err := repository.LoadAccountData(ctx, s.vendors, s.buyers, &user); err != nil {
+			return nil, err
+		}
+		queue = append(queue, AccountReview{User: user, Business: user.AccountData})
+	}
+	return queue, nil
+}
+
+// SetStatus records an admin's review decision on a user account: approving
+// (ACTIVE), rejecting (REJECTED) or suspending (SUSPENDED) it. Rejections and
+// suspensions must give a reason. Admins cannot change their own status.
+func (s *AdminService) SetStatus(ctx context.Context, actor *models.User, userID string, to models.AccountStatus, reason string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if userID == actor.ID {
+		return nil, fmt.Errorf("%w: you cannot change your own account status", ErrIllegalAccountTransition)
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" && (to == models.StatusRejected || to == models.StatusSuspended) {
+		return nil, fmt.Errorf("%w: a reason is required to move an account to %s", ErrInvalidAccount, to)
+	}
+
+	user, err := s.users.Get(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(accountTransitions[user.Status], to) {
+		return nil, fmt.Errorf("%w: %s cannot move to %s", ErrIllegalAccountTransition, user.Status, to)
+	}
+
+	before := *user
+	user.StatusHistory = append(user.StatusHistory, models.AccountStatusChange{
+		From:    user.Status,
+		To:      to,
+		ActorID: actor.ID,
+		Reason:  reason,
+		At:      time.Now(),
+	})
+	user.Status = to
+	user.StatusReason = reason
+	if err := s.users.Update(ctx, *user); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, UserEvent(actor, models.AuditUserStatusChanged, models.AuditEntityUser, user.ID), before, user)
+	return user, nil
+}
+
+// SetBusinessStatus suspends (SUSPENDED, with a reason) or reinstates (ACTIVE)
+// a vendor or buyer business. A suspended business fails the compliance gate
+// whatever its license says; the gate's cached result for it is dropped so the
+// change applies to the next request.
+func (s *AdminService) SetBusinessStatus(ctx context.Context, actor *models.User, entityID string, to models.AccountStatus, reason string) (models.AccountEntity, error) {
+	reason = strings.TrimSpace(reason)
+	switch {
+	case to != models.StatusActive && to != models.StatusSuspended:
+		return nil, fmt.Errorf("%w: a business can only be moved to %s or %s", ErrInvalidAccount, models.StatusActive, models.StatusSuspended)
+	case to == models.StatusSuspended && reason == "":
+		return nil, fmt.Errorf("%w: a reason is required to suspend a business", ErrInvalidAccount)
+	}
+
+	// --- STEP 1: Write only the status, to whichever collection holds the business ---
+	var (
+		from       models.AccountStatus
+		business   models.AccountEntity
+		entityType models.AccountEntityType
+		auditType  models.AuditEntityType
+	)
+	vendor, err := s.vendors.Modify(ctx, entityID, func(v *models.Vendor) error {
+		from, v.Status = v.Status, to
+		return nil
+	})
+	if err == nil {
+		business, entityType, auditType = *vendor, models.AccountEntityVendor, models.AuditEntityVendor
+	} else if errors.Is(err, repository.ErrNotFound) {
+		var buyer *models.Buyer
+		buyer, err = s.buyers.Modify(ctx, entityID, func(b *models.Buyer) error {
+			from, b.Status = b.Status, to
+			return nil
+		})
+		if err == nil {
+			business, entityType, auditType = *buyer, models.AccountEntityBuyer, models.AuditEntityBuyer
+		}
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrBusinessNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	// --- STEP 2: Drop the gate's cached result and record the decision ---
+	if s.gate != nil {
+		s.gate.Invalidate(entityType, entityID)
+	}
+	s.audit.Record(ctx, UserEvent(actor, models.AuditBusinessStatusChanged, auditType, entityID),
+		map[string]any{"status": from},
+		map[string]any{"status": to, "reason": reason})
+	return business, nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+func TestAdminBootstrap(t *testing.T) {
+	ctx := context.Background()
+	admins := NewAdminService(repository.NewMemoryStore(), nil, nil, "bootstrap-secret", time.Hour)
+
+	if _, err := admins.Bootstrap(ctx, "wrong", "root@example.com", "password1", nil); !errors.Is(err, ErrInvalidBootstrapToken) {
+		t.Errorf("wrong token: error = %v, want %v", err, ErrInvalidBootstrapToken)
+	}
+	admin, err := admins.Bootstrap(ctx, "bootstrap-secret", " Root@Example.com ", "password1", nil)
+	if err != nil {
+		t.Fatalf("Bootstrap: %v", err)
+	}
+	if admin.Role != models.RoleAdmin || admin.Status != models.StatusActive || admin.Email != "root@example.com" {
+		t.Errorf("bootstrapped admin = %+v", admin)
+	}
+	if _, err := admins.Bootstrap(ctx, "bootstrap-secret", "second@example.com", "password1", nil); !errors.Is(err, ErrAdminExists) {
+		t.Errorf("second bootstrap: error = %v, want %v", err, ErrAdminExists)
+	}
+}
+
+func TestAdminReviewFlow(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	admins := NewAdminService(store, NewAuditLog(store.Audit), nil, "", time.Hour)
+	admin := &models.User{ID: "admin", Role: models.RoleAdmin, Status: models.StatusActive}
+
+	if err := store.Vendors.Create(ctx, models.Vendor{ID: "vendor_1", BusinessName: "Green Acres"}); err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i, u := range []models.User{
+		{ID: "newer", Email: "newer@example.com", Role: models.RoleBuyer},
+		{ID: "older", Email: "older@example.com", Role: models.RoleVendor, AssociatedEntityID: "vendor_1"},
+	} {
+		u.Status = models.StatusPending
+		u.CreatedAt = t0.Add(time.Duration(-i) * time.Hour)
+		if err := store.Users.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	queue, err := admins.ReviewQueue(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queue) != 2 || queue[0].User.ID != "older" || queue[1].User.ID != "newer" {
+		t.Fatalf("queue = %+v, want older then newer", queue)
+	}
+	if v, ok := queue[0].Business.(models.Vendor); !ok || v.BusinessName != "Green Acres" {
+		t.Errorf("older's business = %#v, want Green Acres", queue[0].Business)
+	}
+	if queue[1].Business != nil {
+		t.Errorf("newer has no business yet, got %#v", queue[1].Business)
+	}
+
+	steps := []struct {
+		name    string
+		userID  string
+		to      models.AccountStatus
+		reason  string
+		wantErr error
+	}{
+		{"approve", "older", models.StatusActive, "", nil},
+		{"can't approve twice", "older", models.StatusActive, "", ErrIllegalAccountTransition},
+		{"suspending needs a reason", "older", models.StatusSuspended, " ", ErrInvalidAccount},
+		{"suspend", "older", models.StatusSuspended, "unpaid invoices", nil},
+		{"reinstate", "older", models.StatusActive, "paid up", nil},
+		{"rejecting needs a reason", "newer", models.StatusRejected, "", ErrInvalidAccount},
+		{"reject", "newer", models.StatusRejected, "license doesn't match", nil},
+		{"rejected is final", "newer", models.StatusActive, "", ErrIllegalAccountTransition},
+		{"not your own account", "admin", models.StatusSuspended, "oops", ErrIllegalAccountTransition},
+		{"unknown user", "nope", models.StatusActive, "", ErrUserNotFound},
+	}
+	for _, step := range steps {
+		got, err := admins.SetStatus(ctx, admin, step.userID, step.to, step.reason)
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: error = %v, want %v", step.name, err, step.wantErr)
+		}
+		if err == nil && got.Status != step.to {
+			t.Fatalf("%s: status = %s, want %s", step.name, got.Status, step.to)
+		}
+	}
+
+	older, _ := store.Users.Get(ctx, "older")
+	if len(older.StatusHistory) != 3 || older.StatusReason != "paid up" {
+		t.Errorf("older: %d status changes, reason %q; want 3, %q", len(older.StatusHistory), older.StatusReason, "paid up")
+	}
+	events, _ := store.Audit.Query(ctx, repository.AuditFilter{Action: models.AuditUserStatusChanged})
+	if len(events) != 4 {
+		t.Errorf("audited %d status changes, want 4", len(events))
+	}
+	if queue, _ := admins.ReviewQueue(ctx); len(queue) != 0 {
+		t.Errorf("queue after review = %+v, want empty", queue)
+	}
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/utils"
+)
+
+type requestMetadataKey struct{}
+
+// WithRequestMetadata returns a copy of ctx carrying meta, so audit events recorded
+// while handling the request can say where it came from.
+func WithRequestMetadata(ctx context.Context, meta models.RequestMetadata) context.Context {
+	return context.WithValue(ctx, requestMetadataKey{}, meta)
+}
+
+// RequestMetadataFromContext returns the request metadata stored by WithRequestMetadata.
+func RequestMetadataFromContext(ctx context.Context) (models.RequestMetadata, bool) {
+	meta, ok := ctx.Value(requestMetadataKey{}).(models.RequestMetadata)
+	return meta, ok
+}
+
+// LicenseCheckResult is the audit snapshot of one license verification.
+type LicenseCheckResult struct {
+	EntityID         string                         `json:"entityID,omitempty"`
+	ComplianceStatus models.AccountComplianceStatus `json:"complianceStatus,omitempty"`
+	ExpirationDate   time.Time                      `json:"expirationDate,omitzero"`
+	Error            string                         `json:"error,omitempty"`
+}
+
+// AuditLog records compliance-relevant actions. A nil *AuditLog records nothing.
+type AuditLog struct {
+	events repository.AuditRepository
+}
+
+// NewAuditLog returns an AuditLog that appends to events.
+func NewAuditLog(events repository.AuditRepository) *AuditLog {
+	return &AuditLog{events: events}
+}
+
+// UserEvent starts an audit event for an action taken by user.
+func UserEvent(user *models.User, action models.AuditAction, entityType models.AuditEntityType, entityID string) models.AuditEvent {
+	return models.AuditEvent{ActorID: user.ID, ActorRole: user.Role, Action: action, EntityType: entityType, EntityID: entityID}
+}
+
+// SystemEvent starts an audit event for an action the platform takes on its own.
+func SystemEvent(action models.AuditAction, entityType models.AuditEntityType, entityID string) models.AuditEvent {
+	return models.AuditEvent{ActorID: SystemActorID, Action: action, EntityType: entityType, EntityID: entityID}
+}
+
+// Record stamps event with an ID, the time and the request in ctx, snapshots
+// before and after as JSON (either may be nil) and appends it. The action being
+// audited has already happened, so a failure to record is logged rather than
+// returned.
+func (a *AuditLog) Record(ctx context.Context, event models.AuditEvent, before, after any) {
+	if a == nil {
+		return
+	}
+
+	event.ID = utils.NewID("audit")
+	event.At = time.Now()
+	if meta, ok := RequestMetadataFromContext(ctx); ok {
+		event.Request = &meta
+	}
+	var err error
+	if event.Before, err = snapshot(before); err != nil {
+		log.Printf("AUDIT: could not snapshot %s %s before %s: %v", event.EntityType, event.EntityID, event.Action, err)
+	}
+	if event.After, err = snapshot(after); err != nil {
+		log.Printf("AUDIT: could not snapshot %s %s after %s: %v", event.EntityType, event.EntityID, event.Action, err)
+	}
+
+	if err := a.events.Append(ctx, event); err != nil {
+		log.Printf("AUDIT: failed to record %s on %s %s by %s: %v", event.Action, event.EntityType, event.EntityID, event.ActorID, err)
+	}
+}
+
+// Query returns the audit events matching filter, oldest first.
+func (a *AuditLog) Query(ctx context.Context, filter repository.AuditFilter) ([]models.AuditEvent, error) {
+	return a.events.Query(ctx, filter)
+}
+
+func snapshot(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
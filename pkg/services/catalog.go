@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+)
+
+var (
+	ErrProductNameRequired     = errors.New("product name is required")
+	ErrProductNegativePrice    = errors.New("pricePerUnit cannot be negative")
+	ErrProductCurrency         = errors.New("pricePerUnit must be in USD")
+	ErrProductNegativeUnits    = errors.New("availableUnits cannot be negative")
+	ErrProductMinQuantity      = errors.New("minOrderQuantity must be at least 1")
+	ErrProductQuantityRange    = errors.New("maxOrderQuantity cannot be less than minOrderQuantity")
+	ErrProductCoaRequired      = errors.New("medical products require a coaLink")
+	ErrProductUnverifiedTest   = errors.New("testStatus PASSED or FAILED is set from an uploaded COA")
+	ErrProductCategoryRequired = errors.New("product category is required")
+	ErrProductNotFound         = errors.New("product not found")
+	ErrInvalidProduct          = errors.New("invalid product")
+)
+
+// CanonicalCategory is the form categories are stored and searched in, so
+// "Flower", "flower " and "FLOWER" are one category.
+func CanonicalCategory(category string) string {
+	return strings.ToUpper(strings.TrimSpace(category))
+}
+
+// hasUnverifiedTestResult reports whether p claims a PASSED or FAILED test
+// without a COA to back it. Only ApplyLabResults may set those.
+func hasUnverifiedTestResult(p models.Product) bool {
+	return p.COA == nil && (p.Compliance.TestStatus == models.TestPassed || p.Compliance.TestStatus == models.TestFailed)
+}
+
+// maxPriceHistory is how many price changes are kept per product.
+const maxPriceHistory = 100
+
+// RecordPrice appends p's current price to its history if it differs from the
+// last recorded one. Only the latest maxPriceHistory points are kept.
+func RecordPrice(p *models.Product, at time.Time) {
+	if n := len(p.PriceHistory); n > 0 && p.PriceHistory[n-1].Price.Equal(p.PricePerUnit) {
+		return
+	}
+	p.PriceHistory = append(p.PriceHistory, models.PricePoint{Price: p.PricePerUnit, At: at})
+	if len(p.PriceHistory) > maxPriceHistory {
+		p.PriceHistory = p.PriceHistory[len(p.PriceHistory)-maxPriceHistory:]
+	}
+}
+
+// ValidateProduct checks a product's fields before it is written to the catalog.
+// A MaxOrderQuantity of 0 means no per-order limit. Errors wrap ErrInvalidProduct
+// as well as the specific problem.
+func ValidateProduct(p models.Product) error {
+	if err := validateProduct(p); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidProduct, err)
+	}
+	return nil
+}
+
+func validateProduct(p models.Product) error {
+	switch {
+	case strings.TrimSpace(p.Name) == "":
+		return ErrProductNameRequired
+	case strings.TrimSpace(p.Category) == "":
+		return ErrProductCategoryRequired
+	case p.PricePerUnit.IsNegative():
+		return ErrProductNegativePrice
+	case p.PricePerUnit.Currency != "" && p.PricePerUnit.Currency != models.USD:
+		return ErrProductCurrency
+	case p.AvailableUnits < 0:
+		return ErrProductNegativeUnits
+	case p.MinOrderQuantity < 1:
+		return ErrProductMinQuantity
+	case p.MaxOrderQuantity != 0 && p.MaxOrderQuantity < p.MinOrderQuantity:
+		return ErrProductQuantityRange
+	case p.IsMedical && strings.TrimSpace(p.CoaLink) == "":
+		return ErrProductCoaRequired
+	case hasUnverifiedTestResult(p):
+		return ErrProductUnverifiedTest
+	}
+	return ValidateCompliance(p.Compliance)
+}
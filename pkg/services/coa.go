@@ -0,0 +1,192 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/blob"
+	"github.com/wesleywinston/wds/pkg/coa"
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+var (
+	ErrInvalidCOA  = errors.New("invalid certificate of analysis")
+	ErrCOAMismatch = errors.New("certificate of analysis does not match")
+	ErrCOAFailed   = errors.New("certificate of analysis reports a failed safety test")
+	ErrCOANotFound = errors.New("certificate of analysis not found")
+)
+
+// COAService stores vendors' certificates of analysis and the lab results parsed
+// from them. The original file goes to the blob store; the results are saved on
+// the product so buyers see what the lab measured rather than what the vendor typed.
+type COAService struct {
+	products repository.ProductRepository
+	vendors  repository.VendorRepository
+	files    blob.Store
+	audit    *AuditLog
+}
+
+// NewCOAService returns a COAService that keeps uploaded files in files.
+func NewCOAService(store *repository.Store, files blob.Store, audit *AuditLog) *COAService {
+	return &COAService{
+		products: store.Products,
+		vendors:  store.Vendors,
+		files:    files,
+		audit:    audit,
+	}
+}
+
+// COALink is the download path for a product's certificate of analysis.
+func COALink(productID string) string {
+	return "/products/" + productID + "/coa"
+}
+
+// Upload parses a COA for one of the caller's products and attaches it. labID and
+// batchNumber are what the vendor says the COA is for; they must match what the
+// lab printed and any lab and batch already recorded on the product, so a COA for
+// one batch can't be passed off as another's. To list a new batch, the vendor
+// changes the product's batch number first. COAs with
+// a failed contaminant screen are rejected, since the batch can't legally be sold.
+func (s *COAService) Upload(ctx context.Context, user *models.User, productID, fileName string, data []byte, labID, batchNumber string) (*models.Product, error) {
+	product, err := s.products.Get(ctx, productID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && product.VendorID != user.AssociatedEntityID) {
+		return nil, ErrProductNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	labID, batchNumber = strings.TrimSpace(labID), strings.TrimSpace(batchNumber)
+	if labID == "" || batchNumber == "" {
+		return nil, fmt.Errorf("%w: labID and batchNumber are required", ErrInvalidCOA)
+	}
+
+	// --- STEP 1: Parse the lab results ---
+	contentType, err := coa.DetectContentType(data, fileName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCOA, err)
+	}
+	results, err := coa.Parse(data, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCOA, err)
+	}
+
+	// --- STEP 2: Check the COA is for this batch and passed ---
+	if err := matchesRecordedBatch(product.Compliance, labID, batchNumber); err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(results.LabID, labID) {
+		return nil, fmt.Errorf("%w: the COA was issued by lab %s, not %s", ErrCOAMismatch, results.LabID, labID)
+	}
+	if !strings.EqualFold(results.BatchNumber, batchNumber) {
+		return nil, fmt.Errorf("%w: the COA is for batch %s, not %s", ErrCOAMismatch, results.BatchNumber, batchNumber)
+	}
+	if !results.Passed {
+		var failed []string
+		for _, c := range results.Contaminants {
+			if !c.Passed {
+				failed = append(failed, c.Name)
+			}
+		}
+		return nil, fmt.Errorf("%w: %s", ErrCOAFailed, strings.Join(failed, ", "))
+	}
+
+	// --- STEP 3: Store the original file ---
+	// Files are keyed by content hash, so earlier COAs stay in the blob store and
+	// the audit log can always point at the file that was current at the time.
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	ext := ".csv"
+	if contentType == coa.ContentTypePDF {
+		ext = ".pdf"
+	}
+	key := fmt.Sprintf("coa/%s/%s/%s%s", product.VendorID, product.ID, digest, ext)
+	if err := s.files.Put(ctx, key, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	// --- STEP 4: Attach the results to the product ---
+	// Modify only touches the COA fields, so stock reserved or edits saved while
+	// the file was being parsed are kept.
+	var before models.Product
+	updated, err := s.products.Modify(ctx, product.ID, func(p *models.Product) error {
+		// The vendor may have changed the batch while the file was being stored.
+		if err := matchesRecordedBatch(p.Compliance, labID, batchNumber); err != nil {
+			return err
+		}
+		before = *p
+		p.COA = &models.COADocument{
+			BlobKey:     key,
+			FileName:    filepath.Base(fileName),
+			ContentType: contentType,
+			Size:        int64(len(data)),
+			SHA256:      digest,
+			UploadedBy:  user.ID,
+			UploadedAt:  time.Now(),
+			Results:     *results,
+		}
+		p.CoaLink = COALink(p.ID)
+		p.Compliance.ApplyLabResults(*results)
+		p.UpdatedAt = time.Now()
+		return nil
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrProductNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, UserEvent(user, models.AuditCOAUploaded, models.AuditEntityProduct, updated.ID), before, updated)
+	return updated, nil
+}
+
+// matchesRecordedBatch checks labID and batchNumber against the lab and batch
+// recorded on the product, if any.
+func matchesRecordedBatch(c models.ComplianceAttributes, labID, batchNumber string) error {
+	if c.LabID != "" && !strings.EqualFold(c.LabID, labID) {
+		return fmt.Errorf("%w: the product is recorded as tested by lab %s, not %s", ErrCOAMismatch, c.LabID, labID)
+	}
+	if c.BatchNumber != "" && !strings.EqualFold(c.BatchNumber, batchNumber) {
+		return fmt.Errorf("%w: the product is recorded as batch %s, not %s", ErrCOAMismatch, c.BatchNumber, batchNumber)
+	}
+	return nil
+}
+
+// Open returns a product's COA file for download. Anyone who can see the product
+// can read its COA: the owning vendor, admins, and everyone once the vendor's
+// menu is live.
+func (s *COAService) Open(ctx context.Context, user *models.User, productID string) (io.ReadCloser, *models.COADocument, error) {
+	product, err := s.products.Get(ctx, productID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, ErrProductNotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	if product.VendorID != user.AssociatedEntityID && user.Role != models.RoleAdmin {
+		vendor, err := s.vendors.Get(ctx, product.VendorID)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && !vendor.MenuEnabled) {
+			return nil, nil, ErrProductNotFound
+		} else if err != nil {
+			return nil, nil, err
+		}
+	}
+	if product.COA == nil {
+		return nil, nil, ErrCOANotFound
+	}
+
+	file, err := s.files.Open(ctx, product.COA.BlobKey)
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, nil, ErrCOANotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+	return file, product.COA, nil
+}
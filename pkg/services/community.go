@@ -0,0 +1,896 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/utils"
+)
+
+const (
+	DefaultFeedLimit = 20
+	MaxFeedLimit     = 100
+
+	maxPostLength    = 2000
+	maxCommentLength = 1000
+	maxPostProducts  = 10
+	maxReportLength  = 500
+)
+
+var (
+	ErrInvalidPost         = errors.New("invalid post")
+	ErrCommunityNotAllowed = errors.New("community action not allowed")
+	ErrPostNotFound        = errors.New("post not found")
+	ErrCommentNotFound     = errors.New("comment not found")
+	ErrReportNotFound      = errors.New("report not found")
+	ErrInvalidModeration   = errors.New("invalid moderation request")
+)
+
+// ModerationAction is what an admin does to a post or comment, or to resolve a report.
+type ModerationAction string
+
+const (
+	ModerationHide    ModerationAction = "HIDE"
+	ModerationUnhide  ModerationAction = "UNHIDE"
+	ModerationFlag    ModerationAction = "FLAG" // label the content with a warning; the note is the label
+	ModerationUnflag  ModerationAction = "UNFLAG"
+	ModerationDismiss ModerationAction = "DISMISS"
+	ModerationBan     ModerationAction = "BAN" // hide the content and ban its author's business
+)
+
+// PostDraft is what a business submits to post on the feed.
+type PostDraft struct {
+	Kind       models.PostKind
+	Body       string
+	ProductIDs []string
+	EventAt    time.Time
+}
+
+// FeedQuery selects a page of the feed, newest first. Before is the cursor:
+// only posts created before it are returned.
+type FeedQuery struct {
+	Kind     models.PostKind
+	EntityID string
+	Before   time.Time
+	Limit    int
+}
+
+// FeedPage is one page of the feed. NextBefore is the cursor for the next page,
+// zero when there are no more posts.
+type FeedPage struct {
+	Posts      []PostView `json:"posts"`
+	NextBefore time.Time  `json:"nextBefore,omitzero"`
+}
+
+// PostView is a post as one viewer sees it: reaction counts rather than who
+// reacted, plus the viewer's own reactions.
+type PostView struct {
+	models.Post
+	Reactions   map[models.Reaction]int `json:"reactions"`
+	MyReactions []models.Reaction       `json:"myReactions"`
+	Comments    []models.Comment        `json:"commentList,omitempty"` // only when a single post is fetched
+	// HiddenReason tells admins why other users can't see the post.
+	HiddenReason string `json:"hiddenReason,omitempty"`
+}
+
+// ReportView is a report together with the content it is about.
+type ReportView struct {
+	models.Report
+	Post    *models.Post    `json:"post,omitempty"`
+	Comment *models.Comment `json:"comment,omitempty"`
+}
+
+// communityMember is what the feed needs to know about a Vendor or Buyer.
+type communityMember struct {
+	id        string
+	kind      models.AuditEntityType // AuditEntityVendor or AuditEntityBuyer
+	name      string
+	compliant bool
+	ban       *models.CommunityBan
+	setBan    func(ctx context.Context, ban *models.CommunityBan) error // writes only the ban
+}
+
+// hiddenReason explains why the member's content is hidden from the feed, or
+// returns "" if it isn't.
+func (m *communityMember) hiddenReason(now time.Time) string {
+	switch {
+	case m == nil:
+		return "author's business no longer exists"
+	case m.ban.Active(now):
+		return "author's business is banned"
+	case !m.compliant:
+		return "author's business is out of compliance"
+	}
+	return ""
+}
+
+// CommunityService runs the community feed: businesses post announcements and
+// comment and react on each other's posts, users report content, and admins
+// moderate it. Content from a business is hidden from the feed while the
+// business is out of compliance or banned, without being changed, so it comes
+// back once the business does.
+type CommunityService struct {
+	posts    repository.PostRepository
+	comments repository.CommentRepository
+	reports  repository.ReportRepository
+	vendors  repository.VendorRepository
+	buyers   repository.BuyerRepository
+	products repository.ProductRepository
+	audit    *AuditLog
+
+	// mu serializes read-modify-writes on posts (reactions, comment counts,
+	// moderation) and on reports.
+	mu sync.Mutex
+}
+
+// NewCommunityService returns a CommunityService that records moderation to audit.
+func NewCommunityService(store *repository.Store, audit *AuditLog) *CommunityService {
+	return &CommunityService{
+		posts:    store.Posts,
+		comments: store.Comments,
+		reports:  store.Reports,
+		vendors:  store.Vendors,
+		buyers:   store.Buyers,
+		products: store.Products,
+		audit:    audit,
+	}
+}
+
+// member loads the Vendor or Buyer with the given ID, or nil if neither exists.
+func (s *CommunityService) member(ctx context.Context, entityID string) (*communityMember, error) {
+	vendor, err := s.vendors.Get(ctx, entityID)
+	if err == nil {
+		return &communityMember{
+			id: vendor.ID, kind: models.AuditEntityVendor, name: vendor.BusinessName, compliant: vendor.IsCompliant(), ban: vendor.CommunityBan,
+			setBan: func(ctx context.Context, ban *models.CommunityBan) error {
+				_, err := s.vendors.Modify(ctx, vendor.ID, func(v *models.Vendor) error {
+					v.CommunityBan = ban
+					return nil
+				})
+				return err
+			},
+		}, nil
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	buyer, err := s.buyers.Get(ctx, entityID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &communityMember{
+		id: buyer.ID, kind: models.AuditEntityBuyer, name: buyer.BusinessName, compliant: buyer.IsCompliant(), ban: buyer.CommunityBan,
+		setBan: func(ctx context.Context, ban *models.CommunityBan) error {
+			_, err := s.buyers.Modify(ctx, buyer.ID, func(b *models.Buyer) error {
+				b.CommunityBan = ban
+				return nil
+			})
+			return err
+		},
+	}, nil
+}
+
+// participant loads the caller's business and checks it may post, comment and react.
+func (s *CommunityService) participant(ctx context.Context, user *models.User) (*communityMember, error) {
+	if user.AssociatedEntityID == "" {
+		return nil, fmt.Errorf("%w: register your business before joining the community", ErrCommunityNotAllowed)
+	}
+	m, err := s.member(ctx, user.AssociatedEntityID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case m == nil:
+		return nil, fmt.Errorf("%w: register your business before joining the community", ErrCommunityNotAllowed)
+	case m.ban.Active(time.Now()):
+		if m.ban.Until.IsZero() {
+			return nil, fmt.Errorf("%w: your business is banned from the community", ErrCommunityNotAllowed)
+		}
+		return nil, fmt.Errorf("%w: your business is banned from the community until %s", ErrCommunityNotAllowed, m.ban.Until.Format(time.RFC3339))
+	case !m.compliant:
+		return nil, fmt.Errorf("%w: your license must be verified and current to take part", ErrCommunityNotAllowed)
+	}
+	return m, nil
+}
+
+// memberCache looks each business up once per request.
+type memberCache struct {
+	s       *CommunityService
+	members map[string]*communityMember
+}
+
+func (s *CommunityService) newMemberCache() *memberCache {
+	return &memberCache{s: s, members: make(map[string]*communityMember)}
+}
+
+func (c *memberCache) get(ctx context.Context, entityID string) (*communityMember, error) {
+	if m, ok := c.members[entityID]; ok {
+		return m, nil
+	}
+	m, err := c.s.member(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	c.members[entityID] = m
+	return m, nil
+}
+
+// hiddenReason explains why viewers other than admins can't see content with the
+// given status from entityID, or returns "" if they can.
+func (c *memberCache) hiddenReason(ctx context.Context, status models.ContentStatus, entityID string) (string, error) {
+	switch status {
+	case models.ContentHidden:
+		return "hidden by a moderator", nil
+	case models.ContentDeleted:
+		return "deleted by its author", nil
+	}
+	m, err := c.get(ctx, entityID)
+	if err != nil {
+		return "", err
+	}
+	return m.hiddenReason(time.Now()), nil
+}
+
+// view renders post for viewer. ok is false if the viewer may not see it; admins
+// see everything, with the reason others can't.
+func (c *memberCache) view(ctx context.Context, viewer *models.User, post models.Post) (view PostView, ok bool, err error) {
+	reason, err := c.hiddenReason(ctx, post.Status, post.EntityID)
+	if err != nil {
+		return view, false, err
+	}
+	isAdmin := viewer.Role == models.RoleAdmin
+	if reason != "" && !isAdmin {
+		return view, false, nil
+	}
+
+	view = PostView{Post: post, Reactions: make(map[models.Reaction]int), MyReactions: []models.Reaction{}}
+	if isAdmin {
+		view.HiddenReason = reason
+	}
+	for reaction, users := range post.Reactions {
+		view.Reactions[reaction] = len(users)
+		if slices.Contains(users, viewer.ID) {
+			view.MyReactions = append(view.MyReactions, reaction)
+		}
+	}
+	slices.Sort(view.MyReactions)
+	return view, true, nil
+}
+
+// Feed returns a page of posts the viewer can see, newest first.
+func (s *CommunityService) Feed(ctx context.Context, viewer *models.User, q FeedQuery) (*FeedPage, error) {
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultFeedLimit
+	case q.Limit < 0 || q.Limit > MaxFeedLimit:
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidPost, MaxFeedLimit)
+	}
+	if q.Kind != "" && !validPostKind(q.Kind) {
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidPost, q.Kind)
+	}
+
+	// Posts come from the repository a batch at a time, newest first. Some may be
+	// hidden from this viewer, so keep reading until the page is full, with one
+	// post to spare to tell whether there is a next page.
+	members := s.newMemberCache()
+	page := &FeedPage{Posts: []PostView{}}
+	filter := repository.PostFilter{Kind: q.Kind, EntityID: q.EntityID, Before: q.Before}
+	batch := q.Limit + 1
+	for {
+		posts, err := s.posts.Page(ctx, filter, batch)
+		if err != nil {
+			return nil, err
+		}
+		for _, post := range posts {
+			view, ok, err := members.view(ctx, viewer, post)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			if len(page.Posts) == q.Limit {
+				page.NextBefore = page.Posts[len(page.Posts)-1].CreatedAt
+				return page, nil
+			}
+			page.Posts = append(page.Posts, view)
+		}
+		if len(posts) < batch {
+			return page, nil
+		}
+		filter.Before = posts[len(posts)-1].CreatedAt
+	}
+}
+
+// loadPost returns a post the viewer can see, with its comments.
+func (s *CommunityService) loadPost(ctx context.Context, viewer *models.User, id string, members *memberCache) (*PostView, error) {
+	post, err := s.posts.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrPostNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	view, ok, err := members.view(ctx, viewer, *post)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrPostNotFound
+	}
+	return &view, nil
+}
+
+// GetPost returns one post and the comments on it the viewer can see, oldest first.
+func (s *CommunityService) GetPost(ctx context.Context, viewer *models.User, id string) (*PostView, error) {
+	members := s.newMemberCache()
+	view, err := s.loadPost(ctx, viewer, id, members)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := s.comments.ListByPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].CreatedAt.Before(comments[j].CreatedAt) })
+	view.Comments = []models.Comment{}
+	for _, comment := range comments {
+		reason, err := members.hiddenReason(ctx, comment.Status, comment.EntityID)
+		if err != nil {
+			return nil, err
+		}
+		if reason == "" || viewer.Role == models.RoleAdmin {
+			view.Comments = append(view.Comments, comment)
+		}
+	}
+	return view, nil
+}
+
+func validPostKind(kind models.PostKind) bool {
+	switch kind {
+	case models.PostAnnouncement, models.PostNewDrop, models.PostRestock, models.PostEvent:
+		return true
+	}
+	return false
+}
+
+// CreatePost publishes a post for the caller's business. Linked products must be
+// the business's own or on a live menu.
+func (s *CommunityService) CreatePost(ctx context.Context, user *models.User, draft PostDraft) (*models.Post, error) {
+	author, err := s.participant(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	// --- STEP 1: Validate the draft ---
+	body := strings.TrimSpace(draft.Body)
+	switch {
+	case !validPostKind(draft.Kind):
+		return nil, fmt.Errorf("%w: kind must be ANNOUNCEMENT, NEW_DROP, RESTOCK or EVENT", ErrInvalidPost)
+	case body == "":
+		return nil, fmt.Errorf("%w: a post needs a body", ErrInvalidPost)
+	case len(body) > maxPostLength:
+		return nil, fmt.Errorf("%w: posts are limited to %d characters", ErrInvalidPost, maxPostLength)
+	case draft.Kind == models.PostEvent && draft.EventAt.IsZero():
+		return nil, fmt.Errorf("%w: an EVENT post needs an eventAt", ErrInvalidPost)
+	case draft.Kind != models.PostEvent && !draft.EventAt.IsZero():
+		return nil, fmt.Errorf("%w: only EVENT posts have an eventAt", ErrInvalidPost)
+	case len(draft.ProductIDs) > maxPostProducts:
+		return nil, fmt.Errorf("%w: a post can link at most %d products", ErrInvalidPost, maxPostProducts)
+	}
+
+	// --- STEP 2: Check the linked products ---
+	productIDs := []string{}
+	for _, id := range draft.ProductIDs {
+		if slices.Contains(productIDs, id) {
+			continue
+		}
+		product, err := s.products.Get(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%w: product %s does not exist", ErrInvalidPost, id)
+		} else if err != nil {
+			return nil, err
+		}
+		if product.VendorID != author.id {
+			vendor, err := s.vendors.Get(ctx, product.VendorID)
+			if errors.Is(err, repository.ErrNotFound) || (err == nil && !vendor.MenuEnabled) {
+				return nil, fmt.Errorf("%w: product %s does not exist", ErrInvalidPost, id)
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		productIDs = append(productIDs, product.ID)
+	}
+
+	now := time.Now()
+	post := models.Post{
+		ID:         utils.NewID("post"),
+		Kind:       draft.Kind,
+		Body:       body,
+		ProductIDs: productIDs,
+		EventAt:    draft.EventAt,
+		AuthorID:   user.ID,
+		EntityID:   author.id,
+		EntityName: author.name,
+		Status:     models.ContentVisible,
+		Reactions:  map[models.Reaction][]string{},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.posts.Create(ctx, post); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// DeletePost removes one of the caller's business's posts from the feed. Admins
+// keep seeing it, so reports about it can still be resolved.
+func (s *CommunityService) DeletePost(ctx context.Context, user *models.User, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post, err := s.posts.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && (post.EntityID != user.AssociatedEntityID || post.Status == models.ContentDeleted)) {
+		return ErrPostNotFound
+	} else if err != nil {
+		return err
+	}
+	post.Status = models.ContentDeleted
+	post.UpdatedAt = time.Now()
+	return s.posts.Update(ctx, *post)
+}
+
+// React adds (on) or removes the caller's reaction to a post. Both are idempotent.
+func (s *CommunityService) React(ctx context.Context, user *models.User, postID string, reaction models.Reaction, on bool) (*PostView, error) {
+	switch reaction {
+	case models.ReactionLike, models.ReactionFire, models.ReactionThanks:
+	default:
+		return nil, fmt.Errorf("%w: reaction must be LIKE, FIRE or THANKS", ErrInvalidPost)
+	}
+	if _, err := s.participant(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members := s.newMemberCache()
+	view, err := s.loadPost(ctx, user, postID, members)
+	if err != nil {
+		return nil, err
+	}
+	// Change a copy: the loaded post's reactions may be shared with the store.
+	post := view.Post
+	post.Reactions = maps.Clone(post.Reactions)
+	if post.Reactions == nil {
+		post.Reactions = map[models.Reaction][]string{}
+	}
+	users := post.Reactions[reaction]
+	switch reacted := slices.Contains(users, user.ID); {
+	case on && !reacted:
+		post.Reactions[reaction] = append(slices.Clone(users), user.ID)
+	case !on && reacted:
+		post.Reactions[reaction] = slices.DeleteFunc(slices.Clone(users), func(id string) bool { return id == user.ID })
+	default:
+		return view, nil
+	}
+	if err := s.posts.Update(ctx, post); err != nil {
+		return nil, err
+	}
+	updated, _, err := members.view(ctx, user, post)
+	return &updated, err
+}
+
+// AddComment replies to a post the caller can see.
+func (s *CommunityService) AddComment(ctx context.Context, user *models.User, postID, body string) (*models.Comment, error) {
+	author, err := s.participant(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	body = strings.TrimSpace(body)
+	switch {
+	case body == "":
+		return nil, fmt.Errorf("%w: a comment needs a body", ErrInvalidPost)
+	case len(body) > maxCommentLength:
+		return nil, fmt.Errorf("%w: comments are limited to %d characters", ErrInvalidPost, maxCommentLength)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.loadPost(ctx, user, postID, s.newMemberCache()); err != nil {
+		return nil, err
+	}
+	comment := models.Comment{
+		ID:         utils.NewID("comment"),
+		PostID:     postID,
+		Body:       body,
+		AuthorID:   user.ID,
+		EntityID:   author.id,
+		EntityName: author.name,
+		Status:     models.ContentVisible,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.comments.Create(ctx, comment); err != nil {
+		return nil, err
+	}
+	if err := s.recountComments(ctx, postID); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// DeleteComment removes one of the caller's business's comments.
+func (s *CommunityService) DeleteComment(ctx context.Context, user *models.User, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comment, err := s.comments.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && (comment.EntityID != user.AssociatedEntityID || comment.Status == models.ContentDeleted)) {
+		return ErrCommentNotFound
+	} else if err != nil {
+		return err
+	}
+	comment.Status = models.ContentDeleted
+	if err := s.comments.Update(ctx, *comment); err != nil {
+		return err
+	}
+	return s.recountComments(ctx, comment.PostID)
+}
+
+// recountComments sets a post's comment count to the comments neither deleted
+// nor hidden. Callers must hold s.mu.
+func (s *CommunityService) recountComments(ctx context.Context, postID string) error {
+	post, err := s.posts.Get(ctx, postID)
+	if err != nil {
+		return err
+	}
+	comments, err := s.comments.ListByPost(ctx, postID)
+	if err != nil {
+		return err
+	}
+	count := 0
+	for _, c := range comments {
+		if c.Status == models.ContentVisible {
+			count++
+		}
+	}
+	if count == post.Comments {
+		return nil
+	}
+	post.Comments = count
+	return s.posts.Update(ctx, *post)
+}
+
+// Report files a report about a post or comment the caller can see. Reporting
+// the same content twice while the first report is open is a no-op.
+func (s *CommunityService) Report(ctx context.Context, user *models.User, targetType models.AuditEntityType, targetID, reason string) (*models.Report, error) {
+	reason = strings.TrimSpace(reason)
+	switch {
+	case reason == "":
+		return nil, fmt.Errorf("%w: a report needs a reason", ErrInvalidPost)
+	case len(reason) > maxReportLength:
+		return nil, fmt.Errorf("%w: reasons are limited to %d characters", ErrInvalidPost, maxReportLength)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// --- STEP 1: Find the content, as the reporter sees it ---
+	members := s.newMemberCache()
+	postID := targetID
+	if targetType == models.AuditEntityComment {
+		comment, err := s.comments.Get(ctx, targetID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrCommentNotFound
+		} else if err != nil {
+			return nil, err
+		}
+		if reason, err := members.hiddenReason(ctx, comment.Status, comment.EntityID); err != nil {
+			return nil, err
+		} else if reason != "" {
+			return nil, ErrCommentNotFound
+		}
+		postID = comment.PostID
+	}
+	if _, err := s.loadPost(ctx, user, postID, members); err != nil {
+		return nil, err
+	}
+
+	// --- STEP 2: File it, unless this user already has ---
+	open, err := s.reports.ListByStatus(ctx, models.ReportOpen)
+	if err != nil {
+		return nil, err
+	}
+	for _, report := range open {
+		if report.TargetID == targetID && report.ReporterID == user.ID {
+			return &report, nil
+		}
+	}
+	report := models.Report{
+		ID:         utils.NewID("report"),
+		TargetType: targetType,
+		TargetID:   targetID,
+		PostID:     postID,
+		Reason:     reason,
+		ReporterID: user.ID,
+		Status:     models.ReportOpen,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.reports.Create(ctx, report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// Reports returns the moderation queue: reports with the given status, oldest
+// first, each with the content it is about.
+func (s *CommunityService) Reports(ctx context.Context, status models.ReportStatus) ([]ReportView, error) {
+	switch status {
+	case models.ReportOpen, models.ReportActioned, models.ReportDismissed:
+	default:
+		return nil, fmt.Errorf("%w: status must be OPEN, ACTIONED or DISMISSED", ErrInvalidModeration)
+	}
+	reports, err := s.reports.ListByStatus(ctx, status)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].CreatedAt.Before(reports[j].CreatedAt) })
+
+	queue := make([]ReportView, 0, len(reports))
+	for _, report := range reports {
+		view := ReportView{Report: report}
+		if report.TargetType == models.AuditEntityComment {
+			if view.Comment, err = s.comments.Get(ctx, report.TargetID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return nil, err
+			}
+		}
+		if view.Post, err = s.posts.Get(ctx, report.PostID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		queue = append(queue, view)
+	}
+	return queue, nil
+}
+
+// Moderate hides, unhides, flags or unflags a post or comment. For FLAG, note is
+// the warning shown with the content.
+func (s *CommunityService) Moderate(ctx context.Context, admin *models.User, targetType models.AuditEntityType, targetID string, action ModerationAction, note string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.moderate(ctx, admin, targetType, targetID, action, note)
+	return err
+}
+
+// moderate applies action and returns the ID of the business that wrote the
+// content. Callers must hold s.mu.
+func (s *CommunityService) moderate(ctx context.Context, admin *models.User, targetType models.AuditEntityType, targetID string, action ModerationAction, note string) (string, error) {
+	note = strings.TrimSpace(note)
+	apply := func(status *models.ContentStatus, flag *string) error {
+		switch action {
+		case ModerationHide:
+			if *status != models.ContentDeleted {
+				*status = models.ContentHidden
+			}
+		case ModerationUnhide:
+			if *status == models.ContentHidden {
+				*status = models.ContentVisible
+			}
+		case ModerationFlag:
+			if note == "" {
+				return fmt.Errorf("%w: FLAG needs a note to show with the content", ErrInvalidModeration)
+			}
+			*flag = note
+		case ModerationUnflag:
+			*flag = ""
+		default:
+			return fmt.Errorf("%w: action must be HIDE, UNHIDE, FLAG or UNFLAG", ErrInvalidModeration)
+		}
+		return nil
+	}
+
+	switch targetType {
+	case models.AuditEntityPost:
+		post, err := s.posts.Get(ctx, targetID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", ErrPostNotFound
+		} else if err != nil {
+			return "", err
+		}
+		before := *post
+		if err := apply(&post.Status, &post.Flag); err != nil {
+			return "", err
+		}
+		post.UpdatedAt = time.Now()
+		if err := s.posts.Update(ctx, *post); err != nil {
+			return "", err
+		}
+		s.audit.Record(ctx, UserEvent(admin, models.AuditContentModerated, models.AuditEntityPost, post.ID), before, post)
+		return post.EntityID, nil
+
+	case models.AuditEntityComment:
+		comment, err := s.comments.Get(ctx, targetID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", ErrCommentNotFound
+		} else if err != nil {
+			return "", err
+		}
+		before := *comment
+		if err := apply(&comment.Status, &comment.Flag); err != nil {
+			return "", err
+		}
+		if err := s.comments.Update(ctx, *comment); err != nil {
+			return "", err
+		}
+		s.audit.Record(ctx, UserEvent(admin, models.AuditContentModerated, models.AuditEntityComment, comment.ID), before, comment)
+		return comment.EntityID, s.recountComments(ctx, comment.PostID)
+	}
+	return "", fmt.Errorf("%w: unknown content type %q", ErrInvalidModeration, targetType)
+}
+
+// author returns the ID of the business that wrote a post or comment.
+func (s *CommunityService) author(ctx context.Context, targetType models.AuditEntityType, targetID string) (string, error) {
+	switch targetType {
+	case models.AuditEntityPost:
+		post, err := s.posts.Get(ctx, targetID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", ErrPostNotFound
+		} else if err != nil {
+			return "", err
+		}
+		return post.EntityID, nil
+	case models.AuditEntityComment:
+		comment, err := s.comments.Get(ctx, targetID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", ErrCommentNotFound
+		} else if err != nil {
+			return "", err
+		}
+		return comment.EntityID, nil
+	}
+	return "", fmt.Errorf("%w: unknown content type %q", ErrInvalidModeration, targetType)
+}
+
+// ResolveReport closes a report: DISMISS leaves the content alone, HIDE hides it,
+// and BAN hides it and bans its author's business until banUntil (zero for
+// indefinitely). Every other open report on the same content is closed with it.
+func (s *CommunityService) ResolveReport(ctx context.Context, admin *models.User, reportID string, action ModerationAction, note string, banUntil time.Time) (*models.Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report, err := s.reports.Get(ctx, reportID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrReportNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if report.Status != models.ReportOpen {
+		return nil, fmt.Errorf("%w: report is already %s", ErrInvalidModeration, report.Status)
+	}
+
+	// --- STEP 1: Act on the content ---
+	status := models.ReportActioned
+	switch action {
+	case ModerationDismiss:
+		status = models.ReportDismissed
+	case ModerationHide:
+		if _, err := s.moderate(ctx, admin, report.TargetType, report.TargetID, ModerationHide, ""); err != nil {
+			return nil, err
+		}
+	case ModerationBan:
+		// Check the ban can go through before hiding anything, so a bad
+		// reason or date doesn't leave the content hidden and the report open.
+		if err := validateBan(note, banUntil, time.Now()); err != nil {
+			return nil, err
+		}
+		author, err := s.author(ctx, report.TargetType, report.TargetID)
+		if err != nil {
+			return nil, err
+		}
+		if m, err := s.member(ctx, author); err != nil {
+			return nil, err
+		} else if m == nil {
+			return nil, fmt.Errorf("%w: business %s does not exist", ErrInvalidModeration, author)
+		}
+		if _, err := s.moderate(ctx, admin, report.TargetType, report.TargetID, ModerationHide, ""); err != nil {
+			return nil, err
+		}
+		if err := s.ban(ctx, admin, author, note, banUntil); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: action must be DISMISS, HIDE or BAN", ErrInvalidModeration)
+	}
+
+	// --- STEP 2: Close this and any duplicate reports ---
+	open, err := s.reports.ListByStatus(ctx, models.ReportOpen)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var resolved *models.Report
+	for _, r := range open {
+		if r.TargetID != report.TargetID {
+			continue
+		}
+		before := r
+		r.Status, r.Resolution, r.ResolvedBy, r.ResolvedAt = status, strings.TrimSpace(note), admin.ID, now
+		if err := s.reports.Update(ctx, r); err != nil {
+			return nil, err
+		}
+		s.audit.Record(ctx, UserEvent(admin, models.AuditReportResolved, r.TargetType, r.TargetID), before, r)
+		if r.ID == reportID {
+			resolved = &r
+		}
+	}
+	return resolved, nil
+}
+
+// Ban bans a business from the community until until, or indefinitely if until is zero.
+func (s *CommunityService) Ban(ctx context.Context, admin *models.User, entityID, reason string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ban(ctx, admin, entityID, reason, until)
+}
+
+// validateBan checks a ban's reason and end date.
+func validateBan(reason string, until, now time.Time) error {
+	switch {
+	case strings.TrimSpace(reason) == "":
+		return fmt.Errorf("%w: a ban needs a reason", ErrInvalidModeration)
+	case !until.IsZero() && !until.After(now):
+		return fmt.Errorf("%w: until must be in the future", ErrInvalidModeration)
+	}
+	return nil
+}
+
+func (s *CommunityService) ban(ctx context.Context, admin *models.User, entityID, reason string, until time.Time) error {
+	reason = strings.TrimSpace(reason)
+	now := time.Now()
+	if err := validateBan(reason, until, now); err != nil {
+		return err
+	}
+	m, err := s.member(ctx, entityID)
+	if err != nil {
+		return err
+	} else if m == nil {
+		return fmt.Errorf("%w: business %s does not exist", ErrInvalidModeration, entityID)
+	}
+
+	before := m.ban
+	ban := &models.CommunityBan{Reason: reason, BannedBy: admin.ID, BannedAt: now, Until: until}
+	if err := m.setBan(ctx, ban); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, UserEvent(admin, models.AuditCommunityBanned, m.kind, entityID), before, ban)
+	return nil
+}
+
+// Unban lifts a business's community ban.
+func (s *CommunityService) Unban(ctx context.Context, admin *models.User, entityID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.member(ctx, entityID)
+	if err != nil {
+		return err
+	} else if m == nil || m.ban == nil {
+		return fmt.Errorf("%w: business %s is not banned", ErrInvalidModeration, entityID)
+	}
+	before := m.ban
+	if err := m.setBan(ctx, nil); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, UserEvent(admin, models.AuditCommunityUnbanned, m.kind, entityID), before, nil)
+	return nil
+}
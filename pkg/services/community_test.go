@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+func TestCommunityFeedVisibility(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	expires := time.Now().AddDate(1, 0, 0)
+	for _, v := range []models.Vendor{
+		{ID: "vendor_ok", BusinessName: "Green Acres", ComplianceStatus: models.ComplianceVerified, LicenseExpirationDate: expires},
+		{ID: "vendor_lapsed", BusinessName: "Red Dirt", ComplianceStatus: models.ComplianceVerified, LicenseExpirationDate: expires},
+	} {
+		if err := store.Vendors.Create(ctx, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Buyers.Create(ctx, models.Buyer{ID: "buyer_1", BusinessName: "Corner Dispensary", ComplianceStatus: models.ComplianceVerified, LicenseExpirationDate: expires}); err != nil {
+		t.Fatal(err)
+	}
+	community := NewCommunityService(store, NewAuditLog(store.Audit))
+	admin := &models.User{ID: "user_admin", Role: models.RoleAdmin}
+	viewer := &models.User{ID: "user_b", Role: models.RoleBuyer, AssociatedEntityID: "buyer_1"}
+
+	posts := map[string]*models.Post{}
+	for _, author := range []*models.User{
+		{ID: "user_ok", Role: models.RoleVendor, AssociatedEntityID: "vendor_ok"},
+		{ID: "user_lapsed", Role: models.RoleVendor, AssociatedEntityID: "vendor_lapsed"},
+		{ID: "user_b", Role: models.RoleBuyer, AssociatedEntityID: "buyer_1"},
+	} {
+		post, err := community.CreatePost(ctx, author, PostDraft{Kind: models.PostAnnouncement, Body: "Hello from " + author.AssociatedEntityID})
+		if err != nil {
+			t.Fatalf("CreatePost for %s: %v", author.AssociatedEntityID, err)
+		}
+		posts[author.AssociatedEntityID] = post
+	}
+
+	// The lapsed vendor's license expires and the buyer is banned after posting.
+	if _, err := store.Vendors.Modify(ctx, "vendor_lapsed", func(v *models.Vendor) error {
+		v.LicenseExpirationDate = time.Now().Add(-time.Hour)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := community.Ban(ctx, admin, "buyer_1", "spam", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	feedIDs := func(user *models.User) map[string]string {
+		page, err := community.Feed(ctx, user, FeedQuery{})
+		if err != nil {
+			t.Fatalf("Feed: %v", err)
+		}
+		reasons := map[string]string{}
+		for _, p := range page.Posts {
+			reasons[p.ID] = p.HiddenReason
+		}
+		return reasons
+	}
+
+	if got := feedIDs(viewer); len(got) != 1 || got[posts["vendor_ok"].ID] != "" {
+		t.Errorf("buyer's feed = %v, want only the compliant vendor's post", got)
+	}
+	got := feedIDs(admin)
+	want := map[string]string{
+		posts["vendor_ok"].ID:     "",
+		posts["vendor_lapsed"].ID: "author's business is out of compliance",
+		posts["buyer_1"].ID:       "author's business is banned",
+	}
+	for id, reason := range want {
+		if r, ok := got[id]; !ok || r != reason {
+			t.Errorf("admin sees post %s with reason %q (shown %v), want %q", id, r, ok, reason)
+		}
+	}
+
+	if _, err := community.GetPost(ctx, viewer, posts["buyer_1"].ID); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("GetPost of a banned business's post = %v, want ErrPostNotFound", err)
+	}
+	if _, err := community.CreatePost(ctx, viewer, PostDraft{Kind: models.PostAnnouncement, Body: "Still here"}); !errors.Is(err, ErrCommunityNotAllowed) {
+		t.Errorf("banned business posting = %v, want ErrCommunityNotAllowed", err)
+	}
+
+	// Lifting the ban brings the buyer's post back without touching it.
+	if err := community.Unban(ctx, admin, "buyer_1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := feedIDs(viewer); len(got) != 2 {
+		t.Errorf("buyer's feed after unban = %v, want 2 posts", got)
+	}
+}
+
+func TestCommunityBanValidation(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	if err := store.Vendors.Create(ctx, models.Vendor{ID: "vendor_1"}); err != nil {
+		t.Fatal(err)
+	}
+	community := NewCommunityService(store, NewAuditLog(store.Audit))
+	admin := &models.User{ID: "user_admin", Role: models.RoleAdmin}
+
+	tests := []struct {
+		name     string
+		entityID string
+		reason   string
+		until    time.Time
+	}{
+		{"no reason", "vendor_1", " ", time.Time{}},
+		{"ends in the past", "vendor_1", "spam", time.Now().Add(-time.Hour)},
+		{"unknown business", "vendor_2", "spam", time.Time{}},
+	}
+	for _, tt := range tests {
+		if err := community.Ban(ctx, admin, tt.entityID, tt.reason, tt.until); !errors.Is(err, ErrInvalidModeration) {
+			t.Errorf("%s: Ban = %v, want ErrInvalidModeration", tt.name, err)
+		}
+	}
+	if err := community.Unban(ctx, admin, "vendor_1"); !errors.Is(err, ErrInvalidModeration) {
+		t.Errorf("Unban of a business that isn't banned = %v, want ErrInvalidModeration", err)
+	}
+}
+
+// Reacting changes a copy of the post, so feeds read concurrently never see a
+// reaction list being written. Run with -race.
+func TestCommunityReactWhileReadingFeed(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	if err := store.Vendors.Create(ctx, models.Vendor{ID: "vendor_1", ComplianceStatus: models.ComplianceVerified, LicenseExpirationDate: time.Now().AddDate(1, 0, 0)}); err != nil {
+		t.Fatal(err)
+	}
+	community := NewCommunityService(store, NewAuditLog(store.Audit))
+	post, err := community.CreatePost(ctx, &models.User{ID: "user_0", Role: models.RoleVendor, AssociatedEntityID: "vendor_1"}, PostDraft{Kind: models.PostRestock, Body: "Back in stock"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := []string{"user_0", "user_1", "user_2", "user_3"}
+	var wg sync.WaitGroup
+	for _, id := range users {
+		user := &models.User{ID: id, Role: models.RoleVendor, AssociatedEntityID: "vendor_1"}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := range 20 {
+				if _, err := community.React(ctx, user, post.ID, models.ReactionFire, i%2 == 0); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			if _, err := community.React(ctx, user, post.ID, models.ReactionFire, true); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				if _, err := community.Feed(ctx, user, FeedQuery{}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	stored, _ := store.Posts.Get(ctx, post.ID)
+	reacted := slices.Clone(stored.Reactions[models.ReactionFire])
+	slices.Sort(reacted)
+	if !slices.Equal(reacted, users) {
+		t.Errorf("FIRE reactions = %v, want %v", reacted, users)
+	}
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+var ErrInvalidCompliance = errors.New("invalid compliance attributes")
+
+// errNothingToFold aborts migrating a product that has no compliance tags left.
+var errNothingToFold = errors.New("nothing to fold")
+
+var (
+	potencyTag        = regexp.MustCompile(`(?i)^(?:total\s+)?(thc|cbd)\s*[:=]?\s*(\d+(?:\.\d+)?)\s*%?$`)
+	potencyTagPostfix = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)?)\s*%\s*(?:total\s+)?(thc|cbd)$`)
+	attributeTag      = regexp.MustCompile(`(?i)^(lab(?:\s*id)?|batch(?:\s*(?:#|no\.?|number))?|lot(?:\s*(?:#|no\.?|number))?|harvest(?:ed)?(?:\s*date)?|packag(?:ed|e\s*date|ing\s*date)|test(?:ing)?(?:\s*status)?|lab\s*test(?:ed|ing)?)\s*[:#]\s*(.+)$`)
+	dominantTag       = regexp.MustCompile(`(?i)^(indica|sativa)[\s-]*dominant(?:\s+hybrid)?$`)
+)
+
+// testStatusTags are the bare tags vendors have used to describe lab testing.
+var testStatusTags = map[string]models.TestStatus{
+	"lab tested": models.TestPassed, "tested": models.TestPassed, "passed": models.TestPassed,
+	"passed testing": models.TestPassed, "test passed": models.TestPassed, "pass": models.TestPassed,
+	"failed": models.TestFailed, "failed testing": models.TestFailed, "test failed": models.TestFailed, "fail": models.TestFailed,
+	"pending": models.TestPending, "test pending": models.TestPending, "testing pending": models.TestPending,
+	"pending test": models.TestPending, "pending testing": models.TestPending,
+}
+
+// tagDateLayouts are the date formats seen in harvest and package date tags.
+var tagDateLayouts = []string{"2006-01-02", "01/02/2006", "1/2/2006", "01/02/06", "Jan 2, 2006", "January 2, 2006", "2 Jan 2006"}
+
+// parseComplianceTag reads one free-form tag into c. It reports false if the tag
+// isn't a compliance attribute, or if c already has that attribute set.
+func parseComplianceTag(c *models.ComplianceAttributes, tag string) bool {
+	tag = strings.TrimSpace(tag)
+
+	setPercent := func(analyte, value string) bool {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return false
+		}
+		dst := &c.THCPercent
+		if strings.EqualFold(analyte, "cbd") {
+			dst = &c.CBDPercent
+		}
+		if *dst != nil {
+			return false
+		}
+		*dst = &v
+		return true
+	}
+	setString := func(dst *string, value string) bool {
+		if *dst != "" {
+			return false
+		}
+		*dst = value
+		return true
+	}
+	setDate := func(dst *time.Time, value string) bool {
+		if !dst.IsZero() {
+			return false
+		}
+		for _, layout := range tagDateLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				*dst = t
+				return true
+			}
+		}
+		return false
+	}
+	setStatus := func(status models.TestStatus) bool {
+		if c.TestStatus != "" {
+			return false
+		}
+		c.TestStatus = status
+		return true
+	}
+	setStrain := func(strain models.StrainType) bool {
+		if c.StrainType != "" {
+			return false
+		}
+		c.StrainType = strain
+		return true
+	}
+
+	lower := strings.ToLower(tag)
+	if m := potencyTag.FindStringSubmatch(tag); m != nil {
+		return setPercent(m[1], m[2])
+	}
+	if m := potencyTagPostfix.FindStringSubmatch(tag); m != nil {
+		return setPercent(m[2], m[1])
+	}
+	switch strain := models.StrainType(strings.ToUpper(tag)); {
+	case strain == models.StrainIndica || strain == models.StrainSativa || strain == models.StrainHybrid:
+		return setStrain(strain)
+	case dominantTag.MatchString(tag), strings.Contains(lower, "hybrid") && len(strings.Fields(lower)) <= 3:
+		return setStrain(models.StrainHybrid)
+	}
+	if status, ok := testStatusTags[lower]; ok {
+		return setStatus(status)
+	}
+
+	m := attributeTag.FindStringSubmatch(tag)
+	if m == nil {
+		return false
+	}
+	key, value := strings.ToLower(m[1]), strings.TrimSpace(m[2])
+	switch {
+	case strings.HasPrefix(key, "batch"), strings.HasPrefix(key, "lot"):
+		return setString(&c.BatchNumber, value)
+	case strings.HasPrefix(key, "harvest"):
+		return setDate(&c.HarvestDate, value)
+	case strings.HasPrefix(key, "packag"):
+		return setDate(&c.PackageDate, value)
+	case strings.HasPrefix(key, "lab") && !strings.Contains(key, "test"):
+		return setString(&c.LabID, value)
+	default:
+		status, ok := testStatusTags[strings.ToLower(value)]
+		return ok && setStatus(status)
+	}
+}
+
+// FoldComplianceTags moves the tags that carry a compliance attribute, i.e.
+// "THC: 25%", "Sativa" or "Lab ID: 12345", into c and returns the tags that
+// are left. Attributes already set on c win over tags.
+func FoldComplianceTags(c *models.ComplianceAttributes, tags []string) []string {
+	var rest []string
+	for _, tag := range tags {
+		if !parseComplianceTag(c, tag) {
+			rest = append(rest, tag)
+		}
+	}
+	return rest
+}
+
+// ValidateCompliance checks a product's compliance attributes.
+func ValidateCompliance(c models.ComplianceAttributes) error {
+	for _, p := range []struct {
+		name  string
+		value *float64
+	}{{"thcPercent", c.THCPercent}, {"cbdPercent", c.CBDPercent}} {
+		if p.value != nil && (*p.value < 0 || *p.value > 100) {
+			return fmt.Errorf("%w: %s must be between 0 and 100", ErrInvalidCompliance, p.name)
+		}
+	}
+	if c.THCPercent != nil && c.CBDPercent != nil && *c.THCPercent+*c.CBDPercent > 100 {
+		return fmt.Errorf("%w: thcPercent and cbdPercent add up to more than 100", ErrInvalidCompliance)
+	}
+
+	switch c.StrainType {
+	case "", models.StrainIndica, models.StrainSativa, models.StrainHybrid:
+	default:
+		return fmt.Errorf("%w: strainType must be INDICA, SATIVA or HYBRID", ErrInvalidCompliance)
+	}
+	switch c.TestStatus {
+	case "", models.TestPending:
+	case models.TestPassed, models.TestFailed:
+		if strings.TrimSpace(c.LabID) == "" {
+			return fmt.Errorf("%w: a labID is required once testing is complete", ErrInvalidCompliance)
+		}
+	default:
+		return fmt.Errorf("%w: testStatus must be PENDING, PASSED or FAILED", ErrInvalidCompliance)
+	}
+
+	// Allow a day of slack so a date entered in a timezone ahead of ours isn't "in the future".
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	switch {
+	case c.HarvestDate.After(tomorrow):
+		return fmt.Errorf("%w: harvestDate is in the future", ErrInvalidCompliance)
+	case c.PackageDate.After(tomorrow):
+		return fmt.Errorf("%w: packageDate is in the future", ErrInvalidCompliance)
+	case !c.HarvestDate.IsZero() && !c.PackageDate.IsZero() && c.PackageDate.Before(c.HarvestDate):
+		return fmt.Errorf("%w: packageDate is before harvestDate", ErrInvalidCompliance)
+	case len(c.BatchNumber) > 64 || len(c.LabID) > 64:
+		return fmt.Errorf("%w: batchNumber and labID are limited to 64 characters", ErrInvalidCompliance)
+	}
+	return nil
+}
+
+// MigrateProductCategories rewrites categories saved before they were stored in
+// CanonicalCategory form, so exact-match searches find them. Products already in
+// canonical form are skipped.
+func MigrateProductCategories(ctx context.Context, products repository.ProductRepository, audit *AuditLog) (int, error) {
+	all, err := products.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, product := range all {
+		if product.Category == CanonicalCategory(product.Category) {
+			continue
+		}
+		var before models.Product
+		updated, err := products.Modify(ctx, product.ID, func(p *models.Product) error {
+			before = *p
+			p.Category = CanonicalCategory(p.Category)
+			return nil
+		})
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return migrated, fmt.Errorf("migrating product %s: %w", product.ID, err)
+		}
+		audit.Record(ctx, SystemEvent(models.AuditProductUpdated, models.AuditEntityProduct, updated.ID), before, updated)
+		migrated++
+	}
+	return migrated, nil
+}
+
+// MigrateUnverifiedTestStatus resets to PENDING the test status of products that
+// claim PASSED or FAILED without an uploaded COA, which vendors could set by hand
+// before test results had to come from a COA.
+func MigrateUnverifiedTestStatus(ctx context.Context, products repository.ProductRepository, audit *AuditLog) (int, error) {
+	all, err := products.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, product := range all {
+		if !hasUnverifiedTestResult(product) {
+			continue
+		}
+		var before models.Product
+		updated, err := products.Modify(ctx, product.ID, func(p *models.Product) error {
+			before = *p
+			if hasUnverifiedTestResult(*p) {
+				p.Compliance.TestStatus = models.TestPending
+			}
+			return nil
+		})
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return migrated, fmt.Errorf("migrating product %s: %w", product.ID, err)
+		}
+		audit.Record(ctx, SystemEvent(models.AuditProductUpdated, models.AuditEntityProduct, updated.ID), before, updated)
+		migrated++
+	}
+	return migrated, nil
+}
+
+// MigrateComplianceTags folds the compliance tags on products written before
+// Product.Compliance existed into the structured fields. It is safe to run
+// repeatedly: products with nothing left to fold are skipped. Products whose
+// tags don't validate (i.e. "THC: 250%") keep their tags and are logged.
+func MigrateComplianceTags(ctx context.Context, products repository.ProductRepository, audit *AuditLog) (int, error) {
+	all, err := products.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, product := range all {
+		var before models.Product
+		var invalid error
+		updated, err := products.Modify(ctx, product.ID, func(p *models.Product) error {
+			before = *p
+			rest := FoldComplianceTags(&p.Compliance, p.ComplianceTags)
+			if len(rest) == len(p.ComplianceTags) {
+				return errNothingToFold
+			}
+			if p.COA != nil {
+				p.Compliance.ApplyLabResults(p.COA.Results)
+			}
+			invalid = ValidateCompliance(p.Compliance)
+			if invalid == nil && hasUnverifiedTestResult(*p) {
+				invalid = ErrProductUnverifiedTest
+			}
+			if invalid != nil {
+				return invalid
+			}
+			p.ComplianceTags = rest
+			p.UpdatedAt = time.Now()
+			return nil
+		})
+		switch {
+		case errors.Is(err, errNothingToFold), errors.Is(err, repository.ErrNotFound):
+			continue
+		case invalid != nil:
+			log.Printf("MIGRATION: leaving tags on product %s: %v", product.ID, invalid)
+			continue
+		case err != nil:
+			return migrated, fmt.Errorf("migrating product %s: %w", product.ID, err)
+		}
+		audit.Record(ctx, SystemEvent(models.AuditProductUpdated, models.AuditEntityProduct, updated.ID), before, updated)
+		migrated++
+	}
+	return migrated, nil
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+// ComplianceReason says why a business failed the transactional compliance check.
+type ComplianceReason string
+
+const (
+	ReasonLicenseExpired     ComplianceReason = "LICENSE_EXPIRED"
+	ReasonLicenseNotVerified ComplianceReason = "LICENSE_NOT_VERIFIED"
+	ReasonBusinessSuspended  ComplianceReason = "BUSINESS_SUSPENDED"
+	ReasonNotRegistered      ComplianceReason = "BUSINESS_NOT_REGISTERED"
+)
+
+// ComplianceError is returned when a business may not trade right now. It names
+// the business and the reason so clients can tell "renew your license" apart
+// from "the vendor you're ordering from is suspended".
+type ComplianceError struct {
+	EntityID   string                   `json:"entityID,omitempty"`
+	EntityType models.AccountEntityType `json:"entityType,omitempty"`
+	Reason     ComplianceReason         `json:"reason"`
+	Message    string                   `json:"message"`
+}
+
+func (e *ComplianceError) Error() string {
+	return fmt.Sprintf("compliance check failed for %s %s: %s", e.EntityType, e.EntityID, e.Reason)
+}
+
+// complianceResult is one cached check; err is nil for a pass.
+type complianceResult struct {
+	err     error
+	expires time.Time
+}
+
+// ComplianceGate runs CheckInternalLicenseStatus against our own records before
+// transactional requests. Results, passes and failures alike, are cached for a
+// short TTL so a busy buyer doesn't reload their business on every click.
+// Status changes we make ourselves (license re-checks, admin suspensions) drop
+// the cached entry through Invalidate, so only outside edits wait out the TTL.
+type ComplianceGate struct {
+	vendors   repository.VendorRepository
+	buyers    repository.BuyerRepository
+	scheduler *LicenseScheduler
+	ttl       time.Duration
+
+	mu    sync.Mutex
+	cache map[string]complianceResult
+}
+
+// NewComplianceGate returns a gate reading businesses from store and caching each
+// result for ttl. License failures are queued on scheduler for an external re-check,
+// and the gate forgets a business whenever the scheduler changes its license status.
+func NewComplianceGate(store *repository.Store, scheduler *LicenseScheduler, ttl time.Duration) *ComplianceGate {
+	g := &ComplianceGate{
+		vendors:   store.Vendors,
+		buyers:    store.Buyers,
+		scheduler: scheduler,
+		ttl:       ttl,
+		cache:     make(map[string]complianceResult),
+	}
+	if scheduler != nil {
+		scheduler.OnStatusChange(g.Invalidate)
+	}
+	return g
+}
+
+// Invalidate drops the cached result for a business, so its next request is
+// checked against fresh data.
+func (g *ComplianceGate) Invalidate(entityType models.AccountEntityType, entityID string) {
+	g.mu.Lock()
+	delete(g.cache, string(entityType)+"/"+entityID)
+	g.mu.Unlock()
+}
+
+// CheckUser checks the business the user acts for. Admins don't trade for a
+// business and always pass; vendors and buyers not linked to a business, and
+// any other role, fail with BUSINESS_NOT_REGISTERED.
+func (g *ComplianceGate) CheckUser(ctx context.Context, user *models.User) error {
+	var entityType models.AccountEntityType
+	switch user.Role {
+	case models.RoleAdmin:
+		return nil
+	case models.RoleVendor:
+		entityType = models.AccountEntityVendor
+	case models.RoleBuyer:
+		entityType = models.AccountEntityBuyer
+	default:
+		return &ComplianceError{Reason: ReasonNotRegistered, Message: "Only vendor and buyer accounts can trade."}
+	}
+	return g.Check(ctx, entityType, user.AssociatedEntityID)
+}
+
+// Check returns nil if the business may trade, or a *ComplianceError saying why not.
+func (g *ComplianceGate) Check(ctx context.Context, entityType models.AccountEntityType, entityID string) error {
+	if entityID == "" {
+		return &ComplianceError{EntityType: entityType, Reason: ReasonNotRegistered, Message: "Register your business before trading."}
+	}
+
+	key := string(entityType) + "/" + entityID
+	now := time.Now()
+	g.mu.Lock()
+	cached, ok := g.cache[key]
+	g.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.err
+	}
+
+	err := g.check(ctx, entityType, entityID)
+	var cerr *ComplianceError
+	if err != nil && !errors.As(err, &cerr) {
+		// Storage trouble says nothing about the business; don't cache it.
+		return err
+	}
+
+	g.mu.Lock()
+	g.cache[key] = complianceResult{err: err, expires: now.Add(g.ttl)}
+	g.mu.Unlock()
+	return err
+}
+
+// check loads the business and runs the checks uncached.
+func (g *ComplianceGate) check(ctx context.Context, entityType models.AccountEntityType, entityID string) error {
+	// --- STEP 1: Retrieve Vendor/Buyer data from our DB ---
+	var (
+		name             string
+		status           models.AccountStatus
+		licenseExpiry    time.Time
+		complianceStatus models.AccountComplianceStatus
+		err              error
+	)
+	switch entityType {
+	case models.AccountEntityVendor:
+		var vendor *models.Vendor
+		if vendor, err = g.vendors.Get(ctx, entityID); err == nil {
+			name, status, licenseExpiry, complianceStatus = vendor.BusinessName, vendor.Status, vendor.LicenseExpirationDate, vendor.ComplianceStatus
+		}
+	case models.AccountEntityBuyer:
+		var buyer *models.Buyer
+		if buyer, err = g.buyers.Get(ctx, entityID); err == nil {
+			name, status, licenseExpiry, complianceStatus = buyer.BusinessName, buyer.Status, buyer.LicenseExpirationDate, buyer.ComplianceStatus
+		}
+	default:
+		return fmt.Errorf("%w: %q", models.ErrUnknownAccountEntity, entityType)
+	}
+	cerr := &ComplianceError{EntityID: entityID, EntityType: entityType}
+	if errors.Is(err, repository.ErrNotFound) {
+		cerr.Reason, cerr.Message = ReasonNotRegistered, "This business is no longer registered."
+		return cerr
+	} else if err != nil {
+		return err
+	}
+
+	// --- STEP 2: An admin suspension overrides the license ---
+	if status == models.StatusSuspended {
+		log.Printf("Transactional compliance check failed for %s %s: business is suspended", entityType, entityID)
+		cerr.Reason, cerr.Message = ReasonBusinessSuspended, fmt.Sprintf("%s is suspended and cannot trade.", name)
+		return cerr
+	}
+
+	// --- STEP 3: Internal Compliance Check ---
+	err = CheckInternalLicenseStatus(entityID, licenseExpiry, complianceStatus)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrLicenseExpired):
+		cerr.Reason, cerr.Message = ReasonLicenseExpired, fmt.Sprintf("%s's license expired on %s.", name, licenseExpiry.Format("2006-01-02"))
+	default:
+		cerr.Reason, cerr.Message = ReasonLicenseNotVerified, fmt.Sprintf("%s's license is not verified (status %s).", name, complianceStatus)
+	}
+	log.Printf("Transactional license check failed for %s %s: %v", entityType, entityID, err)
+
+	// Re-verify externally in the background to catch recently renewed licenses
+	// that we haven't updated yet. This request still fails on the cached data.
+	if g.scheduler != nil {
+		g.scheduler.Enqueue(entityID)
+	}
+	return cerr
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+func TestComplianceGateCheck(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	nextYear, lastYear := time.Now().AddDate(1, 0, 0), time.Now().AddDate(-1, 0, 0)
+	for _, v := range []models.Vendor{
+		{ID: "verified", ComplianceStatus: models.ComplianceVerified, LicenseExpirationDate: nextYear},
+		{ID: "expired", ComplianceStatus: models.ComplianceVerified, LicenseExpirationDate: lastYear},
+		{ID: "pending", ComplianceStatus: models.CompliancePending, LicenseExpirationDate: nextYear},
+		{ID: "suspended", Status: models.StatusSuspended, ComplianceStatus: models.ComplianceVerified, LicenseExpirationDate: nextYear},
+	} {
+		if err := store.Vendors.Create(ctx, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Buyers.Create(ctx, models.Buyer{ID: "buyer", ComplianceStatus: models.ComplianceVerified, LicenseExpirationDate: nextYear}); err != nil {
+		t.Fatal(err)
+	}
+	gate := NewComplianceGate(store, nil, time.Minute)
+
+	tests := []struct {
+		name       string
+		user       *models.User
+		wantReason ComplianceReason // "" for a pass
+	}{
+		{"verified vendor", &models.User{Role: models.RoleVendor, AssociatedEntityID: "verified"}, ""},
+		{"verified buyer", &models.User{Role: models.RoleBuyer, AssociatedEntityID: "buyer"}, ""},
+		{"expired license", &models.User{Role: models.RoleVendor, AssociatedEntityID: "expired"}, ReasonLicenseExpired},
+		{"license not verified", &models.User{Role: models.RoleVendor, AssociatedEntityID: "pending"}, ReasonLicenseNotVerified},
+		{"suspended despite a good license", &models.User{Role: models.RoleVendor, AssociatedEntityID: "suspended"}, ReasonBusinessSuspended},
+		{"no business yet", &models.User{Role: models.RoleBuyer}, ReasonNotRegistered},
+		{"business no longer exists", &models.User{Role: models.RoleVendor, AssociatedEntityID: "gone"}, ReasonNotRegistered},
+		{"a vendor ID checked as a buyer", &models.User{Role: models.RoleBuyer, AssociatedEntityID: "verified"}, ReasonNotRegistered},
+		{"admins don't trade for a business", &models.User{Role: models.RoleAdmin}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := gate.CheckUser(ctx, tt.user)
+			var cerr *ComplianceError
+			switch {
+			case tt.wantReason == "" && err != nil:
+				t.Errorf("CheckUser = %v, want a pass", err)
+			case tt.wantReason != "" && (!errors.As(err, &cerr) || cerr.Reason != tt.wantReason):
+				t.Errorf("CheckUser = %v, want %s", err, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestComplianceGateCaching(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	if err := store.Vendors.Create(ctx, models.Vendor{ID: "vendor_1", ComplianceStatus: models.ComplianceVerified, LicenseExpirationDate: time.Now().AddDate(1, 0, 0)}); err != nil {
+		t.Fatal(err)
+	}
+	gate := NewComplianceGate(store, nil, time.Hour)
+	setStatus := func(status models.AccountStatus) {
+		t.Helper()
+		if _, err := store.Vendors.Modify(ctx, "vendor_1", func(v *models.Vendor) error {
+			v.Status = status
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wantReason := func(step string, want ComplianceReason) {
+		t.Helper()
+		err := gate.Check(ctx, models.AccountEntityVendor, "vendor_1")
+		var cerr *ComplianceError
+		switch {
+		case want == "" && err != nil:
+			t.Errorf("%s: Check = %v, want a pass", step, err)
+		case want != "" && (!errors.As(err, &cerr) || cerr.Reason != want):
+			t.Errorf("%s: Check = %v, want %s", step, err, want)
+		}
+	}
+
+	wantReason("first check", "")
+	setStatus(models.StatusSuspended)
+	wantReason("a pass is cached", "")
+	gate.Invalidate(models.AccountEntityVendor, "vendor_1")
+	wantReason("after Invalidate", ReasonBusinessSuspended)
+	setStatus(models.StatusActive)
+	wantReason("a failure is cached too", ReasonBusinessSuspended)
+	gate.Invalidate(models.AccountEntityBuyer, "vendor_1")
+	wantReason("invalidating another entity type", ReasonBusinessSuspended)
+	gate.Invalidate(models.AccountEntityVendor, "vendor_1")
+	wantReason("reinstated", "")
+}
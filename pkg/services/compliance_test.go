@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+func TestFoldComplianceTags(t *testing.T) {
+	pct := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name     string
+		existing models.ComplianceAttributes
+		tags     []string
+		want     models.ComplianceAttributes
+		wantRest []string
+	}{
+		{
+			name: "potency both ways round",
+			tags: []string{"THC: 25%", "1.2% CBD"},
+			want: models.ComplianceAttributes{THCPercent: pct(25), CBDPercent: pct(1.2)},
+		},
+		{
+			name: "total potency without a colon",
+			tags: []string{"Total THC 22.5"},
+			want: models.ComplianceAttributes{THCPercent: pct(22.5)},
+		},
+		{
+			name: "strain types",
+			tags: []string{"sativa"},
+			want: models.ComplianceAttributes{StrainType: models.StrainSativa},
+		},
+		{
+			name: "dominant strains are hybrids",
+			tags: []string{"Indica-Dominant Hybrid"},
+			want: models.ComplianceAttributes{StrainType: models.StrainHybrid},
+		},
+		{
+			name: "lab, batch and dates",
+			tags: []string{"Lab ID: OK-LAB-0042", "Batch #: GG4-0917", "Harvested: 2025-08-01", "Package Date: 08/15/2025"},
+			want: models.ComplianceAttributes{
+				LabID:       "OK-LAB-0042",
+				BatchNumber: "GG4-0917",
+				HarvestDate: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC),
+				PackageDate: time.Date(2025, 8, 15, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "test status",
+			tags: []string{"Lab Tested"},
+			want: models.ComplianceAttributes{TestStatus: models.TestPassed},
+		},
+		{
+			name: "test status as an attribute",
+			tags: []string{"Testing Status: pending"},
+			want: models.ComplianceAttributes{TestStatus: models.TestPending},
+		},
+		{
+			name:     "ordinary tags are kept in order",
+			tags:     []string{"Top Shelf", "THC: 25%", "Indoor", "Hybrid"},
+			want:     models.ComplianceAttributes{THCPercent: pct(25), StrainType: models.StrainHybrid},
+			wantRest: []string{"Top Shelf", "Indoor"},
+		},
+		{
+			name:     "set attributes win over tags",
+			existing: models.ComplianceAttributes{THCPercent: pct(18), StrainType: models.StrainIndica, LabID: "LAB-1"},
+			tags:     []string{"THC: 25%", "Sativa", "Lab ID: LAB-2"},
+			want:     models.ComplianceAttributes{THCPercent: pct(18), StrainType: models.StrainIndica, LabID: "LAB-1"},
+			wantRest: []string{"THC: 25%", "Sativa", "Lab ID: LAB-2"},
+		},
+		{
+			name:     "the first of two tags wins",
+			tags:     []string{"THC: 25%", "THC: 30%"},
+			want:     models.ComplianceAttributes{THCPercent: pct(25)},
+			wantRest: []string{"THC: 30%"},
+		},
+		{
+			name:     "unparseable dates stay tags",
+			tags:     []string{"Harvest Date: last spring"},
+			wantRest: []string{"Harvest Date: last spring"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.existing
+			rest := FoldComplianceTags(&got, tt.tags)
+			if !slices.Equal(rest, tt.wantRest) {
+				t.Errorf("remaining tags = %q, want %q", rest, tt.wantRest)
+			}
+			if !sameCompliance(got, tt.want) {
+				t.Errorf("attributes = %s, want %s", describeCompliance(got), describeCompliance(tt.want))
+			}
+		})
+	}
+}
+
+func TestMigrateComplianceTags(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	for _, p := range []models.Product{
+		{ID: "fold", VendorID: "v1", Name: "Gelato", ComplianceTags: []string{"THC: 25%", "Indoor"}},
+		{ID: "plain", VendorID: "v1", Name: "Pre-roll", ComplianceTags: []string{"Indoor"}},
+		{ID: "invalid", VendorID: "v1", Name: "Shatter", ComplianceTags: []string{"THC: 250%"}},
+	} {
+		if err := store.Products.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	migrated, err := MigrateComplianceTags(ctx, store.Products, NewAuditLog(store.Audit))
+	if err != nil || migrated != 1 {
+		t.Fatalf("MigrateComplianceTags = %d, %v; want 1, nil", migrated, err)
+	}
+	folded, _ := store.Products.Get(ctx, "fold")
+	if folded.Compliance.THCPercent == nil || *folded.Compliance.THCPercent != 25 || !slices.Equal(folded.ComplianceTags, []string{"Indoor"}) {
+		t.Errorf("folded product = %s %q", describeCompliance(folded.Compliance), folded.ComplianceTags)
+	}
+	invalid, _ := store.Products.Get(ctx, "invalid")
+	if invalid.Compliance.THCPercent != nil || !slices.Equal(invalid.ComplianceTags, []string{"THC: 250%"}) {
+		t.Errorf("invalid product = %s %q, want it left alone", describeCompliance(invalid.Compliance), invalid.ComplianceTags)
+	}
+
+	if migrated, err := MigrateComplianceTags(ctx, store.Products, NewAuditLog(store.Audit)); err != nil || migrated != 0 {
+		t.Errorf("second run = %d, %v; want 0, nil", migrated, err)
+	}
+}
+
+func sameCompliance(a, b models.ComplianceAttributes) bool {
+	samePct := func(x, y *float64) bool { return (x == nil && y == nil) || (x != nil && y != nil && *x == *y) }
+	return samePct(a.THCPercent, b.THCPercent) && samePct(a.CBDPercent, b.CBDPercent) &&
+		a.StrainType == b.StrainType && a.BatchNumber == b.BatchNumber && a.LabID == b.LabID && a.TestStatus == b.TestStatus &&
+		a.HarvestDate.Equal(b.HarvestDate) && a.PackageDate.Equal(b.PackageDate)
+}
+
+func describeCompliance(c models.ComplianceAttributes) string {
+	pct := func(v *float64) any {
+		if v == nil {
+			return nil
+		}
+		return *v
+	}
+	return fmt.Sprintf("{THC %v CBD %v strain %q batch %q lab %q test %q harvest %s package %s}",
+		pct(c.THCPercent), pct(c.CBDPercent), c.StrainType, c.BatchNumber, c.LabID, c.TestStatus,
+		c.HarvestDate.Format("2006-01-02"), c.PackageDate.Format("2006-01-02"))
+}
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+const (
+	DefaultDashboardDays     = 90
+	DefaultTopProducts       = 10
+	DefaultLowStockThreshold = 10
+)
+
+var ErrInvalidDashboardQuery = errors.New("invalid dashboard query")
+
+// openOrderStatuses are the statuses an order passes through before it is done.
+var openOrderStatuses = []models.OrderStatus{
+	models.OrderStatusPending,
+	models.OrderStatusAccepted,
+	models.OrderStatusProcessing,
+	models.OrderStatusShipped,
+}
+
+// DashboardQuery selects the reporting window and thresholds. From is inclusive
+// and To exclusive; zero values fall back to the last DefaultDashboardDays days.
+type DashboardQuery struct {
+	From              time.Time
+	To                time.Time
+	TopProducts       int
+	LowStockThreshold int
+}
+
+// VendorDashboard is a vendor's sales and catalog summary. Sales figures cover
+// orders placed in [From, To) that the vendor accepted and hasn't cancelled;
+// open orders, stock and the license countdown are as of GeneratedAt.
+type VendorDashboard struct {
+	VendorID    string                     `json:"vendorID"`
+	GeneratedAt time.Time                  `json:"generatedAt"`
+	From        time.Time                  `json:"from"`
+	To          time.Time                  `json:"to"`
+	Totals      SalesTotals                `json:"totals"`
+	Sales       SalesSeries                `json:"sales"`
+	TopProducts []ProductSales             `json:"topProducts"`
+	OpenOrders  map[models.OrderStatus]int `json:"openOrders"`
+	LowStock    []StockAlert               `json:"lowStock"`
+	License     LicenseCountdown           `json:"license"`
+}
+
+// SalesTotals sums a set of orders. NetRevenue is what the vendor keeps:
+// TotalPrice less the excise and sales tax collected on the state's behalf.
+type SalesTotals struct {
+	Orders     int          `json:"orders"`
+	Units      int          `json:"units"`
+	SubTotal   models.Money `json:"subTotal"`
+	ExciseTax  models.Money `json:"exciseTax"`
+	SalesTax   models.Money `json:"salesTax"`
+	Shipping   models.Money `json:"shipping"`
+	Gross      models.Money `json:"gross"`
+	NetRevenue models.Money `json:"netRevenue"`
+}
+
+func (t *SalesTotals) add(order models.Order) {
+	t.Orders++
+	for _, item := range order.Items {
+		t.Units += item.Quantity
+	}
+	t.SubTotal = t.SubTotal.Add(order.SubTotal)
+	t.ExciseTax = t.ExciseTax.Add(order.ExciseTax)
+	t.SalesTax = t.SalesTax.Add(order.SalesTax)
+	t.Shipping = t.Shipping.Add(order.ShippingCost)
+	t.Gross = t.Gross.Add(order.TotalPrice)
+	t.NetRevenue = t.Gross.Sub(t.ExciseTax).Sub(t.SalesTax)
+}
+
+// SalesBucket is the sales for one day, ISO week or month, i.e. "2025-03-14",
+// "2025-W11" or "2025-03".
+type SalesBucket struct {
+	Period string    `json:"period"`
+	Start  time.Time `json:"start"`
+	SalesTotals
+}
+
+// SalesSeries is sales over time at three granularities. Periods without sales
+// are included with zero totals so charts have no gaps.
+type SalesSeries struct {
+	Daily   []SalesBucket `json:"daily"`
+	Weekly  []SalesBucket `json:"weekly"`
+	Monthly []SalesBucket `json:"monthly"`
+}
+
+// ProductSales is one product's share of the vendor's sales.
+type ProductSales struct {
+	ProductID string       `json:"productID"`
+	Name      string       `json:"name"` // "" if the product has since been deleted
+	Units     int          `json:"units"`
+	Revenue   models.Money `json:"revenue"` // line totals, before tax
+}
+
+// StockAlert is a product running low.
+type StockAlert struct {
+	ProductID        string `json:"productID"`
+	Name             string `json:"name"`
+	AvailableUnits   int    `json:"availableUnits"`
+	MinOrderQuantity int    `json:"minOrderQuantity"`
+}
+
+// LicenseCountdown is how long the vendor has until its license must be renewed.
+type LicenseCountdown struct {
+	LicenseID        string                         `json:"licenseID"`
+	ExpiresAt        time.Time                      `json:"expiresAt"`
+	DaysRemaining    int                            `json:"daysRemaining"` // negative once expired
+	ComplianceStatus models.AccountComplianceStatus `json:"complianceStatus"`
+}
+
+// DashboardService builds vendor dashboards from orders and the catalog.
+type DashboardService struct {
+	vendors  repository.VendorRepository
+	products repository.ProductRepository
+	orders   repository.OrderRepository
+	location *time.Location // days, weeks and months are bucketed in this timezone
+}
+
+// NewDashboardService returns a DashboardService that reports in loc.
+func NewDashboardService(store *repository.Store, loc *time.Location) *DashboardService {
+	return &DashboardService{
+		vendors:  store.Vendors,
+		products: store.Products,
+		orders:   store.Orders,
+		location: loc,
+	}
+}
+
+// VendorDashboard builds the dashboard for the vendor the user belongs to.
+func (s *DashboardService) VendorDashboard(ctx context.Context, user *models.User, q DashboardQuery) (*VendorDashboard, error) {
+	vendor, err := s.vendors.Get(ctx, user.AssociatedEntityID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: register your business to see its dashboard", ErrInvalidDashboardQuery)
+	} else if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(s.location)
+	if q.To.IsZero() {
+		q.To = now
+	}
+	if q.From.IsZero() {
+		q.From = startOfDay(q.To.AddDate(0, 0, -DefaultDashboardDays+1))
+	}
+	if !q.From.Before(q.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidDashboardQuery)
+	}
+	if q.To.Sub(q.From) > 3*366*24*time.Hour {
+		return nil, fmt.Errorf("%w: the reporting window is limited to three years", ErrInvalidDashboardQuery)
+	}
+	if q.TopProducts <= 0 {
+		q.TopProducts = DefaultTopProducts
+	}
+	if q.LowStockThreshold <= 0 {
+		q.LowStockThreshold = DefaultLowStockThreshold
+	}
+
+	orders, err := s.orders.ListByVendor(ctx, vendor.ID)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := s.products.ListByVendor(ctx, vendor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard := &VendorDashboard{
+		VendorID:    vendor.ID,
+		GeneratedAt: now,
+		From:        q.From.In(s.location),
+		To:          q.To.In(s.location),
+		OpenOrders:  make(map[models.OrderStatus]int, len(openOrderStatuses)),
+		TopProducts: []ProductSales{},
+		LowStock:    []StockAlert{},
+		License:     licenseCountdown(*vendor, now),
+	}
+	for _, status := range openOrderStatuses {
+		dashboard.OpenOrders[status] = 0
+	}
+
+	// --- STEP 1: Sales, bucketed by when each order was placed ---
+	daily := newBuckets(dashboard.From, dashboard.To, func(t time.Time) time.Time { return startOfDay(t) },
+		func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }, func(t time.Time) string { return t.Format("2006-01-02") })
+	weekly := newBuckets(dashboard.From, dashboard.To, startOfWeek,
+		func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		})
+	monthly := newBuckets(dashboard.From, dashboard.To, startOfMonth,
+		func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }, func(t time.Time) string { return t.Format("2006-01") })
+
+	byProduct := make(map[string]*ProductSales)
+	for _, order := range orders {
+		if isOpenOrder(order.Status) {
+			dashboard.OpenOrders[order.Status]++
+		}
+		if !countsAsSale(order) {
+			continue
+		}
+		placed := placedAt(order).In(s.location)
+		if placed.Before(q.From) || !placed.Before(q.To) {
+			continue
+		}
+
+		dashboard.Totals.add(order)
+		daily.add(placed, order)
+		weekly.add(placed, order)
+		monthly.add(placed, order)
+		for _, item := range order.Items {
+			ps, ok := byProduct[item.ProductID]
+			if !ok {
+				ps = &ProductSales{ProductID: item.ProductID}
+				byProduct[item.ProductID] = ps
+			}
+			ps.Units += item.Quantity
+			ps.Revenue = ps.Revenue.Add(item.LineTotal)
+		}
+	}
+	dashboard.Sales = SalesSeries{Daily: daily.list, Weekly: weekly.list, Monthly: monthly.list}
+
+	// --- STEP 2: Top products by revenue, then units ---
+	names := make(map[string]string, len(catalog))
+	for _, p := range catalog {
+		names[p.ID] = p.Name
+	}
+	for _, ps := range byProduct {
+		ps.Name = names[ps.ProductID]
+		dashboard.TopProducts = append(dashboard.TopProducts, *ps)
+	}
+	sort.Slice(dashboard.TopProducts, func(i, j int) bool {
+		a, b := dashboard.TopProducts[i], dashboard.TopProducts[j]
+		if c := a.Revenue.Cmp(b.Revenue); c != 0 {
+			return c > 0
+		}
+		if a.Units != b.Units {
+			return a.Units > b.Units
+		}
+		return a.ProductID < b.ProductID
+	})
+	if len(dashboard.TopProducts) > q.TopProducts {
+		dashboard.TopProducts = dashboard.TopProducts[:q.TopProducts]
+	}
+
+	// --- STEP 3: Low stock; a SKU that can't fill its minimum order is always low ---
+	for _, p := range catalog {
+		if p.AvailableUnits < max(q.LowStockThreshold, p.MinOrderQuantity) {
+			dashboard.LowStock = append(dashboard.LowStock, StockAlert{
+				ProductID:        p.ID,
+				Name:             p.Name,
+				AvailableUnits:   p.AvailableUnits,
+				MinOrderQuantity: p.MinOrderQuantity,
+			})
+		}
+	}
+	sort.Slice(dashboard.LowStock, func(i, j int) bool {
+		a, b := dashboard.LowStock[i], dashboard.LowStock[j]
+		if a.AvailableUnits != b.AvailableUnits {
+			return a.AvailableUnits < b.AvailableUnits
+		}
+		return a.ProductID < b.ProductID
+	})
+	return dashboard, nil
+}
+
+func isOpenOrder(status models.OrderStatus) bool {
+	for _, s := range openOrderStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// countsAsSale reports whether an order is revenue: the vendor has accepted it and
+// it hasn't been cancelled.
+func countsAsSale(order models.Order) bool {
+	return order.Status != models.OrderStatusPending && order.Status != models.OrderStatusCancelled
+}
+
+// placedAt is when the order was placed: its first history entry, falling back
+// to the timeline for orders written before History existed.
+func placedAt(order models.Order) time.Time {
+	if len(order.History) > 0 {
+		return order.History[0].At
+	}
+	t, _ := time.Parse(time.RFC3339, order.OrderStatusTimeline.PlacedAt)
+	return t
+}
+
+func licenseCountdown(vendor models.Vendor, now time.Time) LicenseCountdown {
+	days := int(math.Floor(vendor.LicenseExpirationDate.Sub(now).Hours() / 24))
+	return LicenseCountdown{
+		LicenseID:        vendor.OKStateLicenseID,
+		ExpiresAt:        vendor.LicenseExpirationDate,
+		DaysRemaining:    days,
+		ComplianceStatus: vendor.ComplianceStatus,
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// startOfWeek returns the Monday starting t's ISO week.
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return startOfDay(t).AddDate(0, 0, -offset)
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// buckets is a run of consecutive periods covering a reporting window.
+type buckets struct {
+	list  []SalesBucket
+	start func(time.Time) time.Time
+}
+
+func newBuckets(from, to time.Time, start, next func(time.Time) time.Time, label func(time.Time) string) *buckets {
+	b := &buckets{list: []SalesBucket{}, start: start}
+	for t := start(from); t.Before(to); t = next(t) {
+		b.list = append(b.list, SalesBucket{Period: label(t), Start: t})
+	}
+	return b
+}
+
+func (b *buckets) add(at time.Time, order models.Order) {
+	start := b.start(at)
+	i := sort.Search(len(b.list), func(i int) bool { return !b.list[i].Start.Before(start) })
+	if i < len(b.list) && b.list[i].Start.Equal(start) {
+		b.list[i].add(order)
+	}
+}
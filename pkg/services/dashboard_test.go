@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+func TestVendorDashboard(t *testing.T) {
+	ctx := context.Background()
+	cst := time.FixedZone("CST", -6*60*60)
+	store := repository.NewMemoryStore()
+	if err := store.Vendors.Create(ctx, models.Vendor{
+		ID: "vendor_1", OKStateLicenseID: "GAAA-4K7M-2Q9X-8B3N", ComplianceStatus: models.ComplianceVerified,
+		LicenseExpirationDate: time.Now().Add(30*24*time.Hour + time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []models.Product{
+		{ID: "p1", VendorID: "vendor_1", Name: "Gelato 3.5g", AvailableUnits: 50, MinOrderQuantity: 1},
+		{ID: "p2", VendorID: "vendor_1", Name: "Blue Dream 1oz", AvailableUnits: 8, MinOrderQuantity: 1},
+		{ID: "p4", VendorID: "vendor_1", Name: "Pre-roll case", AvailableUnits: 20, MinOrderQuantity: 25},
+	} {
+		if err := store.Products.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	placed := func(at time.Time) []models.OrderStatusChange {
+		return []models.OrderStatusChange{{To: models.OrderStatusPending, At: at}}
+	}
+	item := func(productID string, quantity int, lineTotal int64) models.OrderItem {
+		return models.OrderItem{ProductID: productID, Quantity: quantity, LineTotal: models.USDCents(lineTotal)}
+	}
+	for _, o := range []models.Order{
+		{
+			ID: "o1", VendorID: "vendor_1", Status: models.OrderStatusAccepted, History: placed(time.Date(2025, 3, 3, 10, 0, 0, 0, cst)),
+			Items:    []models.OrderItem{item("p1", 2, 2000)},
+			SubTotal: models.USDCents(2000), ExciseTax: models.USDCents(140), SalesTax: models.USDCents(90), ShippingCost: models.USDCents(500), TotalPrice: models.USDCents(2730),
+		},
+		{
+			// Already the 11th in UTC, but the dashboard reports in CST.
+			ID: "o2", VendorID: "vendor_1", Status: models.OrderStatusDelivered, History: placed(time.Date(2025, 3, 11, 5, 30, 0, 0, time.UTC)),
+			Items:    []models.OrderItem{item("p2", 5, 5000), item("p1", 1, 1000)},
+			SubTotal: models.USDCents(6000), ExciseTax: models.USDCents(420), SalesTax: models.USDCents(270), TotalPrice: models.USDCents(6690),
+		},
+		{ID: "o3", VendorID: "vendor_1", Status: models.OrderStatusPending, History: placed(time.Date(2025, 3, 5, 9, 0, 0, 0, cst)), Items: []models.OrderItem{item("p1", 1, 1000)}, TotalPrice: models.USDCents(1000)},
+		{ID: "o4", VendorID: "vendor_1", Status: models.OrderStatusCancelled, History: placed(time.Date(2025, 3, 6, 9, 0, 0, 0, cst)), Items: []models.OrderItem{item("p1", 1, 1000)}, TotalPrice: models.USDCents(1000)},
+		{ID: "o5", VendorID: "vendor_1", Status: models.OrderStatusAccepted, History: placed(time.Date(2025, 2, 28, 9, 0, 0, 0, cst)), Items: []models.OrderItem{item("p1", 1, 1000)}, TotalPrice: models.USDCents(1000)},
+		{
+			// Written before History existed, for a product that has since been deleted.
+			ID: "o6", VendorID: "vendor_1", Status: models.OrderStatusCompleted, OrderStatusTimeline: models.Timeline{PlacedAt: "2025-03-12T15:00:00Z"},
+			Items: []models.OrderItem{item("p3", 1, 1000)}, SubTotal: models.USDCents(1000), TotalPrice: models.USDCents(1000),
+		},
+		{ID: "other", VendorID: "vendor_2", Status: models.OrderStatusAccepted, History: placed(time.Date(2025, 3, 4, 9, 0, 0, 0, cst)), TotalPrice: models.USDCents(9999)},
+	} {
+		if err := store.Orders.Create(ctx, o); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dashboards := NewDashboardService(store, cst)
+	vendor := &models.User{ID: "user_v", Role: models.RoleVendor, AssociatedEntityID: "vendor_1"}
+	got, err := dashboards.VendorDashboard(ctx, vendor, DashboardQuery{
+		From:        time.Date(2025, 3, 1, 0, 0, 0, 0, cst),
+		To:          time.Date(2025, 3, 15, 0, 0, 0, 0, cst),
+		TopProducts: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	totals := got.Totals
+	if totals.Orders != 3 || totals.Units != 9 || !totals.SubTotal.Equal(models.USDCents(9000)) || !totals.Gross.Equal(models.USDCents(10420)) ||
+		!totals.ExciseTax.Equal(models.USDCents(560)) || !totals.SalesTax.Equal(models.USDCents(360)) || !totals.Shipping.Equal(models.USDCents(500)) ||
+		!totals.NetRevenue.Equal(models.USDCents(9500)) {
+		t.Errorf("totals = %+v", totals)
+	}
+
+	if len(got.Sales.Daily) != 14 {
+		t.Fatalf("%d daily buckets, want 14", len(got.Sales.Daily))
+	}
+	for _, b := range got.Sales.Daily {
+		want := map[string]int{"2025-03-03": 1, "2025-03-10": 1, "2025-03-12": 1}[b.Period]
+		if b.Orders != want {
+			t.Errorf("daily %s has %d orders, want %d", b.Period, b.Orders, want)
+		}
+	}
+	wantWeeks := []struct {
+		period string
+		orders int
+		gross  int64
+	}{{"2025-W09", 0, 0}, {"2025-W10", 1, 2730}, {"2025-W11", 2, 7690}}
+	if len(got.Sales.Weekly) != len(wantWeeks) {
+		t.Fatalf("weekly buckets = %+v", got.Sales.Weekly)
+	}
+	for i, want := range wantWeeks {
+		b := got.Sales.Weekly[i]
+		if b.Period != want.period || b.Orders != want.orders || !b.Gross.Equal(models.USDCents(want.gross)) || b.Start.Weekday() != time.Monday {
+			t.Errorf("weekly[%d] = %s %s: %d orders, %s gross; want %s: %d orders, %d cents", i, b.Period, b.Start, b.Orders, b.Gross, want.period, want.orders, want.gross)
+		}
+	}
+	if len(got.Sales.Monthly) != 1 || got.Sales.Monthly[0].Period != "2025-03" || got.Sales.Monthly[0].Orders != 3 {
+		t.Errorf("monthly buckets = %+v", got.Sales.Monthly)
+	}
+
+	if len(got.TopProducts) != 2 || got.TopProducts[0].ProductID != "p2" || got.TopProducts[1].ProductID != "p1" ||
+		got.TopProducts[1].Units != 3 || !got.TopProducts[1].Revenue.Equal(models.USDCents(3000)) || got.TopProducts[1].Name != "Gelato 3.5g" {
+		t.Errorf("top products = %+v", got.TopProducts)
+	}
+	wantOpen := map[models.OrderStatus]int{models.OrderStatusPending: 1, models.OrderStatusAccepted: 2, models.OrderStatusProcessing: 0, models.OrderStatusShipped: 0}
+	for status, want := range wantOpen {
+		if got.OpenOrders[status] != want {
+			t.Errorf("open %s orders = %d, want %d", status, got.OpenOrders[status], want)
+		}
+	}
+	if len(got.LowStock) != 2 || got.LowStock[0].ProductID != "p2" || got.LowStock[1].ProductID != "p4" {
+		t.Errorf("low stock = %+v, want p2 then p4", got.LowStock)
+	}
+	if got.License.DaysRemaining != 30 || got.License.LicenseID != "GAAA-4K7M-2Q9X-8B3N" {
+		t.Errorf("license = %+v, want 30 days remaining", got.License)
+	}
+}
+
+func TestVendorDashboardInvalidQuery(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	if err := store.Vendors.Create(ctx, models.Vendor{ID: "vendor_1"}); err != nil {
+		t.Fatal(err)
+	}
+	dashboards := NewDashboardService(store, time.UTC)
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		entity string
+		q      DashboardQuery
+	}{
+		{name: "unregistered vendor", entity: ""},
+		{name: "from after to", entity: "vendor_1", q: DashboardQuery{From: now, To: now.Add(-time.Hour)}},
+		{name: "empty window", entity: "vendor_1", q: DashboardQuery{From: now, To: now}},
+		{name: "more than three years", entity: "vendor_1", q: DashboardQuery{From: now.AddDate(-4, 0, 0), To: now}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.User{ID: "user_v", Role: models.RoleVendor, AssociatedEntityID: tt.entity}
+			if _, err := dashboards.VendorDashboard(ctx, user, tt.q); !errors.Is(err, ErrInvalidDashboardQuery) {
+				t.Errorf("err = %v, want ErrInvalidDashboardQuery", err)
+			}
+		})
+	}
+}
+
+func TestStartOfWeek(t *testing.T) {
+	tests := []struct {
+		day  time.Time
+		want time.Time
+	}{
+		{time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)}, // Monday
+		{time.Date(2025, 3, 16, 23, 59, 0, 0, time.UTC), time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC), time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC)}, // ISO week 2025-W01
+	}
+	for _, tt := range tests {
+		if got := startOfWeek(tt.day); !got.Equal(tt.want) {
+			t.Errorf("startOfWeek(%s) = %s, want %s", tt.day, got, tt.want)
+		}
+	}
+}
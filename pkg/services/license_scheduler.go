@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+// recheckQueueSize bounds how many on-demand re-checks can be waiting at once.
+const recheckQueueSize = 256
+
+// LicenseScheduler keeps our cached compliance in step with state records. It
+// sweeps every Vendor and Buyer on a fixed interval and also re-checks single
+// entities on demand, i.e. right after a transactional check fails.
+type LicenseScheduler struct {
+	vendors  repository.VendorRepository
+	buyers   repository.BuyerRepository
+	verifier LicenseVerifier
+	audit    *AuditLog
+	notify   *NotificationService
+	interval time.Duration
+
+	queue     chan string
+	mu        sync.Mutex
+	pending   map[string]bool                          // entity IDs already queued, so repeated failures don't flood the queue
+	listeners []func(models.AccountEntityType, string) // called after a re-check changes a business's license status
+}
+
+// NewLicenseScheduler returns a scheduler that sweeps all entities every interval once
+// Run is called. Every verification result is recorded to audit, and businesses
+// whose compliance status changes are told through notify.
+func NewLicenseScheduler(vendors repository.VendorRepository, buyers repository.BuyerRepository, verifier LicenseVerifier, audit *AuditLog, notify *NotificationService, interval time.Duration) *LicenseScheduler {
+	return &LicenseScheduler{
+		vendors:  vendors,
+		buyers:   buyers,
+		verifier: verifier,
+		audit:    audit,
+		notify:   notify,
+		interval: interval,
+		queue:    make(chan string, recheckQueueSize),
+		pending:  make(map[string]bool),
+	}
+}
+
+// Run sweeps immediately, then on every tick, and services Enqueue requests in
+// between. It blocks until ctx is cancelled.
+func (s *LicenseScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.Sweep(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Sweep(ctx)
+		case entityID := <-s.queue:
+			s.mu.Lock()
+			delete(s.pending, entityID)
+			s.mu.Unlock()
+
+			if err := s.Recheck(ctx, entityID); err != nil {
+				log.Printf("License re-check failed for entity %s: %v", entityID, err)
+			}
+		}
+	}
+}
+
+// Enqueue asks for entityID to be re-verified as soon as possible. It never blocks;
+// if the queue is full the request is dropped and the next sweep will catch it.
+func (s *LicenseScheduler) Enqueue(entityID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending[entityID] {
+		return
+	}
+	select {
+	case s.queue <- entityID:
+		s.pending[entityID] = true
+	default:
+		log.Printf("License re-check queue full; dropping entity %s until the next sweep", entityID)
+	}
+}
+
+// Sweep re-verifies every Vendor and Buyer. Failures on one entity are logged and
+// do not stop the sweep.
+func (s *LicenseScheduler) Sweep(ctx context.Context) {
+	vendors, err := s.vendors.List(ctx)
+	if err != nil {
+		log.Printf("License sweep could not list vendors: %v", err)
+	}
+	for _, vendor := range vendors {
+		if err := s.recheckVendor(ctx, vendor); err != nil {
+			log.Printf("License sweep failed for vendor %s: %v", vendor.ID, err)
+		}
+	}
+
+	buyers, err := s.buyers.List(ctx)
+	if err != nil {
+		log.Printf("License sweep could not list buyers: %v", err)
+	}
+	for _, buyer := range buyers {
+		if err := s.recheckBuyer(ctx, buyer); err != nil {
+			log.Printf("License sweep failed for buyer %s: %v", buyer.ID, err)
+		}
+	}
+	log.Printf("License sweep complete: %d vendors, %d buyers", len(vendors), len(buyers))
+}
+
+// Recheck re-verifies a single Vendor or Buyer by ID.
+func (s *LicenseScheduler) Recheck(ctx context.Context, entityID string) error {
+	vendor, err := s.vendors.Get(ctx, entityID)
+	if err == nil {
+		return s.recheckVendor(ctx, *vendor)
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	buyer, err := s.buyers.Get(ctx, entityID)
+	if err != nil {
+		return err
+	}
+	return s.recheckBuyer(ctx, *buyer)
+}
+
+func (s *LicenseScheduler) recheckVendor(ctx context.Context, vendor models.Vendor) error {
+	expiry, status, err := s.verify(ctx, vendor.OKStateLicenseID, vendor.LicenseExpirationDate)
+	if err != nil {
+		return err
+	}
+	s.recordCheck(ctx, vendor.OKStateLicenseID,
+		LicenseCheckResult{EntityID: vendor.ID, ComplianceStatus: vendor.ComplianceStatus, ExpirationDate: vendor.LicenseExpirationDate},
+		LicenseCheckResult{EntityID: vendor.ID, ComplianceStatus: status, ExpirationDate: expiry})
+	if expiry.Equal(vendor.LicenseExpirationDate) && status == vendor.ComplianceStatus {
+		return nil
+	}
+
+	// Write only the license fields, so edits made while the state API answered survive.
+	var from models.AccountComplianceStatus
+	updated, err := s.vendors.Modify(ctx, vendor.ID, func(e *models.Vendor) error {
+		if e.OKStateLicenseID != vendor.OKStateLicenseID {
+			return errLicenseReplaced
+		}
+		from = e.ComplianceStatus
+		e.LicenseExpirationDate, e.ComplianceStatus = expiry, status
+		return nil
+	})
+	if errors.Is(err, errLicenseReplaced) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	log.Printf("Vendor %s compliance %s -> %s (license expires %s)", vendor.ID, from, status, expiry.Format("2006-01-02"))
+	s.statusChanged(models.AccountEntityVendor, vendor.ID)
+	s.notify.LicenseStatusChanged(ctx, *updated, from)
+	return nil
+}
+
+func (s *LicenseScheduler) recheckBuyer(ctx context.Context, buyer models.Buyer) error {
+	expiry, status, err := s.verify(ctx, buyer.OKStateLicenseID, buyer.LicenseExpirationDate)
+	if err != nil {
+		return err
+	}
+	s.recordCheck(ctx, buyer.OKStateLicenseID,
+		LicenseCheckResult{EntityID: buyer.ID, ComplianceStatus: buyer.ComplianceStatus, ExpirationDate: buyer.LicenseExpirationDate},
+		LicenseCheckResult{EntityID: buyer.ID, ComplianceStatus: status, ExpirationDate: expiry})
+	if expiry.Equal(buyer.LicenseExpirationDate) && status == buyer.ComplianceStatus {
+		return nil
+	}
+
+	// Write only the license fields, so edits made while the state API answered survive.
+	var from models.AccountComplianceStatus
+	updated, err := s.buyers.Modify(ctx, buyer.ID, func(e *models.Buyer) error {
+		if e.OKStateLicenseID != buyer.OKStateLicenseID {
+			return errLicenseReplaced
+		}
+		from = e.ComplianceStatus
+		e.LicenseExpirationDate, e.ComplianceStatus = expiry, status
+		return nil
+	})
+	if errors.Is(err, errLicenseReplaced) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	log.Printf("Buyer %s compliance %s -> %s (license expires %s)", buyer.ID, from, status, expiry.Format("2006-01-02"))
+	s.statusChanged(models.AccountEntityBuyer, buyer.ID)
+	s.notify.LicenseStatusChanged(ctx, *updated, from)
+	return nil
+}
+
+// OnStatusChange registers fn to be called with a business's type and ID after a
+// re-check writes a new license status for it, e.g. to drop cached checks.
+func (s *LicenseScheduler) OnStatusChange(fn func(entityType models.AccountEntityType, entityID string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+func (s *LicenseScheduler) statusChanged(entityType models.AccountEntityType, entityID string) {
+	s.mu.Lock()
+	listeners := s.listeners
+	s.mu.Unlock()
+	for _, fn := range listeners {
+		fn(entityType, entityID)
+	}
+}
+
+// errLicenseReplaced stops a re-check from writing its result to a business whose
+// license number changed while the state API was being asked about the old one.
+var errLicenseReplaced = errors.New("license number changed during re-check")
+
+// recordCheck audits one scheduled verification: the cached values before and the state's answer after.
+func (s *LicenseScheduler) recordCheck(ctx context.Context, licenseID string, before, after LicenseCheckResult) {
+	s.audit.Record(ctx, SystemEvent(models.AuditLicenseChecked, models.AuditEntityLicense, licenseID), before, after)
+}
+
+// verify maps the state's answer onto our cached fields. An active license is
+// VERIFIED and an expired one EXPIRED; a license the state doesn't recognise as
+// active goes back to PENDING for review. If the API itself is unreachable we
+// return the error and leave the cached values alone rather than downgrade
+// everyone during an outage.
+func (s *LicenseScheduler) verify(ctx context.Context, licenseID string, currentExpiry time.Time) (time.Time, models.AccountComplianceStatus, error) {
+	response, err := VerifyLicenseExternally(ctx, s.verifier, licenseID)
+	switch {
+	case err == nil:
+		return response.ExpirationDate, models.ComplianceVerified, nil
+	case errors.Is(err, ErrLicenseExpired):
+		return response.ExpirationDate, models.ComplianceExpired, nil
+	case errors.Is(err, ErrLicenseInactive):
+		if response != nil {
+			currentExpiry = response.ExpirationDate
+		}
+		return currentExpiry, models.CompliancePending, nil
+	default:
+		return time.Time{}, "", err
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/omma"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+// stubVerifier answers license lookups from a map; IDs it doesn't know are not found.
+type stubVerifier map[string]models.OMMAVerificationResponse
+
+var errStateDown = errors.New("state API unavailable")
+
+func (v stubVerifier) Verify(ctx context.Context, licenseID string) (*models.OMMAVerificationResponse, error) {
+	if licenseID == "DOWN" {
+		return nil, errStateDown
+	}
+	response, ok := v[licenseID]
+	if !ok {
+		return nil, omma.ErrLicenseNotFound
+	}
+	return &response, nil
+}
+
+func TestLicenseSchedulerSweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	lastYear, nextYear := now.AddDate(-1, 0, 0), now.AddDate(1, 0, 0)
+	verifier := stubVerifier{
+		"GAAA-0000-0000-0001": {IsActive: true, ExpirationDate: nextYear},
+		"GAAA-0000-0000-0002": {IsActive: true, ExpirationDate: lastYear},
+		"DAAA-0000-0000-0003": {IsActive: false, ExpirationDate: nextYear},
+	}
+
+	store := repository.NewMemoryStore()
+	vendors := []models.Vendor{
+		{ID: "renewed", OKStateLicenseID: "GAAA-0000-0000-0001", ComplianceStatus: models.CompliancePending, LicenseExpirationDate: lastYear},
+		{ID: "lapsed", OKStateLicenseID: "GAAA-0000-0000-0002", ComplianceStatus: models.ComplianceVerified, LicenseExpirationDate: lastYear},
+		{ID: "outage", OKStateLicenseID: "DOWN", ComplianceStatus: models.ComplianceVerified, LicenseExpirationDate: nextYear},
+	}
+	buyers := []models.Buyer{
+		{ID: "inactive", OKStateLicenseID: "DAAA-0000-0000-0003", ComplianceStatus: models.ComplianceVerified, LicenseExpirationDate: lastYear},
+		{ID: "unknown", OKStateLicenseID: "DAAA-9999-9999-9999", ComplianceStatus: models.ComplianceVerified, LicenseExpirationDate: nextYear},
+	}
+	for _, v := range vendors {
+		if err := store.Vendors.Create(ctx, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, b := range buyers {
+		if err := store.Buyers.Create(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scheduler := NewLicenseScheduler(store.Vendors, store.Buyers, verifier, NewAuditLog(store.Audit), nil, time.Hour)
+	scheduler.Sweep(ctx)
+
+	tests := []struct {
+		id         string
+		wantStatus models.AccountComplianceStatus
+		wantExpiry time.Time
+	}{
+		{"renewed", models.ComplianceVerified, nextYear},
+		{"lapsed", models.ComplianceExpired, lastYear},
+		{"outage", models.ComplianceVerified, nextYear}, // left alone while the state is unreachable
+		{"inactive", models.CompliancePending, nextYear},
+		{"unknown", models.CompliancePending, nextYear},
+	}
+	for _, tt := range tests {
+		var status models.AccountComplianceStatus
+		var expiry time.Time
+		if v, err := store.Vendors.Get(ctx, tt.id); err == nil {
+			status, expiry = v.ComplianceStatus, v.LicenseExpirationDate
+		} else {
+			b, err := store.Buyers.Get(ctx, tt.id)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.id, err)
+			}
+			status, expiry = b.ComplianceStatus, b.LicenseExpirationDate
+		}
+		if status != tt.wantStatus || !expiry.Equal(tt.wantExpiry) {
+			t.Errorf("%s: %s expiring %s, want %s expiring %s", tt.id, status, expiry.Format(time.DateOnly), tt.wantStatus, tt.wantExpiry.Format(time.DateOnly))
+		}
+	}
+
+	// Every answered check is audited; the outage isn't.
+	events, err := store.Audit.Query(ctx, repository.AuditFilter{Action: models.AuditLicenseChecked})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 {
+		t.Errorf("audited %d license checks, want 4", len(events))
+	}
+}
+
+// A re-check that changes a license drops the gate's cached result, so a
+// business that renewed can trade on its next request.
+func TestLicenseSchedulerOnStatusChange(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	nextYear := time.Now().UTC().Truncate(time.Second).AddDate(1, 0, 0)
+	if err := store.Buyers.Create(ctx, models.Buyer{
+		ID: "buyer_1", OKStateLicenseID: "DAAA-0000-0000-0001", ComplianceStatus: models.CompliancePending, LicenseExpirationDate: nextYear,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	verifier := stubVerifier{"DAAA-0000-0000-0001": {IsActive: true, ExpirationDate: nextYear}}
+	scheduler := NewLicenseScheduler(store.Vendors, store.Buyers, verifier, NewAuditLog(store.Audit), nil, time.Hour)
+	gate := NewComplianceGate(store, scheduler, time.Hour)
+
+	var changed []string
+	scheduler.OnStatusChange(func(entityType models.AccountEntityType, entityID string) {
+		changed = append(changed, string(entityType)+"/"+entityID)
+	})
+
+	var cerr *ComplianceError
+	if err := gate.Check(ctx, models.AccountEntityBuyer, "buyer_1"); !errors.As(err, &cerr) || cerr.Reason != ReasonLicenseNotVerified {
+		t.Fatalf("Check before the re-check = %v, want %s", err, ReasonLicenseNotVerified)
+	}
+	if err := scheduler.Recheck(ctx, "buyer_1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := gate.Check(ctx, models.AccountEntityBuyer, "buyer_1"); err != nil {
+		t.Errorf("Check after the re-check = %v, want a pass", err)
+	}
+	if len(changed) != 1 || changed[0] != "BUYER/buyer_1" {
+		t.Errorf("listener saw %v, want [BUYER/buyer_1]", changed)
+	}
+
+	// An unchanged re-check tells nobody.
+	if err := scheduler.Recheck(ctx, "buyer_1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 {
+		t.Errorf("listener called %d times, want 1", len(changed))
+	}
+}
@@ -0,0 +1,483 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/utils"
+)
+
+var (
+	ErrMembershipNotAllowed = errors.New("membership change not allowed")
+	ErrInvalidMembership    = errors.New("invalid membership request")
+	ErrMemberNotFound       = errors.New("member not found")
+	ErrInvitationNotFound   = errors.New("invitation not found")
+)
+
+// businessRank orders business roles so "at least a manager" checks stay readable.
+var businessRank = map[models.BusinessRole]int{
+	models.BusinessRoleStaff:   1,
+	models.BusinessRoleManager: 2,
+	models.BusinessRoleOwner:   3,
+}
+
+// ValidBusinessRole reports whether role is one of the known business roles.
+func ValidBusinessRole(role models.BusinessRole) bool {
+	_, ok := businessRank[role]
+	return ok
+}
+
+// business is a Vendor or Buyer seen only through its member list.
+type business struct {
+	id      string
+	role    models.UserRole // the platform role its users hold: VENDOR or BUYER
+	members []models.Membership
+	save    func(ctx context.Context, members []models.Membership) error // writes only the member list
+	entity  models.AccountEntity
+}
+
+func (b *business) member(userID string) (int, *models.Membership) {
+	for i := range b.members {
+		if b.members[i].UserID == userID {
+			return i, &b.members[i]
+		}
+	}
+	return -1, nil
+}
+
+func (b *business) owners() int {
+	n := 0
+	for _, m := range b.members {
+		if m.Role == models.BusinessRoleOwner {
+			n++
+		}
+	}
+	return n
+}
+
+// MembershipService manages who belongs to each business and in what role. The
+// business document's Members list and each member's User.AssociatedEntityID are
+// always changed together so the two never disagree.
+type MembershipService struct {
+	users         repository.UserRepository
+	vendors       repository.VendorRepository
+	buyers        repository.BuyerRepository
+	invitations   repository.InvitationRepository
+	audit         *AuditLog
+	invitationTTL time.Duration
+
+	// mu serializes membership changes so two concurrent edits can't, for
+	// example, both demote one of the last two owners.
+	mu sync.Mutex
+}
+
+// NewMembershipService returns a MembershipService whose invitations expire after
+// invitationTTL. Accounts created by accepting an invitation are recorded to audit.
+func NewMembershipService(store *repository.Store, audit *AuditLog, invitationTTL time.Duration) *MembershipService {
+	return &MembershipService{
+		audit:         audit,
+		users:         store.Users,
+		vendors:       store.Vendors,
+		buyers:        store.Buyers,
+		invitations:   store.Invitations,
+		invitationTTL: invitationTTL,
+	}
+}
+
+// loadBusiness finds the Vendor or Buyer with the given ID.
+func (s *MembershipService) loadBusiness(ctx context.Context, entityID string) (*business, error) {
+	vendor, err := s.vendors.Get(ctx, entityID)
+	if err == nil {
+		return &business{
+			id: vendor.ID, role: models.RoleVendor, members: vendor.Members, entity: *vendor,
+			save: func(ctx context.Context, members []models.Membership) error {
+				_, err := s.vendors.Modify(ctx, vendor.ID, func(v *models.Vendor) error {
+					v.Members = members
+					return nil
+				})
+				return err
+			},
+		}, nil
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	buyer, err := s.buyers.Get(ctx, entityID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: business %s does not exist", ErrInvalidMembership, entityID)
+	} else if err != nil {
+		return nil, err
+	}
+	return &business{
+		id: buyer.ID, role: models.RoleBuyer, members: buyer.Members, entity: *buyer,
+		save: func(ctx context.Context, members []models.Membership) error {
+			_, err := s.buyers.Modify(ctx, buyer.ID, func(b *models.Buyer) error {
+				b.Members = members
+				return nil
+			})
+			return err
+		},
+	}, nil
+}
+
+// callerBusiness loads the actor's business and their membership of it, requiring
+// at least the given role.
+func (s *MembershipService) callerBusiness(ctx context.Context, actor *models.User, atLeast models.BusinessRole) (*business, *models.Membership, error) {
+	if actor.AssociatedEntityID == "" {
+		return nil, nil, fmt.Errorf("%w: your account is not linked to a business", ErrMembershipNotAllowed)
+	}
+	biz, err := s.loadBusiness(ctx, actor.AssociatedEntityID)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, self := biz.member(actor.ID)
+	if self == nil {
+		return nil, nil, fmt.Errorf("%w: you are not a member of this business", ErrMembershipNotAllowed)
+	}
+	if businessRank[self.Role] < businessRank[atLeast] {
+		return nil, nil, fmt.Errorf("%w: requires the %s role", ErrMembershipNotAllowed, atLeast)
+	}
+	return biz, self, nil
+}
+
+// Members lists everyone in the caller's business. Any member may see the list.
+func (s *MembershipService) Members(ctx context.Context, actor *models.User) ([]models.Membership, error) {
+	biz, _, err := s.callerBusiness(ctx, actor, models.BusinessRoleStaff)
+	if err != nil {
+		return nil, err
+	}
+	return biz.members, nil
+}
+
+// Attach makes an existing, unaffiliated user a member of entityID with the given
+// role, linking their account to the business. It is used by admin provisioning
+// and by invitation acceptance.
+func (s *MembershipService) Attach(ctx context.Context, user *models.User, entityID string, role models.BusinessRole) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attach(ctx, user, entityID, role)
+}
+
+func (s *MembershipService) attach(ctx context.Context, user *models.User, entityID string, role models.BusinessRole) error {
+	if !ValidBusinessRole(role) {
+		return fmt.Errorf("%w: unknown business role %q", ErrInvalidMembership, role)
+	}
+	if user.AssociatedEntityID != "" && user.AssociatedEntityID != entityID {
+		return fmt.Errorf("%w: %s already belongs to another business", ErrMembershipNotAllowed, user.Email)
+	}
+	biz, err := s.loadBusiness(ctx, entityID)
+	if err != nil {
+		return err
+	}
+	if user.Role != biz.role {
+		return fmt.Errorf("%w: a %s account cannot join a %s business", ErrMembershipNotAllowed, user.Role, biz.role)
+	}
+	if _, existing := biz.member(user.ID); existing != nil {
+		return fmt.Errorf("%w: %s is already a member", ErrMembershipNotAllowed, user.Email)
+	}
+
+	members := append(biz.members, models.Membership{
+		UserID:  user.ID,
+		Email:   user.Email,
+		Role:    role,
+		AddedAt: time.Now(),
+	})
+	if err := biz.save(ctx, members); err != nil {
+		return err
+	}
+
+	user.AssociatedEntityID = biz.id
+	user.AccountData = biz.entity
+	if err := s.users.Update(ctx, *user); err != nil {
+		// Undo the membership so the business doesn't list a user who isn't linked to it.
+		if rollbackErr := biz.save(ctx, biz.members); rollbackErr != nil {
+			log.Printf("Membership rollback failed for user %s in %s: %v", user.ID, biz.id, rollbackErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// ChangeRole sets another member's business role. Only owners may change roles,
+// and a business always keeps at least one owner.
+func (s *MembershipService) ChangeRole(ctx context.Context, actor *models.User, userID string, role models.BusinessRole) (*models.Membership, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !ValidBusinessRole(role) {
+		return nil, fmt.Errorf("%w: unknown business role %q", ErrInvalidMembership, role)
+	}
+	biz, _, err := s.callerBusiness(ctx, actor, models.BusinessRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	i, target := biz.member(userID)
+	if target == nil {
+		return nil, ErrMemberNotFound
+	}
+	if target.Role == models.BusinessRoleOwner && role != models.BusinessRoleOwner && biz.owners() == 1 {
+		return nil, fmt.Errorf("%w: a business needs at least one owner", ErrMembershipNotAllowed)
+	}
+
+	members := append([]models.Membership(nil), biz.members...)
+	members[i].Role = role
+	if err := biz.save(ctx, members); err != nil {
+		return nil, err
+	}
+	return &members[i], nil
+}
+
+// Remove revokes a member's access to the business and unlinks their account.
+// Owners may remove anyone, managers may remove staff, and anyone may remove
+// themselves, except the last owner.
+func (s *MembershipService) Remove(ctx context.Context, actor *models.User, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	biz, self, err := s.callerBusiness(ctx, actor, models.BusinessRoleStaff)
+	if err != nil {
+		return err
+	}
+	i, target := biz.member(userID)
+	if target == nil {
+		return ErrMemberNotFound
+	}
+	if userID != actor.ID {
+		switch {
+		case self.Role == models.BusinessRoleOwner:
+		case self.Role == models.BusinessRoleManager && target.Role == models.BusinessRoleStaff:
+		default:
+			return fmt.Errorf("%w: you cannot remove a %s", ErrMembershipNotAllowed, target.Role)
+		}
+	}
+	if target.Role == models.BusinessRoleOwner && biz.owners() == 1 {
+		return fmt.Errorf("%w: a business needs at least one owner", ErrMembershipNotAllowed)
+	}
+
+	members := append(append([]models.Membership(nil), biz.members[:i]...), biz.members[i+1:]...)
+	if err := biz.save(ctx, members); err != nil {
+		return err
+	}
+
+	// --- Unlink the user's account ---
+	// The member is already off the business, so a missing user is not an error.
+	user, err := s.users.Get(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if user.AssociatedEntityID == biz.id {
+		user.AssociatedEntityID = ""
+		user.AccountData = nil
+		if err := s.users.Update(ctx, *user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Invite creates an invitation for email to join the caller's business with the
+// given role, returning the invitation and the raw token to send to the invitee.
+// Owners may invite any role; managers may invite staff.
+func (s *MembershipService) Invite(ctx context.Context, actor *models.User, email string, role models.BusinessRole) (*models.Invitation, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" || !strings.Contains(email, "@") {
+		return nil, "", fmt.Errorf("%w: a valid email address is required", ErrInvalidMembership)
+	}
+	if !ValidBusinessRole(role) {
+		return nil, "", fmt.Errorf("%w: unknown business role %q", ErrInvalidMembership, role)
+	}
+	biz, self, err := s.callerBusiness(ctx, actor, models.BusinessRoleManager)
+	if err != nil {
+		return nil, "", err
+	}
+	if self.Role != models.BusinessRoleOwner && role != models.BusinessRoleStaff {
+		return nil, "", fmt.Errorf("%w: only owners may invite a %s", ErrMembershipNotAllowed, role)
+	}
+	for _, m := range biz.members {
+		if strings.EqualFold(m.Email, email) {
+			return nil, "", fmt.Errorf("%w: %s is already a member", ErrMembershipNotAllowed, email)
+		}
+	}
+
+	return issueInvitation(ctx, s.invitations, models.Invitation{
+		EntityID:   biz.id,
+		EntityRole: biz.role,
+		Email:      email,
+		Role:       role,
+		InvitedBy:  actor.ID,
+	}, s.invitationTTL)
+}
+
+// issueInvitation fills in and stores inv with a fresh token, returning the raw
+// token. A new invitation replaces any still-pending one for the same address.
+func issueInvitation(ctx context.Context, invitations repository.InvitationRepository, inv models.Invitation, ttl time.Duration) (*models.Invitation, string, error) {
+	pending, err := invitations.ListByEntity(ctx, inv.EntityID)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, old := range pending {
+		if old.Status == models.InvitationPending && old.Email == inv.Email && old.EntityRole == inv.EntityRole {
+			old.Status = models.InvitationRevoked
+			if err := invitations.Update(ctx, old); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	token, err := newInvitationToken()
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	inv.ID = utils.NewID("invite")
+	inv.Status = models.InvitationPending
+	inv.TokenHash = hashInvitationToken(token)
+	inv.CreatedAt = now
+	inv.ExpiresAt = now.Add(ttl)
+	if err := invitations.Create(ctx, inv); err != nil {
+		return nil, "", err
+	}
+	return &inv, token, nil
+}
+
+// Invitations lists the caller's business invitations. Owners and managers only.
+func (s *MembershipService) Invitations(ctx context.Context, actor *models.User) ([]models.Invitation, error) {
+	biz, _, err := s.callerBusiness(ctx, actor, models.BusinessRoleManager)
+	if err != nil {
+		return nil, err
+	}
+	return s.invitations.ListByEntity(ctx, biz.id)
+}
+
+// RevokeInvitation withdraws a pending invitation from the caller's business.
+func (s *MembershipService) RevokeInvitation(ctx context.Context, actor *models.User, invitationID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	biz, _, err := s.callerBusiness(ctx, actor, models.BusinessRoleManager)
+	if err != nil {
+		return err
+	}
+	inv, err := s.invitations.Get(ctx, invitationID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && inv.EntityID != biz.id) {
+		return ErrInvitationNotFound
+	} else if err != nil {
+		return err
+	}
+	if inv.Status != models.InvitationPending {
+		return fmt.Errorf("%w: invitation is already %s", ErrMembershipNotAllowed, inv.Status)
+	}
+	inv.Status = models.InvitationRevoked
+	return s.invitations.Update(ctx, *inv)
+}
+
+// AcceptInvitation redeems an invitation token. If no account exists for the
+// invited email, one is created with password and fullName; otherwise password
+// must match the existing account, which must be ACTIVE and not belong to another
+// business. Admin invitations can only be redeemed by a new account. Invited
+// accounts are ACTIVE straight away: the business owner or admin who invited them
+// vouches for them. A new account is removed again if it can't join the business.
+func (s *MembershipService) AcceptInvitation(ctx context.Context, token, password string, fullName []string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inv, err := s.invitations.GetByTokenHash(ctx, hashInvitationToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvitationNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if inv.Status != models.InvitationPending {
+		return nil, fmt.Errorf("%w: invitation is %s", ErrMembershipNotAllowed, inv.Status)
+	}
+	if time.Now().After(inv.ExpiresAt) {
+		return nil, fmt.Errorf("%w: invitation has expired", ErrMembershipNotAllowed)
+	}
+
+	// --- STEP 1: Find or create the invitee's account ---
+	created := false
+	user, err := s.users.GetByEmail(ctx, inv.Email)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		hash, err := HashPassword(password)
+		if errors.Is(err, ErrPasswordTooShort) || errors.Is(err, ErrPasswordTooLong) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMembership, err)
+		} else if err != nil {
+			return nil, err
+		}
+		user = &models.User{
+			ID:           utils.NewID("user"),
+			FullName:     fullName,
+			Email:        inv.Email,
+			PasswordHash: hash,
+			Role:         inv.EntityRole,
+			Status:       models.StatusActive,
+			CreatedAt:    time.Now(),
+		}
+		if err := s.users.Create(ctx, *user); err != nil {
+			return nil, err
+		}
+		created = true
+	case err != nil:
+		return nil, err
+	case inv.EntityRole == models.RoleAdmin:
+		return nil, fmt.Errorf("%w: %s already has an account", ErrMembershipNotAllowed, inv.Email)
+	case user.Status != models.StatusActive:
+		// An invitation mustn't be a way back in for a suspended or rejected account.
+		return nil, fmt.Errorf("%w: the account for %s is %s", ErrMembershipNotAllowed, inv.Email, user.Status)
+	default:
+		if err := CheckPassword(user.PasswordHash, password); err != nil {
+			return nil, fmt.Errorf("%w: password does not match the existing account for %s", ErrMembershipNotAllowed, inv.Email)
+		}
+	}
+
+	// --- STEP 2: Join the business (admins have none) and close out the invitation ---
+	if inv.EntityRole != models.RoleAdmin {
+		if err := s.attach(ctx, user, inv.EntityID, inv.Role); err != nil {
+			if created {
+				if deleteErr := s.users.Delete(ctx, user.ID); deleteErr != nil {
+					log.Printf("Could not remove unattached user %s: %v", user.ID, deleteErr)
+				}
+			}
+			return nil, err
+		}
+	}
+	if created {
+		s.audit.Record(ctx, UserEvent(user, models.AuditUserCreated, models.AuditEntityUser, user.ID), nil, user)
+	}
+	inv.Status = models.InvitationAccepted
+	inv.AcceptedBy = user.ID
+	if err := s.invitations.Update(ctx, *inv); err != nil {
+		log.Printf("User %s joined %s but invitation %s could not be closed: %v", user.ID, inv.EntityID, inv.ID, err)
+	}
+	return user, nil
+}
+
+// newInvitationToken returns a random URL-safe token with 256 bits of entropy.
+func newInvitationToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
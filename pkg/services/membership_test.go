@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+func TestAcceptInvitation(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	if err := store.Vendors.Create(ctx, models.Vendor{ID: "vendor_1"}); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := HashPassword("existing-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Users.Create(ctx, models.User{ID: "user_s", Email: "suspended@example.com", PasswordHash: hash, Role: models.RoleVendor, Status: models.StatusSuspended}); err != nil {
+		t.Fatal(err)
+	}
+	memberships := NewMembershipService(store, nil, time.Hour)
+
+	tests := []struct {
+		name     string
+		entityID string
+		email    string
+		password string
+		wantErr  error
+	}{
+		{"new account joins", "vendor_1", "new@example.com", "new-password", nil},
+		{"suspended account is refused", "vendor_1", "suspended@example.com", "existing-password", ErrMembershipNotAllowed},
+		{"new account for a business that is gone is removed", "vendor_gone", "orphan@example.com", "new-password", ErrInvalidMembership},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, token, err := issueInvitation(ctx, store.Invitations, models.Invitation{
+				EntityID: tt.entityID, EntityRole: models.RoleVendor, Email: tt.email, Role: models.BusinessRoleStaff, InvitedBy: "user_o",
+			}, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			user, err := memberships.AcceptInvitation(ctx, token, tt.password, []string{"Pat", "Doe"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AcceptInvitation error = %v, want %v", err, tt.wantErr)
+			}
+
+			stored, getErr := store.Users.GetByEmail(ctx, tt.email)
+			switch {
+			case tt.wantErr == nil:
+				if user.Status != models.StatusActive || stored == nil || stored.AssociatedEntityID != "vendor_1" {
+					t.Errorf("accepted user = %+v, stored %+v", user, stored)
+				}
+				if v, _ := store.Vendors.Get(ctx, "vendor_1"); len(v.Members) != 1 || v.Members[0].UserID != user.ID {
+					t.Errorf("vendor members = %+v, want just the new user", v.Members)
+				}
+			case tt.email == "suspended@example.com":
+				if stored.AssociatedEntityID != "" || stored.Status != models.StatusSuspended {
+					t.Errorf("suspended user became %s in %q", stored.Status, stored.AssociatedEntityID)
+				}
+			default:
+				if !errors.Is(getErr, repository.ErrNotFound) {
+					t.Errorf("user created for the failed invitation is still stored: %+v, %v", stored, getErr)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/mail"
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/utils"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// DefaultLicenseReminderDays are the lead times, in days before a license
+// expires, at which its business is reminded to renew.
+var DefaultLicenseReminderDays = []int{60, 30, 7}
+
+// Recipient is one person a notification goes to. In-app delivery needs a
+// UserID and email needs an Email; a business's contact address may have no
+// account, and an account is always reachable in-app.
+type Recipient struct {
+	UserID string
+	Email  string
+}
+
+// NotificationChannel delivers a notification outside the app. Every notification
+// is also kept for its recipients' in-app inbox whatever channels are configured.
+type NotificationChannel interface {
+	Deliver(ctx context.Context, notification models.Notification, to []Recipient) error
+}
+
+// EmailChannel emails each recipient that has an address their own copy.
+type EmailChannel struct {
+	sender mail.Sender
+	from   string
+}
+
+// NewEmailChannel returns a channel that sends through sender as from.
+func NewEmailChannel(sender mail.Sender, from string) *EmailChannel {
+	return &EmailChannel{sender: sender, from: from}
+}
+
+func (c *EmailChannel) Deliver(ctx context.Context, notification models.Notification, to []Recipient) error {
+	var errs []error
+	for _, r := range to {
+		if r.Email == "" {
+			continue
+		}
+		msg := mail.Message{From: c.from, To: []string{r.Email}, Subject: notification.Subject, Body: notification.Body}
+		if err := c.sender.Send(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NotificationView is a notification as one recipient sees it.
+type NotificationView struct {
+	models.Notification
+	Read bool `json:"read"`
+}
+
+// NotificationService warns businesses before their licenses lapse, when they
+// do, and when a state re-check changes their compliance status. Reminders go to
+// the business's members and contact address; lapses and status changes also go
+// to every admin.
+type NotificationService struct {
+	notifications repository.NotificationRepository
+	users         repository.UserRepository
+	vendors       repository.VendorRepository
+	buyers        repository.BuyerRepository
+	channels      []NotificationChannel
+	leadDays      []int // ascending
+
+	// mu makes checking for an earlier reminder and recording this one atomic, so
+	// a sweep and a status change can't both send it.
+	mu sync.Mutex
+}
+
+// NewNotificationService returns a service that sends license reminders leadDays
+// before expiry, and delivers every notification through channels as well as in-app.
+func NewNotificationService(store *repository.Store, leadDays []int, channels ...NotificationChannel) *NotificationService {
+	days := slices.Clone(leadDays)
+	slices.Sort(days)
+	return &NotificationService{
+		notifications: store.Notifications,
+		users:         store.Users,
+		vendors:       store.Vendors,
+		buyers:        store.Buyers,
+		channels:      channels,
+		leadDays:      slices.Compact(days),
+	}
+}
+
+// licensee is the part of a Vendor or Buyer that license notifications need.
+type licensee struct {
+	entityType   models.AccountEntityType
+	id, name     string
+	licenseID    string
+	expiry       time.Time
+	status       models.AccountComplianceStatus
+	members      []models.Membership
+	contactEmail string
+}
+
+func licenseeOf(entity models.AccountEntity) (licensee, bool) {
+	switch e := entity.(type) {
+	case models.Vendor:
+		return licensee{models.AccountEntityVendor, e.ID, e.BusinessName, e.OKStateLicenseID, e.LicenseExpirationDate, e.ComplianceStatus, e.Members, e.ContactInfo.Email}, true
+	case models.Buyer:
+		return licensee{models.AccountEntityBuyer, e.ID, e.BusinessName, e.OKStateLicenseID, e.LicenseExpirationDate, e.ComplianceStatus, e.Members, e.ContactInfo.Email}, true
+	}
+	return licensee{}, false
+}
+
+// Run checks every license immediately, then on every tick, until ctx is cancelled.
+func (s *NotificationService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.CheckLicenses(ctx, time.Now()); err != nil {
+			log.Printf("License notification sweep failed: %v", err)
+		} else if n > 0 {
+			log.Printf("License notification sweep sent %d notifications", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckLicenses sends whichever expiry reminders and lapse notices are due as of
+// now and haven't been sent yet, and returns how many went out. A business only
+// gets the most urgent reminder due, so one registered a week before expiry isn't
+// sent the 60- and 30-day reminders too. Renewing moves the expiration date,
+// which starts the reminders over.
+func (s *NotificationService) CheckLicenses(ctx context.Context, now time.Time) (int, error) {
+	var entities []models.AccountEntity
+	vendors, err := s.vendors.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, vendor := range vendors {
+		entities = append(entities, vendor)
+	}
+	buyers, err := s.buyers.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, buyer := range buyers {
+		entities = append(entities, buyer)
+	}
+
+	sent := 0
+	for _, entity := range entities {
+		l, _ := licenseeOf(entity)
+		ok, err := s.remind(ctx, l, now)
+		if err != nil {
+			log.Printf("License notification failed for %s %s: %v", l.entityType, l.id, err)
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// LicenseStatusChanged tells a business, and the admins, that a re-check against
+// state records moved its compliance status away from from. Moving to EXPIRED is
+// sent as the lapse notice, so the sweep doesn't send that again.
+func (s *NotificationService) LicenseStatusChanged(ctx context.Context, entity models.AccountEntity, from models.AccountComplianceStatus) {
+	if s == nil {
+		return
+	}
+	l, ok := licenseeOf(entity)
+	if !ok || l.status == from {
+		return
+	}
+
+	var err error
+	if l.status == models.ComplianceExpired {
+		_, err = s.sendLapsed(ctx, l)
+	} else {
+		body := fmt.Sprintf("Our check of state records moved license %s from %s to %s.", l.licenseID, from, l.status)
+		if l.status == models.ComplianceVerified {
+			body += fmt.Sprintf(" %s can trade again.", l.name)
+		} else {
+			body += fmt.Sprintf(" The OMMA doesn't list it as active, so %s can't trade until it is verified again.", l.name)
+		}
+		_, err = s.send(ctx, l, models.NotificationLicenseStatusChange, "", true,
+			fmt.Sprintf("%s's license status changed to %s", l.name, l.status), body)
+	}
+	if err != nil {
+		log.Printf("License status notification failed for %s %s: %v", l.entityType, l.id, err)
+	}
+}
+
+// remind sends the lapse notice or expiry reminder due for l at now, if any.
+func (s *NotificationService) remind(ctx context.Context, l licensee, now time.Time) (bool, error) {
+	if l.expiry.IsZero() {
+		return false, nil
+	}
+	if !now.Before(l.expiry) {
+		return s.sendLapsed(ctx, l)
+	}
+	// Unverified licenses are already blocked; their status notice said why.
+	if l.status != models.ComplianceVerified {
+		return false, nil
+	}
+
+	days := int(math.Floor(l.expiry.Sub(now).Hours() / 24))
+	i := slices.IndexFunc(s.leadDays, func(lead int) bool { return days <= lead })
+	if i < 0 {
+		return false, nil
+	}
+	lead := s.leadDays[i]
+
+	body := fmt.Sprintf("License %s for %s expires on %s, in %d days. Renew it with the OMMA before then: once it lapses, %s can't place or accept orders",
+		l.licenseID, l.name, l.expiry.Format("2006-01-02"), days, l.name)
+	if l.entityType == models.AccountEntityVendor {
+		body += " and its menu is hidden from buyers"
+	}
+	body += ". We pick up renewals from state records automatically."
+	return s.send(ctx, l, models.NotificationLicenseExpiring, fmt.Sprintf("expiring/%d/%s", lead, l.expiry.Format("2006-01-02")), false,
+		fmt.Sprintf("%s's license expires in %d days", l.name, days), body)
+}
+
+func (s *NotificationService) sendLapsed(ctx context.Context, l licensee) (bool, error) {
+	body := fmt.Sprintf("License %s for %s expired on %s. Orders and catalog changes are blocked", l.licenseID, l.name, l.expiry.Format("2006-01-02"))
+	if l.entityType == models.AccountEntityVendor {
+		body += " and the menu is hidden from buyers"
+	}
+	body += " until a renewed license is verified with the OMMA."
+	return s.send(ctx, l, models.NotificationLicenseLapsed, "lapsed/"+l.expiry.Format("2006-01-02"), true,
+		fmt.Sprintf("%s's license has expired", l.name), body)
+}
+
+// send records a notification about l and delivers it. A non-empty key is sent
+// at most once per business; send reports false if it already was.
+func (s *NotificationService) send(ctx context.Context, l licensee, kind models.NotificationKind, key string, admins bool, subject, body string) (bool, error) {
+	s.mu.Lock()
+	if key != "" {
+		earlier, err := s.notifications.ListByEntity(ctx, l.id)
+		if err != nil {
+			s.mu.Unlock()
+			return false, err
+		}
+		if slices.ContainsFunc(earlier, func(n models.Notification) bool { return n.Key == key }) {
+			s.mu.Unlock()
+			return false, nil
+		}
+	}
+
+	to, err := s.recipients(ctx, l, admins)
+	if err != nil {
+		s.mu.Unlock()
+		return false, err
+	}
+	notification := models.Notification{
+		ID:         utils.NewID("notification"),
+		Kind:       kind,
+		EntityID:   l.id,
+		EntityType: l.entityType,
+		EntityName: l.name,
+		Subject:    subject,
+		Body:       body,
+		Key:        key,
+		CreatedAt:  time.Now(),
+	}
+	for _, r := range to {
+		if r.UserID != "" {
+			notification.Recipients = append(notification.Recipients, r.UserID)
+		}
+	}
+	err = s.notifications.Create(ctx, notification)
+	s.mu.Unlock()
+	if err != nil {
+		return false, err
+	}
+
+	// The in-app copy is saved; a channel failing doesn't unsend it.
+	for _, channel := range s.channels {
+		if err := channel.Deliver(ctx, notification, to); err != nil {
+			log.Printf("Notification %s delivery failed: %v", notification.ID, err)
+		}
+	}
+	log.Printf("Notification %s (%s) sent to %d recipients for %s %s", notification.ID, kind, len(to), l.entityType, l.id)
+	return true, nil
+}
+
+// recipients returns l's members and contact address, plus every active admin if
+// admins is set. Each email address appears once.
+func (s *NotificationService) recipients(ctx context.Context, l licensee, admins bool) ([]Recipient, error) {
+	var to []Recipient
+	seen := make(map[string]bool)
+	add := func(r Recipient) {
+		key := r.UserID
+		if key == "" {
+			key = r.Email
+		}
+		if key == "" || seen[key] || (r.Email != "" && seen[r.Email]) {
+			return
+		}
+		seen[key], seen[r.Email] = true, true
+		to = append(to, r)
+	}
+
+	for _, m := range l.members {
+		add(Recipient{UserID: m.UserID, Email: m.Email})
+	}
+	add(Recipient{Email: l.contactEmail})
+	if admins {
+		users, err := s.users.ListByStatus(ctx, models.StatusActive)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			if u.Role == models.RoleAdmin {
+				add(Recipient{UserID: u.ID, Email: u.Email})
+			}
+		}
+	}
+	return to, nil
+}
+
+// Inbox returns the user's notifications, newest first, optionally only the unread ones.
+func (s *NotificationService) Inbox(ctx context.Context, user *models.User, unreadOnly bool) ([]NotificationView, error) {
+	notifications, err := s.notifications.ListByRecipient(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	views := make([]NotificationView, 0, len(notifications))
+	for _, n := range notifications {
+		read := slices.Contains(n.ReadBy, user.ID)
+		if unreadOnly && read {
+			continue
+		}
+		views = append(views, NotificationView{Notification: n, Read: read})
+	}
+	slices.SortFunc(views, func(a, b NotificationView) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return views, nil
+}
+
+// MarkRead marks one of the user's notifications read.
+func (s *NotificationService) MarkRead(ctx context.Context, user *models.User, notificationID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	notification, err := s.notifications.Get(ctx, notificationID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrNotificationNotFound
+	} else if err != nil {
+		return err
+	}
+	if !slices.Contains(notification.Recipients, user.ID) {
+		return ErrNotificationNotFound
+	}
+	if slices.Contains(notification.ReadBy, user.ID) {
+		return nil
+	}
+	notification.ReadBy = append(notification.ReadBy, user.ID)
+	return s.notifications.Update(ctx, *notification)
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/mail"
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+// Each lead time is sent once per license expiry date, the lapse notice once,
+// and a renewed license starts the reminders over.
+func TestNotificationServiceCheckLicenses(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	expiry := time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)
+	if err := store.Vendors.Create(ctx, models.Vendor{
+		ID: "vendor_1", BusinessName: "Green Acres", OKStateLicenseID: "GAAA-4K7M-2Q9X-8B3N",
+		ComplianceStatus: models.ComplianceVerified, LicenseExpirationDate: expiry,
+		ContactInfo: models.ContactInfo{Email: "office@greenacres.example"},
+		Members:     []models.Membership{{UserID: "user_owner", Email: "owner@greenacres.example", Role: models.BusinessRoleOwner}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// Unverified licenses are already blocked, so they get no reminders.
+	if err := store.Buyers.Create(ctx, models.Buyer{
+		ID: "buyer_1", ComplianceStatus: models.CompliancePending, LicenseExpirationDate: expiry,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Users.Create(ctx, models.User{ID: "user_admin", Email: "admin@wds.example", Role: models.RoleAdmin, Status: models.StatusActive}); err != nil {
+		t.Fatal(err)
+	}
+	sender := mail.NewFakeSender()
+	notifications := NewNotificationService(store, []int{30, 60, 7, 30}, NewEmailChannel(sender, "noreply@wds.example"))
+
+	day := 24 * time.Hour
+	checks := []struct {
+		name     string
+		now      time.Time
+		wantSent int
+	}{
+		{"too early", expiry.Add(-90 * day), 0},
+		{"60 days out", expiry.Add(-59*day - 12*time.Hour), 1},
+		{"same day again", expiry.Add(-59 * day), 0},
+		{"still inside the 60-day window", expiry.Add(-45 * day), 0},
+		{"30 days out", expiry.Add(-29 * day), 1},
+		{"7 days out", expiry.Add(-3 * day), 1},
+		{"lapsed, for the vendor and the buyer", expiry.Add(time.Hour), 2},
+		{"lapsed is sent once", expiry.Add(2 * day), 0},
+	}
+	for _, check := range checks {
+		sent, err := notifications.CheckLicenses(ctx, check.now)
+		if err != nil {
+			t.Fatalf("%s: %v", check.name, err)
+		}
+		if sent != check.wantSent {
+			t.Errorf("%s: sent %d, want %d", check.name, sent, check.wantSent)
+		}
+	}
+
+	history, err := store.Notifications.ListByEntity(ctx, "vendor_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []models.NotificationKind
+	for _, n := range history {
+		kinds = append(kinds, n.Kind)
+		if n.Kind == models.NotificationLicenseLapsed && !slices.Contains(n.Recipients, "user_admin") {
+			t.Errorf("lapse notice went to %v, want the admins too", n.Recipients)
+		}
+		if n.Kind == models.NotificationLicenseExpiring && slices.Contains(n.Recipients, "user_admin") {
+			t.Errorf("reminder went to %v, want only the business", n.Recipients)
+		}
+	}
+	if len(kinds) != 4 {
+		t.Errorf("notifications = %v, want 3 reminders and a lapse notice", kinds)
+	}
+	if got, _ := store.Notifications.ListByEntity(ctx, "buyer_1"); len(got) != 1 || got[0].Kind != models.NotificationLicenseLapsed {
+		t.Errorf("unverified buyer got %d notifications, want only the lapse notice", len(got))
+	}
+	// Reminders email the owner and the contact address; lapse notices also the
+	// admin, who is all the buyer has.
+	if got := len(sender.Sent()); got != 3*2+3+1 {
+		t.Errorf("%d emails sent, want %d", got, 3*2+3+1)
+	}
+
+	// A renewal starts the reminders over for the new expiry date.
+	renewed := expiry.AddDate(1, 0, 0)
+	if _, err := store.Vendors.Modify(ctx, "vendor_1", func(v *models.Vendor) error {
+		v.LicenseExpirationDate = renewed
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if sent, err := notifications.CheckLicenses(ctx, renewed.Add(-50*day)); err != nil || sent != 1 {
+		t.Errorf("after renewal: sent %d, %v; want 1", sent, err)
+	}
+}
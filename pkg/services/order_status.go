@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+// SystemActorID is recorded as the actor for transitions the platform makes on its own.
+const SystemActorID = "system"
+
+var ErrIllegalTransition = errors.New("illegal order status transition")
+
+// orderTransitions lists, for each status, the statuses it may move to and which
+// roles may make that move. Vendors accept, process and ship; buyers confirm
+// delivery; either side may cancel up until the order ships.
+var orderTransitions = map[models.OrderStatus]map[models.OrderStatus][]models.UserRole{
+	models.OrderStatusPending: {
+		models.OrderStatusAccepted:  {models.RoleVendor},
+		models.OrderStatusCancelled: {models.RoleBuyer, models.RoleVendor},
+	},
+	models.OrderStatusAccepted: {
+		models.OrderStatusProcessing: {models.RoleVendor},
+		models.OrderStatusCancelled:  {models.RoleBuyer, models.RoleVendor},
+	},
+	models.OrderStatusProcessing: {
+		models.OrderStatusShipped:   {models.RoleVendor},
+		models.OrderStatusCancelled: {models.RoleBuyer, models.RoleVendor},
+	},
+	models.OrderStatusShipped: {
+		models.OrderStatusDelivered: {models.RoleBuyer},
+	},
+	models.OrderStatusDelivered: {
+		models.OrderStatusCompleted: {models.RoleBuyer, models.RoleVendor},
+	},
+}
+
+// CheckTransition reports whether role may move an order from one status to another.
+func CheckTransition(from, to models.OrderStatus, role models.UserRole) error {
+	next, ok := orderTransitions[from]
+	if !ok {
+		return fmt.Errorf("%w: %s is a final status", ErrIllegalTransition, from)
+	}
+	roles, ok := next[to]
+	if !ok {
+		return fmt.Errorf("%w: %s cannot move to %s", ErrIllegalTransition, from, to)
+	}
+	if !slices.Contains(roles, role) {
+		return fmt.Errorf("%w: a %s cannot move an order from %s to %s", ErrIllegalTransition, role, from, to)
+	}
+	return nil
+}
+
+// stamp sets the Timeline field matching status.
+func stamp(t *models.Timeline, status models.OrderStatus, at string) {
+	switch status {
+	case models.OrderStatusPending:
+		t.PlacedAt = at
+	case models.OrderStatusAccepted:
+		t.AcceptedAt = at
+	case models.OrderStatusProcessing:
+		t.ProcessingAt = at
+	case models.OrderStatusShipped:
+		t.ShippedAt = at
+	case models.OrderStatusDelivered:
+		t.DeliveredAt = at
+	case models.OrderStatusCancelled:
+		t.CancelledAt = at
+	case models.OrderStatusCompleted:
+		t.CompletedAt = at
+	}
+}
+
+// Transition moves one of the caller's orders to status `to` if the state machine
+// allows it for the caller's role. Only the order's buyer or vendor may move it;
+// everyone else gets ErrOrderNotFound.
+func (s *OrderService) Transition(ctx context.Context, user *models.User, orderID string, to models.OrderStatus, reason string) (*models.Order, error) {
+	order, err := s.orders.Get(ctx, orderID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrOrderNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	party := (user.Role == models.RoleBuyer && order.BuyerID == user.AssociatedEntityID) ||
+		(user.Role == models.RoleVendor && order.VendorID == user.AssociatedEntityID)
+	if !party {
+		return nil, ErrOrderNotFound
+	}
+	if err := CheckTransition(order.Status, to, user.Role); err != nil {
+		return nil, err
+	}
+	// Backing out and closing a delivered order are always allowed; every other
+	// step moves product and needs a compliant business.
+	if to != models.OrderStatusCancelled && to != models.OrderStatusCompleted {
+		if err := s.gate.CheckUser(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	change := models.OrderStatusChange{From: order.Status, To: to, ActorID: user.ID, ActorRole: user.Role, Reason: reason, At: time.Now()}
+	err = s.apply(ctx, order, change)
+	if errors.Is(err, repository.ErrConflict) {
+		return nil, fmt.Errorf("%w: order %s changed while it was being updated; reload it and try again", ErrIllegalTransition, order.ID)
+	} else if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// apply records change on order, stamps the timeline and persists it. Cancelling
+// hands the reserved stock back in the same write, which only succeeds if the
+// order is still in change.From, so a buyer cancelling while the expiry sweep
+// runs (possibly on another instance) can't release the stock twice. Callers
+// have already validated the transition.
+func (s *OrderService) apply(ctx context.Context, order *models.Order, change models.OrderStatusChange) error {
+	before := *order
+	at := change.At.Format(time.RFC3339)
+	order.Status = change.To
+	order.History = append(slices.Clip(order.History), change)
+	stamp(&order.OrderStatusTimeline, change.To, at)
+	order.UpdatedAt = at
+
+	var restock map[string]int
+	if change.To == models.OrderStatusCancelled {
+		restock = make(map[string]int, len(order.Items))
+		for _, item := range order.Items {
+			restock[item.ProductID] += item.Quantity
+		}
+	}
+	if err := s.orders.Transition(ctx, *order, change.From, restock); err != nil {
+		*order = before
+		return err
+	}
+
+	log.Printf("Order %s: %s -> %s by %s (%s)", order.ID, change.From, change.To, change.ActorID, change.ActorRole)
+	event := models.AuditEvent{ActorID: change.ActorID, ActorRole: change.ActorRole, Action: models.AuditOrderStatusChanged, EntityType: models.AuditEntityOrder, EntityID: order.ID}
+	s.audit.Record(ctx, event, before, order)
+	return nil
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		from, to models.OrderStatus
+		role     models.UserRole
+		ok       bool
+	}{
+		{models.OrderStatusPending, models.OrderStatusAccepted, models.RoleVendor, true},
+		{models.OrderStatusPending, models.OrderStatusAccepted, models.RoleBuyer, false},
+		{models.OrderStatusPending, models.OrderStatusCancelled, models.RoleBuyer, true},
+		{models.OrderStatusPending, models.OrderStatusShipped, models.RoleVendor, false},
+		{models.OrderStatusAccepted, models.OrderStatusProcessing, models.RoleVendor, true},
+		{models.OrderStatusProcessing, models.OrderStatusShipped, models.RoleVendor, true},
+		{models.OrderStatusProcessing, models.OrderStatusCancelled, models.RoleVendor, true},
+		{models.OrderStatusShipped, models.OrderStatusCancelled, models.RoleBuyer, false},
+		{models.OrderStatusShipped, models.OrderStatusDelivered, models.RoleBuyer, true},
+		{models.OrderStatusShipped, models.OrderStatusDelivered, models.RoleVendor, false},
+		{models.OrderStatusDelivered, models.OrderStatusCompleted, models.RoleVendor, true},
+		{models.OrderStatusCompleted, models.OrderStatusCancelled, models.RoleBuyer, false},
+		{models.OrderStatusCancelled, models.OrderStatusPending, models.RoleBuyer, false},
+		{models.OrderStatusPending, models.OrderStatusAccepted, models.RoleAdmin, false},
+	}
+	for _, tt := range tests {
+		err := CheckTransition(tt.from, tt.to, tt.role)
+		if tt.ok && err != nil {
+			t.Errorf("%s moving %s -> %s: %v", tt.role, tt.from, tt.to, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrIllegalTransition) {
+			t.Errorf("%s moving %s -> %s: error = %v, want %v", tt.role, tt.from, tt.to, err, ErrIllegalTransition)
+		}
+	}
+}
+
+func TestOrderServiceTransition(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	expires := time.Now().AddDate(1, 0, 0)
+	for _, v := range []models.Vendor{
+		{ID: "vendor_1", LicenseExpirationDate: expires, ComplianceStatus: models.ComplianceVerified},
+		{ID: "vendor_2", LicenseExpirationDate: expires, ComplianceStatus: models.ComplianceVerified},
+	} {
+		if err := store.Vendors.Create(ctx, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Buyers.Create(ctx, models.Buyer{ID: "buyer_1", LicenseExpirationDate: expires, ComplianceStatus: models.ComplianceVerified}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Products.Create(ctx, models.Product{ID: "product_1", VendorID: "vendor_1", AvailableUnits: 7}); err != nil {
+		t.Fatal(err)
+	}
+	// Stored directly as placed, with 3 units already reserved.
+	order := models.Order{
+		ID: "order_1", BuyerID: "buyer_1", VendorID: "vendor_1", Status: models.OrderStatusPending,
+		Items: []models.OrderItem{{ProductID: "product_1", Quantity: 3}},
+	}
+	if err := store.Orders.Create(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+	orders := NewOrderService(store, NewTaxEngine(DefaultTaxConfig()), NewComplianceGate(store, nil, time.Minute), nil, time.Hour)
+
+	vendor := &models.User{ID: "user_v", Role: models.RoleVendor, AssociatedEntityID: "vendor_1"}
+	buyer := &models.User{ID: "user_b", Role: models.RoleBuyer, AssociatedEntityID: "buyer_1"}
+	otherVendor := &models.User{ID: "user_x", Role: models.RoleVendor, AssociatedEntityID: "vendor_2"}
+	steps := []struct {
+		name    string
+		user    *models.User
+		to      models.OrderStatus
+		wantErr error
+	}{
+		{"another vendor can't see it", otherVendor, models.OrderStatusAccepted, ErrOrderNotFound},
+		{"buyer can't accept", buyer, models.OrderStatusAccepted, ErrIllegalTransition},
+		{"vendor accepts", vendor, models.OrderStatusAccepted, nil},
+		{"vendor processes", vendor, models.OrderStatusProcessing, nil},
+		{"vendor ships", vendor, models.OrderStatusShipped, nil},
+		{"nobody cancels once shipped", buyer, models.OrderStatusCancelled, ErrIllegalTransition},
+		{"buyer confirms delivery", buyer, models.OrderStatusDelivered, nil},
+		{"vendor completes", vendor, models.OrderStatusCompleted, nil},
+		{"completed is final", vendor, models.OrderStatusCancelled, ErrIllegalTransition},
+	}
+	for _, step := range steps {
+		got, err := orders.Transition(ctx, step.user, order.ID, step.to, "")
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: error = %v, want %v", step.name, err, step.wantErr)
+		}
+		if err == nil && got.Status != step.to {
+			t.Fatalf("%s: status = %s, want %s", step.name, got.Status, step.to)
+		}
+	}
+
+	stored, err := store.Orders.Get(ctx, order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.History) != 5 {
+		t.Errorf("history has %d changes, want 5", len(stored.History))
+	}
+	tl := stored.OrderStatusTimeline
+	if tl.AcceptedAt == "" || tl.ProcessingAt == "" || tl.ShippedAt == "" || tl.DeliveredAt == "" || tl.CompletedAt == "" || tl.CancelledAt != "" {
+		t.Errorf("timeline = %+v", tl)
+	}
+	// Stock only comes back when an order is cancelled.
+	if got, _ := store.Products.Get(ctx, "product_1"); got.AvailableUnits != 7 {
+		t.Errorf("units after completing = %d, want 7", got.AvailableUnits)
+	}
+}
+
+// A suspended vendor can't move its orders forward, but either side may still
+// cancel, which hands the reserved stock back.
+func TestOrderServiceTransitionSuspendedVendor(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	expires := time.Now().AddDate(1, 0, 0)
+	if err := store.Vendors.Create(ctx, models.Vendor{ID: "vendor_1", LicenseExpirationDate: expires, ComplianceStatus: models.ComplianceVerified}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Buyers.Create(ctx, models.Buyer{ID: "buyer_1", LicenseExpirationDate: expires, ComplianceStatus: models.ComplianceVerified}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Products.Create(ctx, models.Product{ID: "product_1", VendorID: "vendor_1", AvailableUnits: 7}); err != nil {
+		t.Fatal(err)
+	}
+	// Stored directly as placed, with 3 units already reserved.
+	if err := store.Orders.Create(ctx, models.Order{
+		ID: "order_1", BuyerID: "buyer_1", VendorID: "vendor_1", Status: models.OrderStatusPending,
+		Items: []models.OrderItem{{ProductID: "product_1", Quantity: 3}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	gate := NewComplianceGate(store, nil, time.Hour)
+	orders := NewOrderService(store, NewTaxEngine(DefaultTaxConfig()), gate, nil, time.Hour)
+	admins := NewAdminService(store, NewAuditLog(store.Audit), gate, "", time.Hour)
+	admin := &models.User{ID: "user_admin", Role: models.RoleAdmin}
+	vendor := &models.User{ID: "user_v", Role: models.RoleVendor, AssociatedEntityID: "vendor_1"}
+
+	// Warm the cache with a pass; the suspension must still apply at once.
+	if err := gate.CheckUser(ctx, vendor); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := admins.SetBusinessStatus(ctx, admin, "vendor_1", models.StatusSuspended, "failed inspection"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := orders.Transition(ctx, vendor, "order_1", models.OrderStatusAccepted, "")
+	var cerr *ComplianceError
+	if !errors.As(err, &cerr) || cerr.Reason != ReasonBusinessSuspended {
+		t.Fatalf("accepting while suspended = %v, want %s", err, ReasonBusinessSuspended)
+	}
+
+	got, err := orders.Transition(ctx, vendor, "order_1", models.OrderStatusCancelled, "can't fulfil while suspended")
+	if err != nil {
+		t.Fatalf("cancelling while suspended: %v", err)
+	}
+	if got.Status != models.OrderStatusCancelled {
+		t.Errorf("status = %s, want CANCELLED", got.Status)
+	}
+	if p, _ := store.Products.Get(ctx, "product_1"); p.AvailableUnits != 10 {
+		t.Errorf("units after cancelling = %d, want 10", p.AvailableUnits)
+	}
+}
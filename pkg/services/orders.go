@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/license"
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/utils"
+)
+
+var (
+	ErrInvalidOrder    = errors.New("invalid order")
+	ErrOrderNotAllowed = errors.New("order not allowed")
+	ErrOrderNotFound   = errors.New("order not found")
+	ErrPriceChanged    = errors.New("price changed")
+)
+
+// OrderDraft is what a buyer submits; prices and totals are filled in by the server.
+type OrderDraft struct {
+	Items           []models.OrderItem // only ProductID and Quantity are read
+	ShippingAddress string
+	PaymentMethod   models.PaymentMethod
+	DeliveryDate    string
+	// ExpectedPrices optionally holds the unit price the buyer agreed to, by
+	// product ID. Place fails with ErrPriceChanged if a product no longer has it.
+	ExpectedPrices map[string]models.Money
+}
+
+// OrderService places orders and owns the stock reserved for them. Stock is taken
+// off AvailableUnits when an order is placed and handed back if the order is
+// cancelled or the vendor doesn't accept it within the reservation window.
+type OrderService struct {
+	orders         repository.OrderRepository
+	products       repository.ProductRepository
+	vendors        repository.VendorRepository
+	buyers         repository.BuyerRepository
+	taxes          *TaxEngine
+	gate           *ComplianceGate
+	audit          *AuditLog
+	reservationTTL time.Duration
+}
+
+// NewOrderService returns an OrderService that prices orders with taxes, checks
+// both parties to a trade with gate, records placements and status changes to
+// audit, and holds stock for reservationTTL awaiting vendor acceptance.
+func NewOrderService(store *repository.Store, taxes *TaxEngine, gate *ComplianceGate, audit *AuditLog, reservationTTL time.Duration) *OrderService {
+	return &OrderService{
+		taxes:          taxes,
+		gate:           gate,
+		audit:          audit,
+		orders:         store.Orders,
+		products:       store.Products,
+		vendors:        store.Vendors,
+		buyers:         store.Buyers,
+		reservationTTL: reservationTTL,
+	}
+}
+
+// Place validates the draft against the vendor's live catalog, captures each unit
+// price, reserves the stock atomically and stores the order as PENDING.
+func (s *OrderService) Place(ctx context.Context, user *models.User, draft OrderDraft) (*models.Order, error) {
+	buyer, err := s.buyers.Get(ctx, user.AssociatedEntityID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: register your business before ordering", ErrOrderNotAllowed)
+	} else if err != nil {
+		return nil, err
+	}
+	if err := s.gate.Check(ctx, models.AccountEntityBuyer, buyer.ID); err != nil {
+		return nil, err
+	}
+
+	if len(draft.Items) == 0 {
+		return nil, fmt.Errorf("%w: an order needs at least one item", ErrInvalidOrder)
+	}
+	if draft.PaymentMethod != "" && !validPaymentMethod(draft.PaymentMethod) {
+		return nil, fmt.Errorf("%w: unknown payment method %q", ErrInvalidOrder, draft.PaymentMethod)
+	}
+
+	// --- Validate each line against the product as it is right now ---
+	var (
+		vendorID string
+		items    = make([]models.OrderItem, 0, len(draft.Items))
+		deltas   = make(map[string]int, len(draft.Items))
+		prices   = make(map[string]models.Money, len(draft.Items))
+	)
+	for _, line := range draft.Items {
+		if _, dup := deltas[line.ProductID]; dup {
+			return nil, fmt.Errorf("%w: product %s appears more than once", ErrInvalidOrder, line.ProductID)
+		}
+		product, err := s.products.Get(ctx, line.ProductID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%w: product %s does not exist", ErrInvalidOrder, line.ProductID)
+		} else if err != nil {
+			return nil, err
+		}
+
+		if vendorID == "" {
+			vendorID = product.VendorID
+		} else if product.VendorID != vendorID {
+			return nil, fmt.Errorf("%w: every item in an order must come from the same vendor", ErrInvalidOrder)
+		}
+		if err := checkQuantity(*product, line.Quantity); err != nil {
+			return nil, err
+		}
+		if expected, ok := draft.ExpectedPrices[product.ID]; ok && !expected.Equal(product.PricePerUnit) {
+			return nil, fmt.Errorf("%w: %s is now %s, not %s", ErrPriceChanged, product.Name, product.PricePerUnit.Amount(), expected.Amount())
+		}
+
+		// Capture the unit price at time of order so later price changes don't touch this order.
+		items = append(items, models.OrderItem{ProductID: product.ID, Quantity: line.Quantity, Price: product.PricePerUnit})
+		deltas[product.ID] = -line.Quantity
+		prices[product.ID] = product.PricePerUnit
+	}
+
+	// --- The vendor must be live and licensed to sell to this buyer ---
+	vendor, err := s.vendors.Get(ctx, vendorID)
+	if err != nil {
+		return nil, err
+	}
+	if !vendor.MenuEnabled {
+		return nil, fmt.Errorf("%w: this vendor is not currently accepting orders", ErrOrderNotAllowed)
+	}
+	if err := s.gate.Check(ctx, models.AccountEntityVendor, vendor.ID); err != nil {
+		return nil, err
+	}
+	if err := license.CheckTrade(vendor.OKStateLicenseID, buyer.OKStateLicenseID); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOrderNotAllowed, err)
+	}
+
+	now := time.Now()
+	placed := models.OrderStatusChange{To: models.OrderStatusPending, ActorID: user.ID, ActorRole: user.Role, At: now}
+	order := models.Order{
+		ID:                  utils.NewID("order"),
+		BuyerID:             buyer.ID,
+		PlacedBy:            user.ID,
+		VendorID:            vendor.ID,
+		Status:              models.OrderStatusPending,
+		History:             []models.OrderStatusChange{placed},
+		OrderStatusTimeline: models.Timeline{PlacedAt: now.Format(time.RFC3339)},
+		Items:               items,
+		PaymentStatus:       string(models.PaymentStatusPending),
+		PaymentMethod:       string(draft.PaymentMethod),
+		ShippingAddress:     draft.ShippingAddress,
+		DeliveryDate:        draft.DeliveryDate,
+		ReservedUntil:       now.Add(s.reservationTTL).Format(time.RFC3339),
+		UpdatedAt:           now.Format(time.RFC3339),
+	}
+
+	// --- Line totals, excise and sales tax ---
+	if err := s.taxes.Apply(&order, buyer.LicenseType); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOrder, err)
+	}
+
+	// --- Reserve stock; this fails as a whole if any SKU would oversell ---
+	// It also fails if a price changed since the products were read above, so the
+	// order is never charged a price that is no longer the product's.
+	err = s.products.ReserveStock(ctx, deltas, prices)
+	switch {
+	case errors.Is(err, repository.ErrInsufficientStock):
+		return nil, fmt.Errorf("%w: not enough stock to fill this order", repository.ErrInsufficientStock)
+	case errors.Is(err, repository.ErrPriceChanged):
+		return nil, fmt.Errorf("%w: a price changed while the order was being placed; review it and try again", ErrPriceChanged)
+	case err != nil:
+		return nil, err
+	}
+
+	if err := s.orders.Create(ctx, order); err != nil {
+		s.releaseStock(ctx, order)
+		return nil, err
+	}
+	s.audit.Record(ctx, UserEvent(user, models.AuditOrderPlaced, models.AuditEntityOrder, order.ID), nil, order)
+
+	log.Printf("Order %s placed by buyer %s with vendor %s (%d items, reserved until %s)", order.ID, buyer.ID, vendor.ID, len(items), order.ReservedUntil)
+	return &order, nil
+}
+
+func checkQuantity(product models.Product, quantity int) error {
+	switch {
+	case quantity <= 0:
+		return fmt.Errorf("%w: quantity for %s must be positive", ErrInvalidOrder, product.Name)
+	case quantity < product.MinOrderQuantity:
+		return fmt.Errorf("%w: %s has a minimum order of %d", ErrInvalidOrder, product.Name, product.MinOrderQuantity)
+	case product.MaxOrderQuantity > 0 && quantity > product.MaxOrderQuantity:
+		return fmt.Errorf("%w: %s has a maximum order of %d", ErrInvalidOrder, product.Name, product.MaxOrderQuantity)
+	case quantity > product.AvailableUnits:
+		return fmt.Errorf("%w: only %d units of %s available", repository.ErrInsufficientStock, product.AvailableUnits, product.Name)
+	}
+	return nil
+}
+
+func validPaymentMethod(m models.PaymentMethod) bool {
+	switch m {
+	case models.PaymentMethodCash, models.PaymentMethodCheck, models.PaymentMethodOther:
+		return true
+	}
+	return false
+}
+
+// List returns the orders visible to user: their business's purchases for buyers,
+// their business's sales for vendors.
+func (s *OrderService) List(ctx context.Context, user *models.User) ([]models.Order, error) {
+	switch user.Role {
+	case models.RoleBuyer:
+		return s.orders.ListByBuyer(ctx, user.AssociatedEntityID)
+	case models.RoleVendor:
+		return s.orders.ListByVendor(ctx, user.AssociatedEntityID)
+	}
+	return nil, fmt.Errorf("%w: only buyers and vendors have orders", ErrOrderNotAllowed)
+}
+
+// ReleaseExpired cancels every PENDING order whose reservation has lapsed and
+// returns its stock. It reports how many orders were released.
+func (s *OrderService) ReleaseExpired(ctx context.Context) (int, error) {
+	pending, err := s.orders.ListByStatus(ctx, models.OrderStatusPending)
+	if err != nil {
+		return 0, err
+	}
+
+	now, released := time.Now(), 0
+	for i := range pending {
+		order := &pending[i]
+		until, err := time.Parse(time.RFC3339, order.ReservedUntil)
+		if err != nil || now.Before(until) {
+			continue
+		}
+		change := models.OrderStatusChange{
+			From:    order.Status,
+			To:      models.OrderStatusCancelled,
+			ActorID: SystemActorID,
+			Reason:  "not accepted before reservation expired",
+			At:      now,
+		}
+		if err := s.apply(ctx, order, change); err != nil {
+			log.Printf("Could not release expired reservation for order %s: %v", order.ID, err)
+			continue
+		}
+		log.Printf("Order %s was not accepted by %s; reservation released", order.ID, order.ReservedUntil)
+		released++
+	}
+	return released, nil
+}
+
+// RunReservationSweeper calls ReleaseExpired every interval until ctx is cancelled.
+func (s *OrderService) RunReservationSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ReleaseExpired(ctx); err != nil {
+				log.Printf("Reservation sweep failed: %v", err)
+			}
+		}
+	}
+}
+
+// releaseStock returns the units reserved for an order that could not be stored.
+// Products deleted since the order was priced are skipped.
+func (s *OrderService) releaseStock(ctx context.Context, order models.Order) {
+	for _, item := range order.Items {
+		err := s.products.AdjustStock(ctx, map[string]int{item.ProductID: item.Quantity})
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			log.Printf("Could not release %d units of %s for order %s: %v", item.Quantity, item.ProductID, order.ID, err)
+		}
+	}
+}
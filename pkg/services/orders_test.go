@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+func TestOrderServicePlace(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	expires := time.Now().AddDate(1, 0, 0)
+	if err := store.Vendors.Create(ctx, models.Vendor{
+		ID: "vendor_1", OKStateLicenseID: "GAAA-4K7M-2Q9X-8B3N", LicenseType: models.LicenseTypeGrower,
+		LicenseExpirationDate: expires, ComplianceStatus: models.ComplianceVerified, MenuEnabled: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Buyers.Create(ctx, models.Buyer{
+		ID: "buyer_1", OKStateLicenseID: "DAAA-7H2L-5R8T-1C6W", LicenseType: models.LicenseTypeDispensary,
+		LicenseExpirationDate: expires, ComplianceStatus: models.ComplianceVerified,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	orders := NewOrderService(store, NewTaxEngine(DefaultTaxConfig()), NewComplianceGate(store, nil, time.Minute), nil, 48*time.Hour)
+	buyer := &models.User{ID: "user_b", Role: models.RoleBuyer, AssociatedEntityID: "buyer_1"}
+
+	tests := []struct {
+		name      string
+		quantity  int
+		unknown   bool
+		expected  int64 // agreed unit price in cents, if any
+		wantErr   error
+		wantUnits int
+	}{
+		{"reserves stock", 3, false, 0, nil, 7},
+		{"takes the last unit", 10, false, 0, nil, 0},
+		{"more than in stock", 11, false, 0, repository.ErrInsufficientStock, 10},
+		{"below the minimum", 1, false, 0, ErrInvalidOrder, 10},
+		{"zero quantity", 0, false, 0, ErrInvalidOrder, 10},
+		{"unknown product", 3, true, 0, ErrInvalidOrder, 10},
+		{"at the agreed price", 3, false, 10000, nil, 7},
+		{"price changed since it was agreed", 3, false, 9000, ErrPriceChanged, 10},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := models.Product{
+				ID: fmt.Sprintf("product_%d", i), VendorID: "vendor_1", Name: "Blue Dream 1oz",
+				PricePerUnit: models.USDCents(10000), AvailableUnits: 10, MinOrderQuantity: 2,
+			}
+			if err := store.Products.Create(ctx, product); err != nil {
+				t.Fatal(err)
+			}
+			line := models.OrderItem{ProductID: product.ID, Quantity: tt.quantity}
+			if tt.unknown {
+				line.ProductID = "nope"
+			}
+
+			draft := OrderDraft{Items: []models.OrderItem{line}, ShippingAddress: "1 Main St, Tulsa OK 74103"}
+			if tt.expected != 0 {
+				draft.ExpectedPrices = map[string]models.Money{product.ID: models.USDCents(tt.expected)}
+			}
+			order, err := orders.Place(ctx, buyer, draft)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Place error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if order.Status != models.OrderStatusPending || !order.Items[0].Price.Equal(product.PricePerUnit) {
+					t.Errorf("placed order is %s at %v, want PENDING at %v", order.Status, order.Items[0].Price, product.PricePerUnit)
+				}
+				if _, err := store.Orders.Get(ctx, order.ID); err != nil {
+					t.Errorf("placed order wasn't stored: %v", err)
+				}
+			}
+			if got, _ := store.Products.Get(ctx, product.ID); got.AvailableUnits != tt.wantUnits {
+				t.Errorf("units = %d, want %d", got.AvailableUnits, tt.wantUnits)
+			}
+		})
+	}
+}
+
+// Cancelling an order while the expiry sweep releases it must hand its stock
+// back exactly once.
+func TestOrderServiceReleasesStockOnce(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	expires := time.Now().AddDate(1, 0, 0)
+	if err := store.Vendors.Create(ctx, models.Vendor{
+		ID: "vendor_1", OKStateLicenseID: "PAAA-DJ3F-28JJ-283H", LicenseType: models.LicenseTypeProcessor,
+		LicenseExpirationDate: expires, ComplianceStatus: models.ComplianceVerified, MenuEnabled: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Buyers.Create(ctx, models.Buyer{
+		ID: "buyer_1", OKStateLicenseID: "DAAA-7H2L-5R8T-1C6W", LicenseType: models.LicenseTypeDispensary,
+		LicenseExpirationDate: expires, ComplianceStatus: models.ComplianceVerified,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Products.Create(ctx, models.Product{ID: "product_1", VendorID: "vendor_1", Name: "Gummies", PricePerUnit: models.USDCents(500), AvailableUnits: 100}); err != nil {
+		t.Fatal(err)
+	}
+	// A negative reservation window leaves every order due for release at once.
+	orders := NewOrderService(store, NewTaxEngine(DefaultTaxConfig()), NewComplianceGate(store, nil, time.Minute), nil, -time.Minute)
+	buyer := &models.User{ID: "user_b", Role: models.RoleBuyer, AssociatedEntityID: "buyer_1"}
+
+	var placed []string
+	for range 20 {
+		order, err := orders.Place(ctx, buyer, OrderDraft{Items: []models.OrderItem{{ProductID: "product_1", Quantity: 2}}, ShippingAddress: "1 Main St, Tulsa OK 74103"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		placed = append(placed, order.ID)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	cancelled := 0
+	for _, id := range placed {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := orders.Transition(ctx, buyer, id, models.OrderStatusCancelled, "changed our mind")
+			if err == nil {
+				mu.Lock()
+				cancelled++
+				mu.Unlock()
+			} else if !errors.Is(err, ErrIllegalTransition) {
+				t.Errorf("cancelling %s: %v", id, err)
+			}
+		}()
+	}
+	var released int
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var err error
+		if released, err = orders.ReleaseExpired(ctx); err != nil {
+			t.Errorf("ReleaseExpired: %v", err)
+		}
+	}()
+	wg.Wait()
+
+	if cancelled+released != len(placed) {
+		t.Errorf("%d cancelled and %d released, want %d in all", cancelled, released, len(placed))
+	}
+	if got, _ := store.Products.Get(ctx, "product_1"); got.AvailableUnits != 100 {
+		t.Errorf("units = %d after every order was cancelled, want 100", got.AvailableUnits)
+	}
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHashPasswordRoundTrip(t *testing.T) {
+	hash, err := HashPassword("correct horse battery")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	if hash == "correct horse battery" {
+		t.Fatal("HashPassword returned the plaintext")
+	}
+	if err := CheckPassword(hash, "correct horse battery"); err != nil {
+		t.Errorf("CheckPassword with the right password: %v", err)
+	}
+	if err := CheckPassword(hash, "correct horse battery!"); !errors.Is(err, ErrPasswordMismatch) {
+		t.Errorf("CheckPassword with the wrong password: error = %v, want %v", err, ErrPasswordMismatch)
+	}
+	if err := CheckPassword("not a bcrypt hash", "anything"); !errors.Is(err, ErrPasswordMismatch) {
+		t.Errorf("CheckPassword against garbage: error = %v, want %v", err, ErrPasswordMismatch)
+	}
+}
+
+func TestHashPasswordLength(t *testing.T) {
+	if _, err := HashPassword("short"); !errors.Is(err, ErrPasswordTooShort) {
+		t.Errorf("7-byte password: error = %v, want %v", err, ErrPasswordTooShort)
+	}
+	if _, err := HashPassword(strings.Repeat("a", MaxPasswordLength)); err != nil {
+		t.Errorf("72-byte password: %v", err)
+	}
+	// bcrypt ignores everything past 72 bytes, so longer passwords are refused
+	// rather than silently truncated.
+	if _, err := HashPassword(strings.Repeat("a", MaxPasswordLength+1)); !errors.Is(err, ErrPasswordTooLong) {
+		t.Errorf("73-byte password: error = %v, want %v", err, ErrPasswordTooLong)
+	}
+}
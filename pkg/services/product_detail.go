@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+// licenseWarningDays is how close to expiry a compliant vendor's badge starts warning buyers.
+const licenseWarningDays = 30
+
+type BadgeLevel string
+
+const (
+	BadgeVerified     BadgeLevel = "VERIFIED"      // compliant, with more than licenseWarningDays left
+	BadgeExpiringSoon BadgeLevel = "EXPIRING_SOON" // compliant, but the license is due for renewal
+	BadgeNotCompliant BadgeLevel = "NOT_COMPLIANT" // unverified or expired; orders will be refused
+)
+
+type StockLevel string
+
+const (
+	StockIn  StockLevel = "IN_STOCK"
+	StockLow StockLevel = "LOW_STOCK"
+	StockOut StockLevel = "OUT_OF_STOCK" // fewer units than the minimum order
+)
+
+// ProductDetail is everything a buyer needs to decide on a product in one response.
+type ProductDetail struct {
+	Product      models.Product      `json:"product"`
+	Vendor       VendorProfile       `json:"vendor"`
+	Compliance   ComplianceBadge     `json:"complianceBadge"`
+	Stock        StockStatus         `json:"stock"`
+	PriceHistory []models.PricePoint `json:"priceHistory"`  // oldest first
+	COA          *models.COADocument `json:"coa,omitempty"` // the parsed lab results, if a COA has been uploaded
+}
+
+// VendorProfile is the part of a Vendor any signed-in user may see.
+type VendorProfile struct {
+	ID               string             `json:"id"`
+	BusinessName     string             `json:"businessName"`
+	LicenseType      models.LicenseType `json:"licenseType"`
+	OKStateLicenseID string             `json:"okStateLicenseID"` // public record at the OMMA
+	MenuEnabled      bool               `json:"menuEnabled"`
+	MemberSince      time.Time          `json:"memberSince"`
+}
+
+// ComplianceBadge summarizes whether a vendor is safe to buy from right now.
+// SafeToBuy is the vendor's IsCompliant(); Level adds a warning as the license
+// nears expiry.
+type ComplianceBadge struct {
+	Level     BadgeLevel       `json:"level"`
+	SafeToBuy bool             `json:"safeToBuy"`
+	Summary   string           `json:"summary"`
+	License   LicenseCountdown `json:"license"`
+}
+
+// StockStatus is a product's stock as of the request.
+type StockStatus struct {
+	Level            StockLevel `json:"level"`
+	AvailableUnits   int        `json:"availableUnits"`
+	MinOrderQuantity int        `json:"minOrderQuantity"`
+	MaxOrderQuantity int        `json:"maxOrderQuantity"` // 0 for no limit
+}
+
+// ProductDetailService assembles product detail pages.
+type ProductDetailService struct {
+	products repository.ProductRepository
+	vendors  repository.VendorRepository
+}
+
+// NewProductDetailService returns a ProductDetailService reading from store.
+func NewProductDetailService(store *repository.Store) *ProductDetailService {
+	return &ProductDetailService{products: store.Products, vendors: store.Vendors}
+}
+
+// Detail returns one product with its vendor, stock, price history, lab results
+// and compliance badge. Like the product's COA, it is visible to the owning
+// vendor, admins, and everyone once the vendor's menu is live.
+func (s *ProductDetailService) Detail(ctx context.Context, user *models.User, productID string) (*ProductDetail, error) {
+	product, err := s.products.Get(ctx, productID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrProductNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	vendor, err := s.vendors.Get(ctx, product.VendorID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrProductNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if !vendor.MenuEnabled && product.VendorID != user.AssociatedEntityID && user.Role != models.RoleAdmin {
+		return nil, ErrProductNotFound
+	}
+
+	now := time.Now()
+	detail := &ProductDetail{
+		Product: *product,
+		Vendor: VendorProfile{
+			ID:               vendor.ID,
+			BusinessName:     vendor.BusinessName,
+			LicenseType:      vendor.LicenseType,
+			OKStateLicenseID: vendor.OKStateLicenseID,
+			MenuEnabled:      vendor.MenuEnabled,
+			MemberSince:      vendor.CreatedAt,
+		},
+		Compliance:   complianceBadge(*vendor, now),
+		Stock:        stockStatus(*product),
+		PriceHistory: product.PriceHistory,
+		COA:          product.COA,
+	}
+	// The COA is served at the top level; don't send it twice.
+	detail.Product.COA = nil
+	// Products saved before prices were tracked start from their last update.
+	if len(detail.PriceHistory) == 0 {
+		detail.PriceHistory = []models.PricePoint{{Price: product.PricePerUnit, At: product.UpdatedAt}}
+	}
+	return detail, nil
+}
+
+func complianceBadge(vendor models.Vendor, now time.Time) ComplianceBadge {
+	badge := ComplianceBadge{SafeToBuy: vendor.IsCompliant(), License: licenseCountdown(vendor, now)}
+	days := badge.License.DaysRemaining
+	switch {
+	case vendor.ComplianceStatus != models.ComplianceVerified:
+		badge.Level = BadgeNotCompliant
+		badge.Summary = fmt.Sprintf("License %s is not verified with the OMMA (status %s).", vendor.OKStateLicenseID, vendor.ComplianceStatus)
+	case !badge.SafeToBuy:
+		badge.Level = BadgeNotCompliant
+		badge.Summary = fmt.Sprintf("License %s expired on %s.", vendor.OKStateLicenseID, vendor.LicenseExpirationDate.Format("2006-01-02"))
+	case days <= licenseWarningDays:
+		badge.Level = BadgeExpiringSoon
+		badge.Summary = fmt.Sprintf("License verified, but it expires in %d days.", days)
+	default:
+		badge.Level = BadgeVerified
+		badge.Summary = fmt.Sprintf("License verified; expires in %d days.", days)
+	}
+	return badge
+}
+
+// stockStatus uses the same low-stock rule as the vendor dashboard's default.
+func stockStatus(product models.Product) StockStatus {
+	status := StockStatus{
+		Level:            StockIn,
+		AvailableUnits:   product.AvailableUnits,
+		MinOrderQuantity: product.MinOrderQuantity,
+		MaxOrderQuantity: product.MaxOrderQuantity,
+	}
+	switch {
+	case product.AvailableUnits < max(product.MinOrderQuantity, 1):
+		status.Level = StockOut
+	case product.AvailableUnits < max(DefaultLowStockThreshold, product.MinOrderQuantity):
+		status.Level = StockLow
+	}
+	return status
+}
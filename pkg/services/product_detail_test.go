@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+func TestComplianceBadge(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		status    models.AccountComplianceStatus
+		expiresIn time.Duration
+		want      BadgeLevel
+		wantSafe  bool
+	}{
+		{"verified", models.ComplianceVerified, 90 * 24 * time.Hour, BadgeVerified, true},
+		{"expiring soon", models.ComplianceVerified, 30*24*time.Hour + time.Hour, BadgeExpiringSoon, true},
+		{"expired", models.ComplianceVerified, -time.Hour, BadgeNotCompliant, false},
+		{"not verified", models.CompliancePending, 90 * 24 * time.Hour, BadgeNotCompliant, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vendor := models.Vendor{OKStateLicenseID: "GAAA-4K7M-2Q9X-8B3N", ComplianceStatus: tt.status, LicenseExpirationDate: now.Add(tt.expiresIn)}
+			got := complianceBadge(vendor, now)
+			if got.Level != tt.want || got.SafeToBuy != tt.wantSafe || got.Summary == "" {
+				t.Errorf("badge = %+v, want %s, safe %v", got, tt.want, tt.wantSafe)
+			}
+		})
+	}
+}
+
+func TestStockStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		available int
+		minimum   int
+		want      StockLevel
+	}{
+		{"plenty", 50, 1, StockIn},
+		{"low", DefaultLowStockThreshold - 1, 1, StockLow},
+		{"at the threshold", DefaultLowStockThreshold, 1, StockIn},
+		{"sold out", 0, 0, StockOut},
+		{"can't fill the minimum order", 4, 5, StockOut},
+		{"fills a large minimum", 25, 20, StockIn},
+		{"exactly fills a minimum above the threshold", 12, 12, StockIn},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stockStatus(models.Product{AvailableUnits: tt.available, MinOrderQuantity: tt.minimum})
+			if got.Level != tt.want || got.AvailableUnits != tt.available {
+				t.Errorf("stock = %+v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductDetailVisibility(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	if err := store.Vendors.Create(ctx, models.Vendor{ID: "vendor_1", ComplianceStatus: models.ComplianceVerified, LicenseExpirationDate: time.Now().AddDate(1, 0, 0)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Products.Create(ctx, models.Product{ID: "p1", VendorID: "vendor_1", PricePerUnit: models.USDCents(1000), AvailableUnits: 5}); err != nil {
+		t.Fatal(err)
+	}
+	details := NewProductDetailService(store)
+
+	tests := []struct {
+		name    string
+		user    *models.User
+		wantErr error
+	}{
+		{"owning vendor", &models.User{ID: "u1", Role: models.RoleVendor, AssociatedEntityID: "vendor_1"}, nil},
+		{"admin", &models.User{ID: "u2", Role: models.RoleAdmin}, nil},
+		{"buyer before the menu is live", &models.User{ID: "u3", Role: models.RoleBuyer, AssociatedEntityID: "buyer_1"}, ErrProductNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detail, err := details.Detail(ctx, tt.user, "p1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Detail error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (len(detail.PriceHistory) != 1 || detail.Stock.Level != StockLow || detail.Compliance.Level != BadgeVerified) {
+				t.Errorf("detail = %+v", detail)
+			}
+		})
+	}
+}
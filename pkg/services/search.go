@@ -0,0 +1,374 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+const (
+	DefaultSearchLimit = 24
+	MaxSearchLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("cursor is malformed or belongs to a different search")
+
+// SearchQuery holds the marketplace filters. Nil pointers and empty strings mean "any".
+type SearchQuery struct {
+	Text        string
+	Category    string
+	SubCategory string
+	IsMedical   *bool
+	MinPrice    *models.Money
+	MaxPrice    *models.Money
+	InStock     bool
+	MinTHC      *float64 // percent; products that don't report THC never match a potency filter
+	MaxTHC      *float64
+	MinCBD      *float64
+	MaxCBD      *float64
+	StrainType  models.StrainType
+	TestStatus  models.TestStatus
+	Tags        []string // every tag must be present on the product
+	Limit       int
+	Cursor      string
+}
+
+// filterKey is a canonical string for the query's filters (not its page), used both
+// as Catalog.FilterApplied and to bind cursors to the search that produced them.
+func (q SearchQuery) filterKey() string {
+	v := url.Values{}
+	set := func(k, val string) {
+		if val != "" {
+			v.Set(k, val)
+		}
+	}
+	set("q", strings.ToLower(strings.TrimSpace(q.Text)))
+	set("category", strings.ToLower(q.Category))
+	set("subCategory", strings.ToLower(q.SubCategory))
+	if q.IsMedical != nil {
+		v.Set("medical", strconv.FormatBool(*q.IsMedical))
+	}
+	if q.MinPrice != nil {
+		v.Set("minPrice", q.MinPrice.Amount())
+	}
+	if q.MaxPrice != nil {
+		v.Set("maxPrice", q.MaxPrice.Amount())
+	}
+	if q.InStock {
+		v.Set("inStock", "true")
+	}
+	for k, p := range map[string]*float64{"minTHC": q.MinTHC, "maxTHC": q.MaxTHC, "minCBD": q.MinCBD, "maxCBD": q.MaxCBD} {
+		if p != nil {
+			v.Set(k, strconv.FormatFloat(*p, 'f', -1, 64))
+		}
+	}
+	set("strainType", string(q.StrainType))
+	set("testStatus", string(q.TestStatus))
+	tags := append([]string(nil), q.Tags...)
+	sort.Strings(tags)
+	for _, t := range tags {
+		v.Add("tag", t)
+	}
+	return v.Encode() // Encode sorts by key
+}
+
+// searchFilter is a set of the query's filters, as bits. Each faceted filter has
+// its own bit; the filters without a facet share filterOther.
+type searchFilter uint
+
+const (
+	facetCategory searchFilter = 1 << iota
+	facetSubCategory
+	facetMedical
+	facetInStock
+	facetTags
+	facetStrainType
+	facetTestStatus
+	filterOther // price, potency and text
+)
+
+// misses returns the filters p fails; p matches the query if there are none.
+func (q SearchQuery) misses(p models.Product) searchFilter {
+	var miss searchFilter
+	if q.Category != "" && !strings.EqualFold(p.Category, q.Category) {
+		miss |= facetCategory
+	}
+	if q.SubCategory != "" && !strings.EqualFold(p.SubCategory, q.SubCategory) {
+		miss |= facetSubCategory
+	}
+	if q.IsMedical != nil && p.IsMedical != *q.IsMedical {
+		miss |= facetMedical
+	}
+	if q.InStock && !inStock(p) {
+		miss |= facetInStock
+	}
+	for _, want := range q.Tags {
+		if !hasTag(p.ComplianceTags, want) && !hasAttributeTag(p.Compliance, want) {
+			miss |= facetTags
+		}
+	}
+	if q.StrainType != "" && p.Compliance.StrainType != q.StrainType {
+		miss |= facetStrainType
+	}
+	if q.TestStatus != "" && p.Compliance.TestStatus != q.TestStatus {
+		miss |= facetTestStatus
+	}
+
+	if q.MinPrice != nil && p.PricePerUnit.Cmp(*q.MinPrice) < 0 {
+		miss |= filterOther
+	}
+	if q.MaxPrice != nil && p.PricePerUnit.Cmp(*q.MaxPrice) > 0 {
+		miss |= filterOther
+	}
+	if !inRange(p.Compliance.THCPercent, q.MinTHC, q.MaxTHC) || !inRange(p.Compliance.CBDPercent, q.MinCBD, q.MaxCBD) {
+		miss |= filterOther
+	}
+	// Every whitespace-separated term must appear somewhere in the product's text.
+	c := p.Compliance
+	haystack := strings.ToLower(strings.Join(append([]string{p.Name, p.Description, p.Category, p.SubCategory, string(c.StrainType), c.BatchNumber, c.LabID}, p.ComplianceTags...), " "))
+	for _, term := range strings.Fields(strings.ToLower(q.Text)) {
+		if !strings.Contains(haystack, term) {
+			miss |= filterOther
+		}
+	}
+	return miss
+}
+
+func inStock(p models.Product) bool {
+	return p.AvailableUnits >= max(p.MinOrderQuantity, 1)
+}
+
+// countFacets adds p to each facet in which whose count it belongs in: those for
+// which p passes every filter but the facet's own. So with category=FLOWER
+// selected, the category facet still counts the other categories' products.
+func countFacets(facets *models.CatalogFacets, q SearchQuery, p models.Product, which searchFilter) {
+	miss := q.misses(p)
+	in := func(f searchFilter) bool { return which&f != 0 && miss&^f == 0 }
+	if in(facetCategory) && p.Category != "" {
+		facets.Categories[p.Category]++
+	}
+	if in(facetSubCategory) && p.SubCategory != "" {
+		facets.SubCategories[p.SubCategory]++
+	}
+	if in(facetMedical) {
+		facets.Medical[strconv.FormatBool(p.IsMedical)]++
+	}
+	if in(facetInStock) {
+		facets.InStock[strconv.FormatBool(inStock(p))]++
+	}
+	if in(facetTags) {
+		for _, t := range p.ComplianceTags {
+			facets.ComplianceTags[strings.TrimSpace(t)]++
+		}
+	}
+	if in(facetStrainType) && p.Compliance.StrainType != "" {
+		facets.StrainTypes[string(p.Compliance.StrainType)]++
+	}
+	if in(facetTestStatus) && p.Compliance.TestStatus != "" {
+		facets.TestStatuses[string(p.Compliance.TestStatus)]++
+	}
+}
+
+// inRange reports whether v lies within [lo, hi]. A missing value never matches a bound.
+func inRange(v, lo, hi *float64) bool {
+	if lo == nil && hi == nil {
+		return true
+	}
+	return v != nil && (lo == nil || *v >= *lo) && (hi == nil || *v <= *hi)
+}
+
+// hasAttributeTag keeps old tag filters such as tag=Sativa or "tag=THC: 25%" working
+// now that those tags have been folded into Product.Compliance.
+func hasAttributeTag(c models.ComplianceAttributes, tag string) bool {
+	var want models.ComplianceAttributes
+	if len(FoldComplianceTags(&want, []string{tag})) > 0 {
+		return false
+	}
+	samePercent := func(a, b *float64) bool { return b == nil || (a != nil && *a == *b) }
+	return samePercent(c.THCPercent, want.THCPercent) &&
+		samePercent(c.CBDPercent, want.CBDPercent) &&
+		(want.StrainType == "" || c.StrainType == want.StrainType) &&
+		(want.TestStatus == "" || c.TestStatus == want.TestStatus) &&
+		(want.BatchNumber == "" || strings.EqualFold(c.BatchNumber, want.BatchNumber)) &&
+		(want.LabID == "" || strings.EqualFold(c.LabID, want.LabID)) &&
+		(want.HarvestDate.IsZero() || c.HarvestDate.Equal(want.HarvestDate)) &&
+		(want.PackageDate.IsZero() || c.PackageDate.Equal(want.PackageDate))
+}
+
+func hasTag(tags []string, want string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(strings.TrimSpace(t), strings.TrimSpace(want)) {
+			return true
+		}
+	}
+	return false
+}
+
+// searchCursor marks the last product on a page. Results are ordered by
+// (lower-cased name, ID), so resuming after this key is stable even when products
+// are added or removed between requests.
+type searchCursor struct {
+	Name   string `json:"n"`
+	ID     string `json:"i"`
+	Filter string `json:"f"` // hash of filterKey
+}
+
+func filterHash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+func encodeCursor(c searchCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string, filter string) (*searchCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c searchCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.Filter != filterHash(filter) {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+func productSortKey(p models.Product) string {
+	return strings.ToLower(p.Name)
+}
+
+func productLess(a, b models.Product) bool {
+	ka, kb := productSortKey(a), productSortKey(b)
+	if ka != kb {
+		return ka < kb
+	}
+	return a.ID < b.ID
+}
+
+// SearchMarketplace searches the products of every vendor whose menu is enabled and
+// whose license is compliant. Products holds one page of the matches. Each facet
+// counts the products matching every filter except its own, so clients can show
+// how many results picking another value would give.
+//
+// The vendor, category and medical filters are run by the repository. When the
+// category or medical filter is set, its facet needs one more query without it.
+func SearchMarketplace(ctx context.Context, vendors repository.VendorRepository, products repository.ProductRepository, q SearchQuery) (*models.Catalog, error) {
+	q.Category = CanonicalCategory(q.Category)
+	if q.Limit <= 0 {
+		q.Limit = DefaultSearchLimit
+	}
+	q.Limit = min(q.Limit, MaxSearchLimit)
+
+	filter := q.filterKey()
+	var after *searchCursor
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor, filter)
+		if err != nil {
+			return nil, err
+		}
+		after = c
+	}
+
+	allVendors, err := vendors.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing vendors: %w", err)
+	}
+	var live []string
+	for _, v := range allVendors {
+		if v.MenuEnabled && v.IsCompliant() {
+			live = append(live, v.ID)
+		}
+	}
+
+	facets := &models.CatalogFacets{
+		Categories:     map[string]int{},
+		SubCategories:  map[string]int{},
+		Medical:        map[string]int{},
+		InStock:        map[string]int{},
+		ComplianceTags: map[string]int{},
+		StrainTypes:    map[string]int{},
+		TestStatuses:   map[string]int{},
+	}
+	var matched []models.Product
+	if len(live) > 0 {
+		filter := repository.ProductFilter{VendorIDs: live, Category: q.Category, IsMedical: q.IsMedical}
+		found, err := products.Search(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("searching products: %w", err)
+		}
+		which := ^searchFilter(0)
+		if q.Category != "" {
+			which &^= facetCategory
+		}
+		if q.IsMedical != nil {
+			which &^= facetMedical
+		}
+		for _, p := range found {
+			if q.misses(p) == 0 {
+				matched = append(matched, p)
+			}
+			countFacets(facets, q, p, which)
+		}
+
+		// The pushed-down filters hide the products their own facets need.
+		if q.Category != "" {
+			without := filter
+			without.Category = ""
+			if err := countFacetsFrom(ctx, products, without, facets, q, facetCategory); err != nil {
+				return nil, err
+			}
+		}
+		if q.IsMedical != nil {
+			without := filter
+			without.IsMedical = nil
+			if err := countFacetsFrom(ctx, products, without, facets, q, facetMedical); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return productLess(matched[i], matched[j]) })
+
+	start := 0
+	if after != nil {
+		marker := models.Product{Name: after.Name, ID: after.ID}
+		start = sort.Search(len(matched), func(i int) bool { return productLess(marker, matched[i]) })
+	}
+	end := min(start+q.Limit, len(matched))
+
+	catalog := &models.Catalog{
+		TotalProducts: len(matched),
+		FilterApplied: filter,
+		Products:      append([]models.Product{}, matched[start:end]...),
+		Facets:        facets,
+	}
+	if end < len(matched) {
+		last := matched[end-1]
+		catalog.NextCursor = encodeCursor(searchCursor{Name: productSortKey(last), ID: last.ID, Filter: filterHash(filter)})
+	}
+	return catalog, nil
+}
+
+// countFacetsFrom counts one facet over the products matching filter.
+func countFacetsFrom(ctx context.Context, products repository.ProductRepository, filter repository.ProductFilter, facets *models.CatalogFacets, q SearchQuery, facet searchFilter) error {
+	found, err := products.Search(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("searching products: %w", err)
+	}
+	for _, p := range found {
+		countFacets(facets, q, p, facet)
+	}
+	return nil
+}
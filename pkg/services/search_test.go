@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+// recordingProducts remembers the filters each Search was called with.
+type recordingProducts struct {
+	repository.ProductRepository
+	filters []repository.ProductFilter
+}
+
+func (r *recordingProducts) Search(ctx context.Context, filter repository.ProductFilter) ([]models.Product, error) {
+	r.filters = append(r.filters, filter)
+	return r.ProductRepository.Search(ctx, filter)
+}
+
+// newSearchStore returns vendors and products for the marketplace search tests.
+// Only vendor "live" is listed: "closed" has its menu off and "lapsed" has an
+// expired license.
+func newSearchStore(t *testing.T) (*repository.Store, *recordingProducts) {
+	t.Helper()
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	nextYear := time.Now().AddDate(1, 0, 0)
+	for _, v := range []models.Vendor{
+		{ID: "live", MenuEnabled: true, ComplianceStatus: models.ComplianceVerified, LicenseExpirationDate: nextYear},
+		{ID: "closed", ComplianceStatus: models.ComplianceVerified, LicenseExpirationDate: nextYear},
+		{ID: "lapsed", MenuEnabled: true, ComplianceStatus: models.ComplianceExpired, LicenseExpirationDate: nextYear},
+	} {
+		if err := store.Vendors.Create(ctx, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, p := range []models.Product{
+		{ID: "p1", VendorID: "live", Name: "Alpha", Category: "FLOWER", IsMedical: true, AvailableUnits: 10},
+		{ID: "p2", VendorID: "live", Name: "Bravo", Category: "EDIBLE", AvailableUnits: 10},
+		{ID: "p3", VendorID: "live", Name: "Charlie", Category: "FLOWER", AvailableUnits: 10},
+		{ID: "p4", VendorID: "live", Name: "Delta", Category: "FLOWER", IsMedical: true},
+		{ID: "p5", VendorID: "live", Name: "Echo", Category: "EDIBLE", IsMedical: true, AvailableUnits: 10},
+		{ID: "p6", VendorID: "closed", Name: "Foxtrot", Category: "FLOWER", IsMedical: true, AvailableUnits: 10},
+		{ID: "p7", VendorID: "lapsed", Name: "Golf", Category: "FLOWER", IsMedical: true, AvailableUnits: 10},
+	} {
+		if err := store.Products.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store, &recordingProducts{ProductRepository: store.Products}
+}
+
+func TestSearchMarketplaceFacets(t *testing.T) {
+	store, products := newSearchStore(t)
+	medical := true
+	catalog, err := SearchMarketplace(context.Background(), store.Vendors, products, SearchQuery{Category: "flower", IsMedical: &medical})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, p := range catalog.Products {
+		ids = append(ids, p.ID)
+	}
+	if want := []string{"p1", "p4"}; !slices.Equal(ids, want) {
+		t.Errorf("products = %v, want %v", ids, want)
+	}
+
+	// Each facet counts the listed vendors' products that pass every filter but its own.
+	for _, tt := range []struct {
+		facet     string
+		got, want map[string]int
+	}{
+		{"categories", catalog.Facets.Categories, map[string]int{"FLOWER": 2, "EDIBLE": 1}},
+		{"medical", catalog.Facets.Medical, map[string]int{"true": 2, "false": 1}},
+		{"in stock", catalog.Facets.InStock, map[string]int{"true": 1, "false": 1}},
+	} {
+		if !maps.Equal(tt.got, tt.want) {
+			t.Errorf("%s facet = %v, want %v", tt.facet, tt.got, tt.want)
+		}
+	}
+}
+
+func TestSearchMarketplacePushesFiltersDown(t *testing.T) {
+	store, products := newSearchStore(t)
+	medical := true
+	if _, err := SearchMarketplace(context.Background(), store.Vendors, products, SearchQuery{Category: " flower", IsMedical: &medical}); err != nil {
+		t.Fatal(err)
+	}
+
+	// One query with every pushed-down filter, then one each without the
+	// category and medical filters for those two facets.
+	if len(products.filters) != 3 {
+		t.Fatalf("ran %d product searches, want 3: %+v", len(products.filters), products.filters)
+	}
+	for i, f := range products.filters {
+		if !slices.Equal(f.VendorIDs, []string{"live"}) {
+			t.Errorf("search %d: VendorIDs = %v, want only the listed vendor", i, f.VendorIDs)
+		}
+	}
+	all, noCategory, noMedical := products.filters[0], products.filters[1], products.filters[2]
+	if all.Category != "FLOWER" || all.IsMedical == nil || !*all.IsMedical {
+		t.Errorf("main search filter = %+v, want category FLOWER and medical", all)
+	}
+	if noCategory.Category != "" || noCategory.IsMedical == nil {
+		t.Errorf("category facet filter = %+v, want only the medical filter", noCategory)
+	}
+	if noMedical.Category != "FLOWER" || noMedical.IsMedical != nil {
+		t.Errorf("medical facet filter = %+v, want only the category filter", noMedical)
+	}
+
+	// Without those filters set, one search is enough.
+	products.filters = nil
+	if _, err := SearchMarketplace(context.Background(), store.Vendors, products, SearchQuery{InStock: true}); err != nil {
+		t.Fatal(err)
+	}
+	if len(products.filters) != 1 {
+		t.Errorf("ran %d product searches for an in-stock query, want 1", len(products.filters))
+	}
+}
+
+func TestSearchMarketplaceCursor(t *testing.T) {
+	store, products := newSearchStore(t)
+	ctx := context.Background()
+
+	var pages [][]string
+	q := SearchQuery{Limit: 2}
+	for {
+		catalog, err := SearchMarketplace(ctx, store.Vendors, products, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var page []string
+		for _, p := range catalog.Products {
+			page = append(page, p.ID)
+		}
+		pages = append(pages, page)
+		if catalog.NextCursor == "" {
+			break
+		}
+		q.Cursor = catalog.NextCursor
+		if len(pages) > 5 {
+			t.Fatal("cursor never ran out")
+		}
+	}
+	if want := [][]string{{"p1", "p2"}, {"p3", "p4"}, {"p5"}}; !slices.EqualFunc(pages, want, slices.Equal) {
+		t.Errorf("pages = %v, want %v", pages, want)
+	}
+
+	first, err := SearchMarketplace(ctx, store.Vendors, products, SearchQuery{Limit: 1, Category: "FLOWER"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A cursor only resumes the search that produced it; the page size may change.
+	if _, err := SearchMarketplace(ctx, store.Vendors, products, SearchQuery{Limit: 5, Category: "flower", Cursor: first.NextCursor}); err != nil {
+		t.Errorf("same filters, bigger page: %v", err)
+	}
+	for name, q := range map[string]SearchQuery{
+		"other category": {Category: "EDIBLE", Cursor: first.NextCursor},
+		"extra filter":   {Category: "FLOWER", InStock: true, Cursor: first.NextCursor},
+		"garbage":        {Category: "FLOWER", Cursor: "not-a-cursor"},
+	} {
+		if _, err := SearchMarketplace(ctx, store.Vendors, products, q); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: error = %v, want %v", name, err, ErrInvalidCursor)
+		}
+	}
+}
@@ -3,66 +3,58 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/omma"
 )
 
 var (
-	ErrLicenseExpired  = errors.New("license is expired based on state records")
-	ErrLicenseInactive = errors.New("license is inactive or invalid based on state records")
+	ErrLicenseExpired     = errors.New("license is expired based on state records")
+	ErrLicenseInactive    = errors.New("license is inactive or invalid based on state records")
+	ErrLicenseNotVerified = errors.New("license is not verified")
 )
 
-// VerifyLicenseExternally simulates the API call to the state (OMMA Verify).
-// In a real application, this would be an HTTP call to a licensed third-party verification service
-// or a government API endpoint.
-func VerifyLicenseExternally(ctx context.Context, licenseID string) (*models.OMMAVerificationResponse, error) {
-	// --- SIMULATION LOGIC START ---
-	// Real-world implementation would involve using net/http to call a secure endpoint.
-
-	// Simulate connection failure (e.g., network error)
-	if licenseID == "OMMA-FAIL" {
-		return nil, errors.New("external compliance API call failed due to timeout")
-	}
+// LicenseVerifier looks a license up in the state's records. *omma.Client is the
+// production implementation; local runs point it at omma.FakeServer.
+type LicenseVerifier interface {
+	Verify(ctx context.Context, licenseID string) (*models.OMMAVerificationResponse, error)
+}
 
-	// Simulate an active license expiring 6 months from now
-	if licenseID == "OMMA-ACTIVE-VENDOR" || licenseID == "OMMA-ACTIVE-BUYER" {
-		response := &models.OMMAVerificationResponse{
-			LicenseID:      licenseID,
-			IsActive:       true,
-			ExpirationDate: time.Now().AddDate(0, 6, 0), // Valid for 6 more months
-			EntityType:     "Dispensary",
-		}
-		return response, nil
+// VerifyLicenseExternally calls the state (OMMA Verify) through verifier and
+// interprets the result. An expired license returns the response together with
+// ErrLicenseExpired; an unknown or inactive license returns ErrLicenseInactive.
+func VerifyLicenseExternally(ctx context.Context, verifier LicenseVerifier, licenseID string) (*models.OMMAVerificationResponse, error) {
+	response, err := verifier.Verify(ctx, licenseID)
+	if errors.Is(err, omma.ErrLicenseNotFound) {
+		return nil, ErrLicenseInactive
+	} else if err != nil {
+		// Network failure or the state API being unavailable.
+		return nil, fmt.Errorf("external compliance API call failed: %w", err)
 	}
 
-	// Simulate an expired license
-	if licenseID == "OMMA-EXPIRED" {
-		response := &models.OMMAVerificationResponse{
-			LicenseID:      licenseID,
-			IsActive:       false,
-			ExpirationDate: time.Now().AddDate(-1, 0, 0), // Expired 1 year ago
-			EntityType:     "Grower",
-		}
+	if time.Now().After(response.ExpirationDate) {
 		return response, ErrLicenseExpired
 	}
-
-	// Simulate an invalid/non-existent license
-	return nil, ErrLicenseInactive
-	// --- SIMULATION LOGIC END ---
+	if !response.IsActive {
+		return response, ErrLicenseInactive
+	}
+	return response, nil
 }
 
 // CheckInternalLicenseStatus performs high-frequency checks on our own database record.
-// This is used for all transactional requests (e.g., placing an order, adding a product).
-func CheckInternalLicenseStatus(entityID string, licenseExpiry time.Time, status models.Status) error {
+// This is used for all transactional requests (e.g., placing an order, adding a product),
+// through ComplianceGate. Failures wrap ErrLicenseNotVerified or ErrLicenseExpired.
+func CheckInternalLicenseStatus(entityID string, licenseExpiry time.Time, status models.AccountComplianceStatus) error {
 	// 1. Check our cached compliance status
-	if status != "VERIFIED" {
-		return errors.New("internal status check failed: entity is not marked as VERIFIED")
+	if status != models.ComplianceVerified {
+		return fmt.Errorf("%w: %s is marked %s", ErrLicenseNotVerified, entityID, status)
 	}
 
 	// 2. Check the expiration date locally (fast check)
 	if time.Now().After(licenseExpiry) {
-		return errors.New("internal status check failed: license has expired")
+		return fmt.Errorf("%w: %s expired on %s", ErrLicenseExpired, entityID, licenseExpiry.Format("2006-01-02"))
 	}
 
 	return nil // License is internally valid and active
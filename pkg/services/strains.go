@@ -0,0 +1,367 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/strains"
+	"github.com/wesleywinston/wds/pkg/utils"
+)
+
+var ErrStrainNotFound = errors.New("strain not found")
+
+// errNothingToLink aborts linking a product that already has a strain, or whose
+// name matches none.
+var errNothingToLink = errors.New("nothing to link")
+
+// StrainImportResult summarizes one import run.
+type StrainImportResult struct {
+	Source         string `json:"source"`
+	Fetched        int    `json:"fetched"`
+	Created        int    `json:"created"`
+	Merged         int    `json:"merged"` // existing strains the dataset added to or corrected
+	Unchanged      int    `json:"unchanged"`
+	LinkedProducts int    `json:"linkedProducts"`
+}
+
+// StrainService owns the strain reference database. It imports a dataset on a
+// schedule, merging each record into the strain it duplicates (by name or alias)
+// rather than creating a second copy, and links products to their strain.
+type StrainService struct {
+	strains  repository.StrainRepository
+	products repository.ProductRepository
+	source   strains.Source
+	audit    *AuditLog
+	interval time.Duration
+
+	// importing serializes imports so two runs can't both create the same strain.
+	importing sync.Mutex
+
+	// mu guards index: every strain keyed by the normalized form of its name and
+	// each alias. It is rebuilt lazily after an import.
+	mu    sync.Mutex
+	index map[string]models.Strain
+}
+
+// NewStrainService returns a StrainService that imports from source every interval once Run is called.
+func NewStrainService(store *repository.Store, source strains.Source, audit *AuditLog, interval time.Duration) *StrainService {
+	return &StrainService{
+		strains:  store.Strains,
+		products: store.Products,
+		source:   source,
+		audit:    audit,
+		interval: interval,
+	}
+}
+
+// Run imports immediately and then on every tick. It blocks until ctx is cancelled.
+func (s *StrainService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if result, err := s.Import(ctx); err != nil {
+			log.Printf("Strain import from %s failed: %v", s.source.Name(), err)
+		} else {
+			log.Printf("Strain import from %s complete: %d fetched, %d created, %d merged, %d products linked",
+				result.Source, result.Fetched, result.Created, result.Merged, result.LinkedProducts)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Import fetches the dataset and merges it into the strain database, then links
+// any unlinked products whose names match a strain.
+func (s *StrainService) Import(ctx context.Context) (*StrainImportResult, error) {
+	records, err := s.source.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.importing.Lock()
+	defer s.importing.Unlock()
+
+	// --- STEP 1: Index the strains we already have ---
+	existing, err := s.strains.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]*models.Strain)
+	index := func(strain *models.Strain) {
+		for _, name := range append([]string{strain.Name}, strain.Aliases...) {
+			if _, taken := byKey[strains.Key(name)]; !taken {
+				byKey[strains.Key(name)] = strain
+			}
+		}
+	}
+	for i := range existing {
+		index(&existing[i])
+	}
+
+	// --- STEP 2: Merge each record into its strain, or start a new one ---
+	result := &StrainImportResult{Source: s.source.Name(), Fetched: len(records)}
+	created := make(map[*models.Strain]bool)
+	changed := make(map[*models.Strain]bool)
+	now := time.Now()
+	for _, rec := range records {
+		strain := matchRecord(byKey, rec)
+		if strain == nil {
+			strain = &models.Strain{
+				ID:        utils.NewID("strain"),
+				Name:      rec.Name,
+				Key:       strains.Key(rec.Name),
+				CreatedAt: now,
+			}
+			created[strain] = true
+		}
+		if mergeRecord(strain, rec, s.source.Name()) {
+			strain.UpdatedAt = now
+			changed[strain] = true
+		}
+		index(strain)
+	}
+
+	// --- STEP 3: Save ---
+	for strain := range changed {
+		if created[strain] {
+			err = s.strains.Create(ctx, *strain)
+			result.Created++
+		} else {
+			err = s.strains.Update(ctx, *strain)
+			result.Merged++
+		}
+		if err != nil {
+			return nil, fmt.Errorf("saving strain %s: %w", strain.Name, err)
+		}
+	}
+	result.Unchanged = len(existing) - result.Merged
+	s.mu.Lock()
+	s.index = nil
+	s.mu.Unlock()
+
+	// --- STEP 4: Link products that predate their strain ---
+	if result.LinkedProducts, err = s.linkProducts(ctx); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, SystemEvent(models.AuditStrainsImported, models.AuditEntityStrain, result.Source), nil, result)
+	return result, nil
+}
+
+// matchRecord finds the strain rec duplicates: the one whose name or an alias
+// matches rec's name, or failing that one of rec's aliases.
+func matchRecord(byKey map[string]*models.Strain, rec strains.Record) *models.Strain {
+	if strain, ok := byKey[strains.Key(rec.Name)]; ok {
+		return strain
+	}
+	for _, alias := range rec.Aliases {
+		if strain, ok := byKey[strains.Key(alias)]; ok {
+			return strain
+		}
+	}
+	return nil
+}
+
+// mergeRecord folds rec into strain and reports whether anything changed. Aliases,
+// lineage and sources accumulate; the dataset's type and terpene profile replace
+// ours, since a newer dataset is a correction.
+func mergeRecord(strain *models.Strain, rec strains.Record, source string) bool {
+	changed := false
+	addUnique := func(list *[]string, values ...string) {
+		for _, v := range values {
+			v = strings.TrimSpace(v)
+			key := strains.Key(v)
+			if key == "" || slices.ContainsFunc(*list, func(have string) bool { return strains.Key(have) == key }) {
+				continue
+			}
+			*list = append(*list, v)
+			changed = true
+		}
+	}
+
+	for _, alias := range append([]string{rec.Name}, rec.Aliases...) {
+		if strains.Key(alias) != strain.Key {
+			addUnique(&strain.Aliases, alias)
+		}
+	}
+	addUnique(&strain.Lineage, rec.Lineage...)
+	addUnique(&strain.Sources, source)
+
+	if t := strains.ParseType(rec.Type); t != "" && t != strain.Type {
+		strain.Type = t
+		changed = true
+	}
+	if len(rec.Terpenes) > 0 && !slices.Equal(rec.Terpenes, strain.Terpenes) {
+		strain.Terpenes = slices.Clone(rec.Terpenes)
+		changed = true
+	}
+
+	// Keep slices non-nil so strains serialize as [] rather than null.
+	for _, list := range []*[]string{&strain.Aliases, &strain.Lineage, &strain.Terpenes} {
+		if *list == nil {
+			*list = []string{}
+		}
+	}
+	return changed
+}
+
+// loadIndex returns every strain keyed by its normalized name and aliases,
+// building it if an import has invalidated it. Callers must hold s.mu.
+func (s *StrainService) loadIndex(ctx context.Context) (map[string]models.Strain, error) {
+	if s.index != nil {
+		return s.index, nil
+	}
+	all, err := s.strains.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Canonical names are indexed before aliases so a name always wins over
+	// another strain's alias.
+	index := make(map[string]models.Strain)
+	for _, strain := range all {
+		index[strain.Key] = strain
+	}
+	for _, strain := range all {
+		for _, alias := range strain.Aliases {
+			if _, taken := index[strains.Key(alias)]; !taken {
+				index[strains.Key(alias)] = strain
+			}
+		}
+	}
+	s.index = index
+	return index, nil
+}
+
+// Get returns one strain by ID.
+func (s *StrainService) Get(ctx context.Context, id string) (*models.Strain, error) {
+	strain, err := s.strains.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrStrainNotFound
+	}
+	return strain, err
+}
+
+// Search returns strains whose name or an alias contains query, ordered by name.
+// An empty query matches every strain; strainType narrows by type.
+func (s *StrainService) Search(ctx context.Context, query string, strainType models.StrainType) ([]models.Strain, error) {
+	all, err := s.strains.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	want := strains.Key(query)
+	matched := []models.Strain{}
+	for _, strain := range all {
+		if strainType != "" && strain.Type != strainType {
+			continue
+		}
+		if slices.ContainsFunc(append([]string{strain.Name}, strain.Aliases...), func(name string) bool {
+			return strings.Contains(strains.Key(name), want)
+		}) {
+			matched = append(matched, strain)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return strings.ToLower(matched[i].Name) < strings.ToLower(matched[j].Name) })
+	return matched, nil
+}
+
+// Match finds the strain a product name refers to, or nil. The whole name may be
+// a strain's name or alias ("GG4"); otherwise the longest run of leading words
+// that is a strain's canonical name counts ("Blue Dream Pre-Roll 1g"). Aliases
+// must match the whole name, since short ones like "Cookies" are too ambiguous
+// to match as a prefix.
+func (s *StrainService) Match(ctx context.Context, productName string) (*models.Strain, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, err := s.loadIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if strain, ok := index[strains.Key(productName)]; ok {
+		return &strain, nil
+	}
+	words := strings.Fields(productName)
+	for n := len(words) - 1; n > 0; n-- {
+		key := strains.Key(strings.Join(words[:n], " "))
+		if strain, ok := index[key]; ok && strain.Key == key {
+			return &strain, nil
+		}
+	}
+	return nil, nil
+}
+
+// LinkProduct sets product.StrainID: a StrainID the vendor chose must exist; with
+// none, the product is linked to the strain its name matches, if any. A linked
+// product with no strain type of its own takes the strain's.
+func (s *StrainService) LinkProduct(ctx context.Context, product *models.Product) error {
+	var strain *models.Strain
+	var err error
+	if product.StrainID != "" {
+		strain, err = s.Get(ctx, product.StrainID)
+	} else {
+		strain, err = s.Match(ctx, product.Name)
+	}
+	if err != nil || strain == nil {
+		return err
+	}
+
+	product.StrainID = strain.ID
+	if product.Compliance.StrainType == "" {
+		product.Compliance.StrainType = strain.Type
+	}
+	return nil
+}
+
+// linkProducts links every product without a strain to the one its name matches.
+func (s *StrainService) linkProducts(ctx context.Context) (int, error) {
+	all, err := s.products.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	linked := 0
+	for _, product := range all {
+		if product.StrainID != "" {
+			continue
+		}
+		// Link the product as stored now, not as listed, so a vendor's edit or a
+		// reservation since the listing isn't overwritten.
+		var before models.Product
+		updated, err := s.products.Modify(ctx, product.ID, func(p *models.Product) error {
+			before = *p
+			if p.StrainID != "" {
+				return errNothingToLink
+			}
+			if err := s.LinkProduct(ctx, p); err != nil {
+				return err
+			}
+			if p.StrainID == "" {
+				return errNothingToLink
+			}
+			p.UpdatedAt = time.Now()
+			return nil
+		})
+		if errors.Is(err, errNothingToLink) || errors.Is(err, repository.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return linked, fmt.Errorf("linking product %s: %w", product.ID, err)
+		}
+		s.audit.Record(ctx, SystemEvent(models.AuditProductUpdated, models.AuditEntityProduct, updated.ID), before, updated)
+		linked++
+	}
+	return linked, nil
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/strains"
+)
+
+// staticSource serves a fixed dataset.
+type staticSource struct {
+	name    string
+	records []strains.Record
+}
+
+func (s staticSource) Name() string { return s.name }
+
+func (s staticSource) Fetch(ctx context.Context) ([]strains.Record, error) { return s.records, nil }
+
+func TestMergeRecord(t *testing.T) {
+	base := func() models.Strain {
+		return models.Strain{
+			Name: "Gorilla Glue #4", Key: "gorillaglue4", Type: models.StrainHybrid,
+			Aliases: []string{"GG4"}, Lineage: []string{"Chem's Sister"}, Terpenes: []string{"Caryophyllene", "Myrcene"}, Sources: []string{"old.json"},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		rec         strains.Record
+		source      string
+		wantChanged bool
+		want        func(s *models.Strain)
+	}{
+		{
+			name:   "same record from the same source changes nothing",
+			rec:    strains.Record{Name: "Gorilla Glue 4", Aliases: []string{"gg4"}, Type: "hybrid", Lineage: []string{"chems sister"}, Terpenes: []string{"Caryophyllene", "Myrcene"}},
+			source: "old.json",
+		},
+		{
+			name:        "aliases, lineage and sources accumulate",
+			rec:         strains.Record{Name: "Original Glue", Aliases: []string{"GG4", "Glue"}, Lineage: []string{"Sour Dubb"}},
+			source:      "new.json",
+			wantChanged: true,
+			want: func(s *models.Strain) {
+				s.Aliases = []string{"GG4", "Original Glue", "Glue"}
+				s.Lineage = []string{"Chem's Sister", "Sour Dubb"}
+				s.Sources = []string{"old.json", "new.json"}
+			},
+		},
+		{
+			name:        "type is corrected",
+			rec:         strains.Record{Name: "Gorilla Glue #4", Type: "Indica"},
+			source:      "old.json",
+			wantChanged: true,
+			want:        func(s *models.Strain) { s.Type = models.StrainIndica },
+		},
+		{
+			name:   "unknown type is ignored",
+			rec:    strains.Record{Name: "Gorilla Glue #4", Type: "mystery"},
+			source: "old.json",
+		},
+		{
+			name:        "terpene profile is replaced",
+			rec:         strains.Record{Name: "Gorilla Glue #4", Terpenes: []string{"Limonene"}},
+			source:      "old.json",
+			wantChanged: true,
+			want:        func(s *models.Strain) { s.Terpenes = []string{"Limonene"} },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, want := base(), base()
+			if tt.want != nil {
+				tt.want(&want)
+			}
+			if changed := mergeRecord(&got, tt.rec, tt.source); changed != tt.wantChanged {
+				t.Errorf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if got.Type != want.Type || !slices.Equal(got.Aliases, want.Aliases) || !slices.Equal(got.Lineage, want.Lineage) ||
+				!slices.Equal(got.Terpenes, want.Terpenes) || !slices.Equal(got.Sources, want.Sources) {
+				t.Errorf("merged = %+v\nwant     %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestStrainServiceImport(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	existing := models.Strain{ID: "strain_gg4", Name: "Gorilla Glue #4", Key: "gorillaglue4", Aliases: []string{"GG4"}, Sources: []string{"old.json"}}
+	if err := store.Strains.Create(ctx, existing); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []models.Product{
+		{ID: "p1", Name: "Blue Dream Pre-Roll 1g"},
+		{ID: "p2", Name: "GG4"},
+		{ID: "p3", Name: "House Blend"},
+	} {
+		if err := store.Products.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source := staticSource{name: "new.json", records: []strains.Record{
+		{Name: "Original Glue", Aliases: []string{"GG4"}, Type: "hybrid"}, // matched through an alias
+		{Name: "gorilla-glue 4"}, // matched by name
+		{Name: "Blue Dream", Type: "Sativa-dominant hybrid", Lineage: []string{"Blueberry", "Haze"}},
+		{Name: "Blue Dream", Aliases: []string{"BD"}}, // a duplicate within the dataset
+	}}
+	svc := NewStrainService(store, source, NewAuditLog(store.Audit), time.Hour)
+
+	runs := []struct {
+		name       string
+		want       StrainImportResult
+		wantTotal  int
+		wantLinked map[string]string // product ID -> strain name
+	}{
+		{
+			name:       "first import",
+			want:       StrainImportResult{Source: "new.json", Fetched: 4, Created: 1, Merged: 1, Unchanged: 0, LinkedProducts: 2},
+			wantTotal:  2,
+			wantLinked: map[string]string{"p1": "Blue Dream", "p2": "Gorilla Glue #4", "p3": ""},
+		},
+		{
+			name:       "re-importing the same dataset changes nothing",
+			want:       StrainImportResult{Source: "new.json", Fetched: 4, Unchanged: 2},
+			wantTotal:  2,
+			wantLinked: map[string]string{"p1": "Blue Dream", "p2": "Gorilla Glue #4", "p3": ""},
+		},
+	}
+	for _, run := range runs {
+		got, err := svc.Import(ctx)
+		if err != nil {
+			t.Fatalf("%s: %v", run.name, err)
+		}
+		if *got != run.want {
+			t.Errorf("%s: result = %+v, want %+v", run.name, *got, run.want)
+		}
+		all, _ := store.Strains.List(ctx)
+		if len(all) != run.wantTotal {
+			t.Errorf("%s: %d strains stored, want %d", run.name, len(all), run.wantTotal)
+		}
+		for productID, wantStrain := range run.wantLinked {
+			p, _ := store.Products.Get(ctx, productID)
+			name := ""
+			if p.StrainID != "" {
+				strain, err := store.Strains.Get(ctx, p.StrainID)
+				if err != nil {
+					t.Fatalf("%s: product %s links to %s: %v", run.name, productID, p.StrainID, err)
+				}
+				name = strain.Name
+			}
+			if name != wantStrain {
+				t.Errorf("%s: product %s linked to %q, want %q", run.name, productID, name, wantStrain)
+			}
+		}
+	}
+
+	gg4, _ := store.Strains.Get(ctx, "strain_gg4")
+	if !slices.Equal(gg4.Aliases, []string{"GG4", "Original Glue"}) || gg4.Type != models.StrainHybrid || !slices.Equal(gg4.Sources, []string{"old.json", "new.json"}) {
+		t.Errorf("merged strain = %+v", gg4)
+	}
+	blueDream, err := svc.Match(ctx, "BD")
+	if err != nil || blueDream == nil || blueDream.Name != "Blue Dream" || !slices.Equal(blueDream.Lineage, []string{"Blueberry", "Haze"}) {
+		t.Errorf("Match(BD) = %+v, %v; want the imported Blue Dream", blueDream, err)
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+)
+
+var ErrNoTaxJurisdiction = errors.New("shipping address must end in a 5-digit Oklahoma ZIP code")
+
+// zipPattern matches the ZIP (optionally ZIP+4) ending a free-form address, so a
+// street number like "12345 Main St" is never mistaken for it.
+var zipPattern = regexp.MustCompile(`\b(\d{5})(?:-\d{4})?\s*$`)
+
+// isOklahomaZIP reports whether zip is an Oklahoma ZIP; we only ship in-state.
+// Oklahoma's 3-digit prefixes are 730-731 and 734-749: 733 belongs to Texas
+// (Austin) and 732 is unassigned.
+func isOklahomaZIP(zip string) bool {
+	prefix, err := strconv.Atoi(zip[:3])
+	if err != nil {
+		return false
+	}
+	return (prefix >= 730 && prefix <= 731) || (prefix >= 734 && prefix <= 749)
+}
+
+// TaxJurisdiction is a local (city/county) sales tax rate applied on top of the state rate.
+type TaxJurisdiction struct {
+	Name        string   `json:"name"`
+	ZipPrefixes []string `json:"zipPrefixes"` // longest matching prefix wins, i.e. "731" or "73069"
+	Rate        float64  `json:"rate"`
+}
+
+// TaxExemption switches off excise and/or sales tax for buyers holding a license type.
+type TaxExemption struct {
+	Excise   bool   `json:"excise"`
+	SalesTax bool   `json:"salesTax"`
+	Reason   string `json:"reason"`
+}
+
+// TaxConfig holds every rate the tax engine uses. Version is copied onto each order
+// so it is always clear which rate table produced its totals.
+type TaxConfig struct {
+	Version           string                              `json:"version"`
+	ExciseRate        float64                             `json:"exciseRate"`
+	StateSalesTaxRate float64                             `json:"stateSalesTaxRate"`
+	Jurisdictions     []TaxJurisdiction                   `json:"jurisdictions"`
+	Exemptions        map[models.LicenseType]TaxExemption `json:"exemptions"`
+}
+
+// DefaultTaxConfig is the built-in rate table: the 7% state medical marijuana excise
+// tax, the 4.5% state sales tax and local rates for the largest municipalities.
+// Deployments should supply their own table through WDS_TAX_CONFIG.
+func DefaultTaxConfig() TaxConfig {
+	return TaxConfig{
+		Version:           "ok-2025-01",
+		ExciseRate:        0.07,
+		StateSalesTaxRate: 0.045,
+		Jurisdictions: []TaxJurisdiction{
+			{Name: "Oklahoma City", ZipPrefixes: []string{"731"}, Rate: 0.04125},
+			{Name: "Tulsa", ZipPrefixes: []string{"741"}, Rate: 0.0365},
+			{Name: "Norman", ZipPrefixes: []string{"73019", "73026", "73069", "73071", "73072"}, Rate: 0.04125},
+			{Name: "Edmond", ZipPrefixes: []string{"73003", "73012", "73013", "73025", "73034"}, Rate: 0.0375},
+		},
+		Exemptions: map[models.LicenseType]TaxExemption{
+			models.LicenseTypeProcessor: {SalesTax: true, Reason: "purchase of ingredients for manufacture"},
+		},
+	}
+}
+
+// LoadTaxConfig reads a TaxConfig from a JSON file.
+func LoadTaxConfig(path string) (TaxConfig, error) {
+	var cfg TaxConfig
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("parsing tax config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// TaxEngine computes order totals from a TaxConfig.
+type TaxEngine struct {
+	cfg TaxConfig
+}
+
+// NewTaxEngine returns a TaxEngine using cfg.
+func NewTaxEngine(cfg TaxConfig) *TaxEngine {
+	return &TaxEngine{cfg: cfg}
+}
+
+// jurisdiction returns the local rate for the ZIP in address. A ZIP we have no
+// local rate for pays state tax only.
+func (e *TaxEngine) jurisdiction(address string) (name string, rate float64, err error) {
+	match := zipPattern.FindStringSubmatch(address)
+	if match == nil {
+		return "", 0, ErrNoTaxJurisdiction
+	}
+	zip := match[1]
+	if !isOklahomaZIP(zip) {
+		return "", 0, fmt.Errorf("%w: %s is not an Oklahoma ZIP", ErrNoTaxJurisdiction, zip)
+	}
+
+	name, best := "ZIP "+zip+" (state only)", 0
+	for _, j := range e.cfg.Jurisdictions {
+		for _, prefix := range j.ZipPrefixes {
+			if strings.HasPrefix(zip, prefix) && len(prefix) > best {
+				name, rate, best = j.Name+" "+zip, j.Rate, len(prefix)
+			}
+		}
+	}
+	return name, rate, nil
+}
+
+// Apply fills in each line total and the order's SubTotal, ExciseTax, SalesTax and
+// TotalPrice, and records the rates used in order.Tax. Item prices and
+// ShippingCost must already be set. Each tax is computed on the subtotal and
+// rounded to the cent once per order (halves away from zero), so re-running
+// RecalculateTotals with order.Tax's rates reproduces the same totals.
+func (e *TaxEngine) Apply(order *models.Order, buyerLicense models.LicenseType) error {
+	name, localRate, err := e.jurisdiction(order.ShippingAddress)
+	if err != nil {
+		return err
+	}
+	exemption := e.cfg.Exemptions[buyerLicense]
+
+	applied := models.AppliedTax{
+		RateVersion:       e.cfg.Version,
+		Jurisdiction:      name,
+		ExciseRate:        e.cfg.ExciseRate,
+		StateSalesTaxRate: e.cfg.StateSalesTaxRate,
+		LocalSalesTaxRate: localRate,
+		ExciseExempt:      exemption.Excise,
+		SalesTaxExempt:    exemption.SalesTax,
+		ExemptionReason:   exemption.Reason,
+		CalculatedAt:      time.Now(),
+	}
+	order.Tax = applied
+	RecalculateTotals(order)
+	return nil
+}
+
+// RecalculateTotals recomputes an order's totals from its items and the rates
+// already recorded in order.Tax, i.e. when re-reading an old order.
+func RecalculateTotals(order *models.Order) {
+	subTotal := models.USDCents(0)
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.LineTotal = item.Price.Mul(int64(item.Quantity))
+		subTotal = subTotal.Add(item.LineTotal)
+	}
+
+	t := order.Tax
+	excise, sales := models.USDCents(0), models.USDCents(0)
+	if !t.ExciseExempt {
+		excise = subTotal.MulRate(t.ExciseRate)
+	}
+	if !t.SalesTaxExempt {
+		sales = subTotal.MulRate(t.StateSalesTaxRate + t.LocalSalesTaxRate)
+	}
+
+	order.SubTotal = subTotal
+	order.ExciseTax = excise
+	order.SalesTax = sales
+	order.TotalPrice = subTotal.Add(excise).Add(sales).Add(order.ShippingCost)
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wesleywinston/wds/pkg/models"
+)
+
+func TestTaxEngineApply(t *testing.T) {
+	engine := NewTaxEngine(DefaultTaxConfig())
+	hundred := []models.OrderItem{{ProductID: "p1", Quantity: 4, Price: models.USDCents(2500)}}
+
+	tests := []struct {
+		name             string
+		address          string
+		license          models.LicenseType
+		items            []models.OrderItem
+		shipping         int64
+		wantErr          error
+		wantJurisdiction string
+		wantExcise       int64
+		wantSales        int64
+		wantTotal        int64
+	}{
+		{
+			name: "Tulsa", address: "1 Main St, Tulsa OK 74103", license: models.LicenseTypeDispensary, items: hundred,
+			wantJurisdiction: "Tulsa 74103", wantExcise: 700, wantSales: 815, wantTotal: 11515,
+		},
+		{
+			name: "Oklahoma City rounds half a cent up", address: "100 N Broadway, Oklahoma City, OK 73102", license: models.LicenseTypeDispensary, items: hundred,
+			wantJurisdiction: "Oklahoma City 73102", wantExcise: 700, wantSales: 863, wantTotal: 11563,
+		},
+		{
+			name: "ZIP+4 and trailing space", address: "1 Main St, Tulsa OK 74103-1234  ", license: models.LicenseTypeDispensary, items: hundred,
+			wantJurisdiction: "Tulsa 74103", wantExcise: 700, wantSales: 815, wantTotal: 11515,
+		},
+		{
+			name: "no local rate pays state tax only", address: "1 Main St, Guymon OK 73942", license: models.LicenseTypeDispensary, items: hundred,
+			wantJurisdiction: "ZIP 73942 (state only)", wantExcise: 700, wantSales: 450, wantTotal: 11150,
+		},
+		{
+			name: "processors are exempt from sales tax", address: "1 Main St, Tulsa OK 74103", license: models.LicenseTypeProcessor, items: hundred,
+			wantJurisdiction: "Tulsa 74103", wantExcise: 700, wantSales: 0, wantTotal: 10700,
+		},
+		{
+			name: "shipping is added untaxed", address: "1 Main St, Tulsa OK 74103", license: models.LicenseTypeDispensary, items: hundred, shipping: 1500,
+			wantJurisdiction: "Tulsa 74103", wantExcise: 700, wantSales: 815, wantTotal: 13015,
+		},
+		{
+			name: "lines are summed before rounding", address: "1 Main St, Tulsa OK 74103", license: models.LicenseTypeDispensary,
+			items: []models.OrderItem{
+				{ProductID: "p1", Quantity: 3, Price: models.USDCents(333)},
+				{ProductID: "p2", Quantity: 1, Price: models.USDCents(1)},
+			},
+			wantJurisdiction: "Tulsa 74103", wantExcise: 70, wantSales: 82, wantTotal: 1152,
+		},
+		{
+			name: "a street number is not a ZIP", address: "12345 Main St, Tulsa OK", license: models.LicenseTypeDispensary, items: hundred,
+			wantErr: ErrNoTaxJurisdiction,
+		},
+		{
+			name: "out-of-state ZIP", address: "74103 Elm St, Dallas TX 75201", license: models.LicenseTypeDispensary, items: hundred,
+			wantErr: ErrNoTaxJurisdiction,
+		},
+		{
+			name: "eastern Oklahoma", address: "1 Main St, Stilwell OK 74960", license: models.LicenseTypeDispensary, items: hundred,
+			wantJurisdiction: "ZIP 74960 (state only)", wantExcise: 700, wantSales: 450, wantTotal: 11150,
+		},
+		{
+			name: "Austin's 733 prefix is Texas", address: "1 Main St, Austin TX 73301", license: models.LicenseTypeDispensary, items: hundred,
+			wantErr: ErrNoTaxJurisdiction,
+		},
+		{
+			name: "unassigned 732 prefix", address: "1 Main St, Anywhere OK 73201", license: models.LicenseTypeDispensary, items: hundred,
+			wantErr: ErrNoTaxJurisdiction,
+		},
+		{
+			name: "ZIP must end the address", address: "Tulsa OK 74103, Suite 5", license: models.LicenseTypeDispensary, items: hundred,
+			wantErr: ErrNoTaxJurisdiction,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &models.Order{
+				Items:           append([]models.OrderItem(nil), tt.items...),
+				ShippingAddress: tt.address,
+				ShippingCost:    models.USDCents(tt.shipping),
+			}
+			err := engine.Apply(order, tt.license)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Apply error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if order.Tax.Jurisdiction != tt.wantJurisdiction || order.Tax.RateVersion != "ok-2025-01" {
+				t.Errorf("tax = %q (%s), want %q", order.Tax.Jurisdiction, order.Tax.RateVersion, tt.wantJurisdiction)
+			}
+			if !order.ExciseTax.Equal(models.USDCents(tt.wantExcise)) || !order.SalesTax.Equal(models.USDCents(tt.wantSales)) || !order.TotalPrice.Equal(models.USDCents(tt.wantTotal)) {
+				t.Errorf("excise %v, sales %v, total %v; want %v, %v, %v", order.ExciseTax, order.SalesTax, order.TotalPrice,
+					models.USDCents(tt.wantExcise), models.USDCents(tt.wantSales), models.USDCents(tt.wantTotal))
+			}
+
+			// The rates recorded on the order reproduce its totals.
+			again := *order
+			RecalculateTotals(&again)
+			if !again.TotalPrice.Equal(order.TotalPrice) || !again.SalesTax.Equal(order.SalesTax) {
+				t.Errorf("RecalculateTotals = %v, Apply = %v", again.TotalPrice, order.TotalPrice)
+			}
+		})
+	}
+}
+
+func TestTaxEngineLongestPrefixWins(t *testing.T) {
+	cfg := DefaultTaxConfig()
+	cfg.Jurisdictions = []TaxJurisdiction{
+		{Name: "County", ZipPrefixes: []string{"731"}, Rate: 0.01},
+		{Name: "City", ZipPrefixes: []string{"73102"}, Rate: 0.02},
+	}
+	engine := NewTaxEngine(cfg)
+
+	tests := []struct {
+		address string
+		want    string
+		rate    float64
+	}{
+		{"Oklahoma City OK 73102", "City 73102", 0.02},
+		{"Oklahoma City OK 73104", "County 73104", 0.01},
+	}
+	for _, tt := range tests {
+		order := &models.Order{ShippingAddress: tt.address}
+		if err := engine.Apply(order, models.LicenseTypeDispensary); err != nil {
+			t.Fatalf("Apply(%q): %v", tt.address, err)
+		}
+		if order.Tax.Jurisdiction != tt.want || order.Tax.LocalSalesTaxRate != tt.rate {
+			t.Errorf("Apply(%q) = %q at %v, want %q at %v", tt.address, order.Tax.Jurisdiction, order.Tax.LocalSalesTaxRate, tt.want, tt.rate)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+)
+
+func TestTokenRoundTrip(t *testing.T) {
+	issuer := NewTokenIssuer([]byte("test-secret"), time.Hour)
+	user := models.User{ID: "user_1", Email: "wes@example.com", Role: models.RoleVendor}
+
+	token, err := issuer.Issue(user)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	claims, err := issuer.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.UserID != user.ID || claims.Email != user.Email || claims.Role != user.Role {
+		t.Errorf("claims = %+v, want the user's ID, email and role", claims)
+	}
+	if got := time.Duration(claims.ExpiresAt-claims.IssuedAt) * time.Second; got != time.Hour {
+		t.Errorf("token lifetime = %v, want 1h", got)
+	}
+}
+
+func TestTokenRejected(t *testing.T) {
+	issuer := NewTokenIssuer([]byte("test-secret"), time.Hour)
+	token, err := issuer.Issue(models.User{ID: "user_1", Role: models.RoleBuyer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+
+	// Swap in a payload claiming the admin role, keeping the buyer's signature.
+	forged, _ := NewTokenIssuer([]byte("other-secret"), time.Hour).Issue(models.User{ID: "user_1", Role: models.RoleAdmin})
+	tampered := parts[0] + "." + strings.Split(forged, ".")[1] + "." + parts[2]
+
+	for name, bad := range map[string]string{
+		"empty":            "",
+		"two parts":        parts[0] + "." + parts[1],
+		"other header":     "eyJhbGciOiJub25lIn0." + parts[1] + "." + parts[2],
+		"tampered payload": tampered,
+		"other secret":     forged,
+	} {
+		if _, err := issuer.Verify(bad); !errors.Is(err, ErrTokenInvalid) {
+			t.Errorf("%s: error = %v, want %v", name, err, ErrTokenInvalid)
+		}
+	}
+
+	issuer.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if _, err := issuer.Verify(token); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("after the TTL: error = %v, want %v", err, ErrTokenExpired)
+	}
+}
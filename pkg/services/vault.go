@@ -0,0 +1,553 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+	"github.com/wesleywinston/wds/pkg/utils"
+)
+
+const (
+	MaxReorderLists     = 50
+	MaxReorderListItems = 200
+)
+
+var (
+	ErrVaultNotAllowed        = errors.New("vault not allowed")
+	ErrVaultItemNotFound      = errors.New("vendor or product not found")
+	ErrInvalidReorderList     = errors.New("invalid reorder list")
+	ErrReorderListNotFound    = errors.New("reorder list not found")
+	ErrReorderPricesChanged   = errors.New("prices have changed since this list was saved")
+	errReorderProductNotFound = errors.New("product no longer exists")
+)
+
+// VaultView is a vault with its saved IDs resolved against the catalog as it is
+// right now, so buyers can see what has gone out of stock or off the market.
+type VaultView struct {
+	BuyerID          string            `json:"buyerID"`
+	FavoriteVendors  []SavedVendor     `json:"favoriteVendors"`
+	FavoriteProducts []SavedProduct    `json:"favoriteProducts"`
+	ReorderLists     []ReorderListView `json:"reorderLists"`
+	UpdatedAt        time.Time         `json:"updatedAt,omitzero"`
+}
+
+// SavedVendor is a favorited vendor. Available is false once the vendor takes its
+// menu down or its license lapses; the favorite is kept in case it comes back.
+type SavedVendor struct {
+	ID           string `json:"id"`
+	BusinessName string `json:"businessName,omitempty"`
+	Available    bool   `json:"available"`
+}
+
+// SavedProduct is a favorited product. Products that have been deleted are listed
+// with only their ID so the buyer can remove them.
+type SavedProduct struct {
+	ID             string        `json:"id"`
+	Name           string        `json:"name,omitempty"`
+	VendorID       string        `json:"vendorID,omitempty"`
+	PricePerUnit   *models.Money `json:"pricePerUnit,omitempty"`
+	AvailableUnits int           `json:"availableUnits"`
+	Available      bool          `json:"available"`
+}
+
+// ReorderListView is a reorder list with each line checked against the catalog.
+type ReorderListView struct {
+	models.ReorderList
+	Lines []ReorderLine `json:"lines"`
+	// Ready is true when every line could be ordered as-is right now.
+	Ready bool `json:"ready"`
+}
+
+// ReorderLine is one list item as it would be ordered now. Issue explains why it
+// couldn't be, i.e. "only 4 units of Blue Dream available".
+type ReorderLine struct {
+	ProductID      string        `json:"productID"`
+	Name           string        `json:"name,omitempty"`
+	Quantity       int           `json:"quantity"`
+	SavedPrice     models.Money  `json:"savedPrice"`
+	CurrentPrice   *models.Money `json:"currentPrice,omitempty"`
+	AvailableUnits int           `json:"availableUnits"`
+	Issue          string        `json:"issue,omitempty"`
+}
+
+// ReorderDraft is what a buyer submits to order a list. The order is refused if
+// any price has moved since the list was saved, unless AcceptedPrices holds the
+// new price the buyer was shown for that product, so a buyer never reorders at
+// a price they haven't seen.
+type ReorderDraft struct {
+	ShippingAddress string
+	PaymentMethod   models.PaymentMethod
+	DeliveryDate    string
+	// AcceptedPrices is the unit price the buyer accepted for each changed line,
+	// by product ID, as reported by the list view or a refused reorder.
+	AcceptedPrices map[string]models.Money
+}
+
+// PriceChange is a line whose unit price differs from the one saved on the list.
+type PriceChange struct {
+	ProductID string       `json:"productID"`
+	Name      string       `json:"name"`
+	Was       models.Money `json:"was"`
+	Now       models.Money `json:"now"`
+}
+
+// ReorderResult is the order placed from a list and the price changes it accepted.
+type ReorderResult struct {
+	Order        *models.Order `json:"order"`
+	PriceChanges []PriceChange `json:"priceChanges"`
+}
+
+// VaultService manages buyers' vaults. Reordering goes through OrderService.Place,
+// so a list is held to exactly the same price, stock and license checks as an
+// order typed in by hand.
+type VaultService struct {
+	vaults   repository.VaultRepository
+	vendors  repository.VendorRepository
+	products repository.ProductRepository
+	orders   *OrderService
+
+	// locks holds a mutex per buyer, serializing changes to that buyer's vault so
+	// two teammates editing at once don't overwrite each other, and one list
+	// can't be ordered twice concurrently. Other buyers aren't held up. An entry
+	// lives only while someone holds or waits for it.
+	mu    sync.Mutex
+	locks map[string]*vaultLock
+}
+
+// vaultLock is one buyer's mutex and the number of callers holding or waiting for it.
+type vaultLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NewVaultService returns a VaultService that places reorders through orders.
+func NewVaultService(store *repository.Store, orders *OrderService) *VaultService {
+	return &VaultService{
+		vaults:   store.Vaults,
+		vendors:  store.Vendors,
+		products: store.Products,
+		orders:   orders,
+		locks:    make(map[string]*vaultLock),
+	}
+}
+
+// lock locks buyerID's vault and returns the function that unlocks it.
+func (s *VaultService) lock(buyerID string) func() {
+	s.mu.Lock()
+	l, ok := s.locks[buyerID]
+	if !ok {
+		l = &vaultLock{}
+		s.locks[buyerID] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		s.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(s.locks, buyerID)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// load returns the caller's business's vault, or an empty one if nothing has been saved yet.
+func (s *VaultService) load(ctx context.Context, user *models.User) (*models.Vault, error) {
+	if user.AssociatedEntityID == "" {
+		return nil, fmt.Errorf("%w: register your business before using the vault", ErrVaultNotAllowed)
+	}
+	vault, err := s.vaults.Get(ctx, user.AssociatedEntityID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return &models.Vault{BuyerID: user.AssociatedEntityID, FavoriteVendors: []string{}, FavoriteProducts: []string{}, ReorderLists: []models.ReorderList{}}, nil
+	}
+	return vault, err
+}
+
+func (s *VaultService) save(ctx context.Context, vault *models.Vault) error {
+	vault.UpdatedAt = time.Now()
+	return s.vaults.Save(ctx, *vault)
+}
+
+// Get returns the caller's vault with every favorite and list line resolved.
+func (s *VaultService) Get(ctx context.Context, user *models.User) (*VaultView, error) {
+	vault, err := s.load(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &VaultView{
+		BuyerID:          vault.BuyerID,
+		FavoriteVendors:  make([]SavedVendor, 0, len(vault.FavoriteVendors)),
+		FavoriteProducts: make([]SavedProduct, 0, len(vault.FavoriteProducts)),
+		ReorderLists:     make([]ReorderListView, 0, len(vault.ReorderLists)),
+		UpdatedAt:        vault.UpdatedAt,
+	}
+
+	// Vendors are looked up once each, since lists and favorites share them.
+	vendors := make(map[string]*models.Vendor)
+	vendorLive := func(id string) (*models.Vendor, bool, error) {
+		vendor, seen := vendors[id]
+		if !seen {
+			vendor, err = s.vendors.Get(ctx, id)
+			if errors.Is(err, repository.ErrNotFound) {
+				vendor = nil
+			} else if err != nil {
+				return nil, false, err
+			}
+			vendors[id] = vendor
+		}
+		return vendor, vendor != nil && vendor.MenuEnabled && vendor.IsCompliant(), nil
+	}
+
+	for _, id := range vault.FavoriteVendors {
+		vendor, live, err := vendorLive(id)
+		if err != nil {
+			return nil, err
+		}
+		saved := SavedVendor{ID: id, Available: live}
+		if vendor != nil {
+			saved.BusinessName = vendor.BusinessName
+		}
+		view.FavoriteVendors = append(view.FavoriteVendors, saved)
+	}
+
+	for _, id := range vault.FavoriteProducts {
+		saved := SavedProduct{ID: id}
+		product, err := s.products.Get(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) {
+			view.FavoriteProducts = append(view.FavoriteProducts, saved)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		_, live, err := vendorLive(product.VendorID)
+		if err != nil {
+			return nil, err
+		}
+		saved.Name, saved.VendorID, saved.AvailableUnits = product.Name, product.VendorID, product.AvailableUnits
+		saved.PricePerUnit = &product.PricePerUnit
+		saved.Available = live && product.AvailableUnits > 0
+		view.FavoriteProducts = append(view.FavoriteProducts, saved)
+	}
+
+	for _, list := range vault.ReorderLists {
+		listView := ReorderListView{ReorderList: list, Lines: make([]ReorderLine, 0, len(list.Items)), Ready: true}
+		if _, live, err := vendorLive(list.VendorID); err != nil {
+			return nil, err
+		} else if !live {
+			listView.Ready = false
+		}
+		for _, item := range list.Items {
+			line := ReorderLine{ProductID: item.ProductID, Quantity: item.Quantity, SavedPrice: item.SavedPrice}
+			product, err := s.products.Get(ctx, item.ProductID)
+			if errors.Is(err, repository.ErrNotFound) {
+				line.Issue = errReorderProductNotFound.Error()
+			} else if err != nil {
+				return nil, err
+			} else {
+				line.Name, line.AvailableUnits = product.Name, product.AvailableUnits
+				line.CurrentPrice = &product.PricePerUnit
+				if err := checkQuantity(*product, item.Quantity); err != nil {
+					line.Issue = err.Error()
+				}
+			}
+			if line.Issue != "" {
+				listView.Ready = false
+			}
+			listView.Lines = append(listView.Lines, line)
+		}
+		view.ReorderLists = append(view.ReorderLists, listView)
+	}
+	return view, nil
+}
+
+// liveVendor returns a vendor whose menu buyers can currently see. Hidden vendors
+// are reported as not found, the same as GetVendorCatalog does.
+func (s *VaultService) liveVendor(ctx context.Context, id string) (*models.Vendor, error) {
+	vendor, err := s.vendors.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !vendor.MenuEnabled) {
+		return nil, fmt.Errorf("%w: vendor %s", ErrVaultItemNotFound, id)
+	}
+	return vendor, err
+}
+
+// liveProduct returns a product on a menu buyers can currently see.
+func (s *VaultService) liveProduct(ctx context.Context, id string) (*models.Product, error) {
+	product, err := s.products.Get(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: product %s", ErrVaultItemNotFound, id)
+	} else if err != nil {
+		return nil, err
+	}
+	if _, err := s.liveVendor(ctx, product.VendorID); errors.Is(err, ErrVaultItemNotFound) {
+		return nil, fmt.Errorf("%w: product %s", ErrVaultItemNotFound, id)
+	} else if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// SetFavoriteVendor saves or removes a favorite vendor. Both are idempotent; only
+// vendors with a live menu can be saved.
+func (s *VaultService) SetFavoriteVendor(ctx context.Context, user *models.User, vendorID string, favorite bool) error {
+	if favorite {
+		if _, err := s.liveVendor(ctx, vendorID); err != nil {
+			return err
+		}
+	}
+	return s.setFavorite(ctx, user, func(v *models.Vault) *[]string { return &v.FavoriteVendors }, vendorID, favorite)
+}
+
+// SetFavoriteProduct saves or removes a favorite product. Both are idempotent;
+// only products on a live menu can be saved.
+func (s *VaultService) SetFavoriteProduct(ctx context.Context, user *models.User, productID string, favorite bool) error {
+	if favorite {
+		if _, err := s.liveProduct(ctx, productID); err != nil {
+			return err
+		}
+	}
+	return s.setFavorite(ctx, user, func(v *models.Vault) *[]string { return &v.FavoriteProducts }, productID, favorite)
+}
+
+func (s *VaultService) setFavorite(ctx context.Context, user *models.User, field func(*models.Vault) *[]string, id string, favorite bool) error {
+	defer s.lock(user.AssociatedEntityID)()
+
+	vault, err := s.load(ctx, user)
+	if err != nil {
+		return err
+	}
+	ids := field(vault)
+	switch saved := slices.Contains(*ids, id); {
+	case favorite && !saved:
+		*ids = append(*ids, id)
+	case !favorite && saved:
+		*ids = slices.DeleteFunc(*ids, func(have string) bool { return have == id })
+	default:
+		return nil
+	}
+	return s.save(ctx, vault)
+}
+
+// buildList validates name and items for a list and prices each item. Items
+// already on previous keep their SavedPrice, so editing a quantity doesn't hide
+// a price change; new items are saved at the current price.
+func (s *VaultService) buildList(ctx context.Context, vault *models.Vault, listID, name string, items []models.ReorderItem, previous []models.ReorderItem) (string, []models.ReorderItem, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", nil, fmt.Errorf("%w: a list needs a name", ErrInvalidReorderList)
+	case len(name) > 80:
+		return "", nil, fmt.Errorf("%w: list names are limited to 80 characters", ErrInvalidReorderList)
+	case len(items) == 0:
+		return "", nil, fmt.Errorf("%w: a list needs at least one item", ErrInvalidReorderList)
+	case len(items) > MaxReorderListItems:
+		return "", nil, fmt.Errorf("%w: a list can hold at most %d items", ErrInvalidReorderList, MaxReorderListItems)
+	}
+	for _, list := range vault.ReorderLists {
+		if list.ID != listID && strings.EqualFold(list.Name, name) {
+			return "", nil, fmt.Errorf("%w: you already have a list named %q", ErrInvalidReorderList, list.Name)
+		}
+	}
+
+	var vendorID string
+	built := make([]models.ReorderItem, 0, len(items))
+	for _, item := range items {
+		if slices.ContainsFunc(built, func(have models.ReorderItem) bool { return have.ProductID == item.ProductID }) {
+			return "", nil, fmt.Errorf("%w: product %s appears more than once", ErrInvalidReorderList, item.ProductID)
+		}
+		if item.Quantity <= 0 {
+			return "", nil, fmt.Errorf("%w: quantity for product %s must be positive", ErrInvalidReorderList, item.ProductID)
+		}
+		product, err := s.liveProduct(ctx, item.ProductID)
+		if errors.Is(err, ErrVaultItemNotFound) {
+			return "", nil, fmt.Errorf("%w: product %s does not exist", ErrInvalidReorderList, item.ProductID)
+		} else if err != nil {
+			return "", nil, err
+		}
+		if vendorID == "" {
+			vendorID = product.VendorID
+		} else if product.VendorID != vendorID {
+			return "", nil, fmt.Errorf("%w: every item on a list must come from the same vendor", ErrInvalidReorderList)
+		}
+
+		saved := models.ReorderItem{ProductID: product.ID, Quantity: item.Quantity, SavedPrice: product.PricePerUnit}
+		if i := slices.IndexFunc(previous, func(p models.ReorderItem) bool { return p.ProductID == product.ID }); i >= 0 {
+			saved.SavedPrice = previous[i].SavedPrice
+		}
+		built = append(built, saved)
+	}
+	return vendorID, built, nil
+}
+
+// CreateList saves a new reorder list for the caller's business.
+func (s *VaultService) CreateList(ctx context.Context, user *models.User, name string, items []models.ReorderItem) (*models.ReorderList, error) {
+	defer s.lock(user.AssociatedEntityID)()
+
+	vault, err := s.load(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if len(vault.ReorderLists) >= MaxReorderLists {
+		return nil, fmt.Errorf("%w: you can keep at most %d lists", ErrInvalidReorderList, MaxReorderLists)
+	}
+	vendorID, built, err := s.buildList(ctx, vault, "", name, items, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	list := models.ReorderList{
+		ID:        utils.NewID("list"),
+		Name:      strings.TrimSpace(name),
+		VendorID:  vendorID,
+		Items:     built,
+		CreatedBy: user.ID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	vault.ReorderLists = append(vault.ReorderLists, list)
+	if err := s.save(ctx, vault); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// findList returns the index of listID in vault.
+func findList(vault *models.Vault, listID string) (int, error) {
+	i := slices.IndexFunc(vault.ReorderLists, func(l models.ReorderList) bool { return l.ID == listID })
+	if i < 0 {
+		return 0, ErrReorderListNotFound
+	}
+	return i, nil
+}
+
+// UpdateList replaces a list's name and items.
+func (s *VaultService) UpdateList(ctx context.Context, user *models.User, listID, name string, items []models.ReorderItem) (*models.ReorderList, error) {
+	defer s.lock(user.AssociatedEntityID)()
+
+	vault, err := s.load(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	i, err := findList(vault, listID)
+	if err != nil {
+		return nil, err
+	}
+	list := &vault.ReorderLists[i]
+	vendorID, built, err := s.buildList(ctx, vault, listID, name, items, list.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	list.Name, list.VendorID, list.Items = strings.TrimSpace(name), vendorID, built
+	list.UpdatedAt = time.Now()
+	if err := s.save(ctx, vault); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// DeleteList removes a list.
+func (s *VaultService) DeleteList(ctx context.Context, user *models.User, listID string) error {
+	defer s.lock(user.AssociatedEntityID)()
+
+	vault, err := s.load(ctx, user)
+	if err != nil {
+		return err
+	}
+	i, err := findList(vault, listID)
+	if err != nil {
+		return err
+	}
+	vault.ReorderLists = slices.Delete(vault.ReorderLists, i, i+1)
+	return s.save(ctx, vault)
+}
+
+// Reorder places a new order for everything on a list. Prices are compared with
+// the ones saved on the list first; the order itself is placed by
+// OrderService.Place, which re-checks stock, order limits and both licenses.
+// Place is given the prices the buyer agreed to (the saved ones, or the ones in
+// draft.AcceptedPrices), so a price changed in the meantime fails the order
+// rather than being charged. On success the list's
+// saved prices are brought up to date with what was charged.
+func (s *VaultService) Reorder(ctx context.Context, user *models.User, listID string, draft ReorderDraft) (*ReorderResult, error) {
+	defer s.lock(user.AssociatedEntityID)()
+
+	vault, err := s.load(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	i, err := findList(vault, listID)
+	if err != nil {
+		return nil, err
+	}
+	list := &vault.ReorderLists[i]
+
+	// --- STEP 1: Compare today's prices with the saved ones ---
+	changes := []PriceChange{}
+	var refused []string
+	items := make([]models.OrderItem, 0, len(list.Items))
+	agreed := make(map[string]models.Money, len(list.Items))
+	for _, item := range list.Items {
+		items = append(items, models.OrderItem{ProductID: item.ProductID, Quantity: item.Quantity})
+		agreed[item.ProductID] = item.SavedPrice
+		product, err := s.products.Get(ctx, item.ProductID)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue // Place reports the missing product
+		} else if err != nil {
+			return nil, err
+		}
+		if product.PricePerUnit.Equal(item.SavedPrice) {
+			continue
+		}
+		if accepted, ok := draft.AcceptedPrices[product.ID]; !ok || !accepted.Equal(product.PricePerUnit) {
+			refused = append(refused, fmt.Sprintf("%s (%s) %s -> %s", product.Name, product.ID, item.SavedPrice.Amount(), product.PricePerUnit.Amount()))
+			continue
+		}
+		changes = append(changes, PriceChange{ProductID: product.ID, Name: product.Name, Was: item.SavedPrice, Now: product.PricePerUnit})
+		agreed[item.ProductID] = draft.AcceptedPrices[product.ID]
+	}
+	if len(refused) > 0 {
+		return nil, fmt.Errorf("%w: %s; resubmit with these acceptedPrices to order at current prices",
+			ErrReorderPricesChanged, strings.Join(refused, ", "))
+	}
+
+	// --- STEP 2: Place the order exactly as if it had been typed in ---
+	order, err := s.orders.Place(ctx, user, OrderDraft{
+		Items:           items,
+		ShippingAddress: draft.ShippingAddress,
+		PaymentMethod:   draft.PaymentMethod,
+		DeliveryDate:    draft.DeliveryDate,
+		ExpectedPrices:  agreed,
+	})
+	if errors.Is(err, ErrPriceChanged) {
+		return nil, fmt.Errorf("%w: %v", ErrReorderPricesChanged, err)
+	} else if err != nil {
+		return nil, err
+	}
+
+	// --- STEP 3: Remember what was charged ---
+	// The order is placed, so a failure here only leaves the list's prices stale.
+	for j := range list.Items {
+		for _, line := range order.Items {
+			if line.ProductID == list.Items[j].ProductID {
+				list.Items[j].SavedPrice = line.Price
+			}
+		}
+	}
+	list.LastOrderID = order.ID
+	if err := s.save(ctx, vault); err != nil {
+		log.Printf("Error updating reorder list %s after order %s: %v", list.ID, order.ID, err)
+	}
+	return &ReorderResult{Order: order, PriceChanges: changes}, nil
+}
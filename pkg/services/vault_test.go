@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+	"github.com/wesleywinston/wds/pkg/repository"
+)
+
+func TestVaultServiceReorder(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	expires := time.Now().AddDate(1, 0, 0)
+	if err := store.Vendors.Create(ctx, models.Vendor{
+		ID: "vendor_1", OKStateLicenseID: "GAAA-4K7M-2Q9X-8B3N", LicenseType: models.LicenseTypeGrower,
+		LicenseExpirationDate: expires, ComplianceStatus: models.ComplianceVerified, MenuEnabled: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Buyers.Create(ctx, models.Buyer{
+		ID: "buyer_1", OKStateLicenseID: "DAAA-7H2L-5R8T-1C6W", LicenseType: models.LicenseTypeDispensary,
+		LicenseExpirationDate: expires, ComplianceStatus: models.ComplianceVerified,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Products.Create(ctx, models.Product{
+		ID: "p1", VendorID: "vendor_1", Name: "Blue Dream 1oz", PricePerUnit: models.USDCents(10000), AvailableUnits: 10, MinOrderQuantity: 1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	orders := NewOrderService(store, NewTaxEngine(DefaultTaxConfig()), NewComplianceGate(store, nil, time.Minute), nil, 48*time.Hour)
+	vaults := NewVaultService(store, orders)
+	buyer := &models.User{ID: "user_b", Role: models.RoleBuyer, AssociatedEntityID: "buyer_1"}
+
+	list, err := vaults.CreateList(ctx, buyer, "Weekly", []models.ReorderItem{{ProductID: "p1", Quantity: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Products.Modify(ctx, "p1", func(p *models.Product) error {
+		p.PricePerUnit = models.USDCents(11000)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		accepted  map[string]models.Money
+		wantErr   error
+		wantUnits int
+	}{
+		{"changed price not accepted", nil, ErrReorderPricesChanged, 10},
+		{"accepted price is out of date", map[string]models.Money{"p1": models.USDCents(10500)}, ErrReorderPricesChanged, 10},
+		{"accepted the current price", map[string]models.Money{"p1": models.USDCents(11000)}, nil, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := vaults.Reorder(ctx, buyer, list.ID, ReorderDraft{ShippingAddress: "1 Main St, Tulsa OK 74103", AcceptedPrices: tt.accepted})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Reorder error = %v, want %v", err, tt.wantErr)
+			}
+			if got, _ := store.Products.Get(ctx, "p1"); got.AvailableUnits != tt.wantUnits {
+				t.Errorf("units = %d, want %d", got.AvailableUnits, tt.wantUnits)
+			}
+			if err != nil {
+				return
+			}
+			if !result.Order.Items[0].Price.Equal(models.USDCents(11000)) || len(result.PriceChanges) != 1 {
+				t.Errorf("ordered at %s with changes %+v, want 110.00 and one change", result.Order.Items[0].Price, result.PriceChanges)
+			}
+			vault, _ := store.Vaults.Get(ctx, "buyer_1")
+			if saved := vault.ReorderLists[0]; !saved.Items[0].SavedPrice.Equal(models.USDCents(11000)) || saved.LastOrderID != result.Order.ID {
+				t.Errorf("list after reorder = %+v", saved)
+			}
+		})
+	}
+}
+
+// Per-buyer vault locks must be dropped once nobody holds them.
+func TestVaultServiceLocksAreReleased(t *testing.T) {
+	vaults := NewVaultService(repository.NewMemoryStore(), nil)
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := vaults.lock(fmt.Sprintf("buyer_%d", i%3))
+			time.Sleep(time.Millisecond)
+			unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(vaults.locks) != 0 {
+		t.Errorf("%d vault locks left behind, want 0", len(vaults.locks))
+	}
+}
@@ -0,0 +1,167 @@
+// Package strains reads strain reference datasets. A dataset is a JSON array of
+// Records (or an object with a "strains" array) served from a file or over HTTP;
+// services.StrainService merges them into the canonical strain database.
+package strains
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/wesleywinston/wds/pkg/models"
+)
+
+// maxDatasetSize caps how much of a dataset we read.
+const maxDatasetSize = 32 << 20
+
+// seed is a small built-in dataset so local runs have strains to link products to.
+//
+//go:embed seed.json
+var seed []byte
+
+// Record is one strain as a dataset publishes it. Type is free text such as
+// "Indica", "sativa-dominant hybrid" or "Hybrid".
+type Record struct {
+	Name     string   `json:"name"`
+	Aliases  []string `json:"aliases"`
+	Type     string   `json:"type"`
+	Lineage  []string `json:"lineage"`
+	Terpenes []string `json:"terpenes"`
+}
+
+// Source is where a dataset comes from.
+type Source interface {
+	// Name identifies the dataset, i.e. its path or URL. It is recorded on every
+	// strain the dataset contributes to.
+	Name() string
+	Fetch(ctx context.Context) ([]Record, error)
+}
+
+// NewSource returns the Source for location: an http(s) URL, a file path, or the
+// built-in seed dataset if location is empty.
+func NewSource(location string) Source {
+	switch {
+	case location == "":
+		return seedSource{}
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		return &HTTPSource{URL: location, Client: &http.Client{Timeout: 30 * time.Second}}
+	default:
+		return FileSource{Path: location}
+	}
+}
+
+type seedSource struct{}
+
+func (seedSource) Name() string { return "builtin:seed" }
+
+func (seedSource) Fetch(ctx context.Context) ([]Record, error) {
+	return Decode(bytes.NewReader(seed))
+}
+
+// FileSource reads a dataset from the local filesystem.
+type FileSource struct {
+	Path string
+}
+
+func (s FileSource) Name() string { return s.Path }
+
+func (s FileSource) Fetch(ctx context.Context) ([]Record, error) {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Decode(io.LimitReader(f, maxDatasetSize))
+}
+
+// HTTPSource downloads a dataset with a GET request.
+type HTTPSource struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s *HTTPSource) Name() string { return s.URL }
+
+func (s *HTTPSource) Fetch(ctx context.Context) ([]Record, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("strains: %s returned %s", s.URL, resp.Status)
+	}
+	return Decode(io.LimitReader(resp.Body, maxDatasetSize))
+}
+
+// Decode reads a dataset: either a JSON array of records or {"strains": [...]}.
+// Records without a name are dropped.
+func Decode(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var wrapped struct {
+			Strains []Record `json:"strains"`
+		}
+		err = json.Unmarshal(trimmed, &wrapped)
+		records = wrapped.Strains
+	} else {
+		err = json.Unmarshal(trimmed, &records)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("strains: invalid dataset: %w", err)
+	}
+
+	kept := records[:0]
+	for _, rec := range records {
+		rec.Name = strings.TrimSpace(rec.Name)
+		if Key(rec.Name) != "" {
+			kept = append(kept, rec)
+		}
+	}
+	return kept, nil
+}
+
+// Key normalizes a strain name for matching: "Gorilla Glue #4", "gorilla glue 4"
+// and "Gorilla-Glue #4" all become "gorillaglue4".
+func Key(name string) string {
+	name = strings.ReplaceAll(strings.ToLower(name), "&", "and")
+	var b strings.Builder
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ParseType maps a dataset's free-text type onto a StrainType, or "" if it can't tell.
+// Indica- or sativa-dominant strains are hybrids.
+func ParseType(s string) models.StrainType {
+	s = strings.ToLower(s)
+	switch {
+	case strings.Contains(s, "hybrid"), strings.Contains(s, "dominant"):
+		return models.StrainHybrid
+	case strings.Contains(s, "indica"):
+		return models.StrainIndica
+	case strings.Contains(s, "sativa"):
+		return models.StrainSativa
+	}
+	return ""
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 )
 
@@ -9,3 +11,13 @@ import (
 func FormatMessage(fullName []string) string {
 	return fmt.Sprintf("Server up and running! Greeting from utils, %s %s.", fullName[0], fullName[1])
 }
+
+// NewID returns a random document ID with the given prefix, i.e. "user_3f9c2a7d1e4b8c60".
+// IDs generated from time.Now() collide when two requests land in the same second.
+func NewID(prefix string) string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("utils: reading random bytes: %v", err))
+	}
+	return prefix + "_" + hex.EncodeToString(b)
+}